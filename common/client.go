@@ -1,12 +1,16 @@
 package common
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
 // HTTPClient interface for making HTTP requests
@@ -18,6 +22,16 @@ type HTTPClient interface {
 type Client struct {
 	config     *ClientConfig
 	httpClient HTTPClient
+	limiter    *RateLimiter
+
+	middlewares []Middleware
+
+	timeMu          sync.Mutex
+	timeEndpoint    string
+	timeOffset      int64 // server clock minus local clock, in milliseconds
+	lastTimeSync    time.Time
+	timeSyncAttempt time.Time     // last automatic sync, successful or not
+	timeSyncing     chan struct{} // closed once the automatic sync in progress ends
 }
 
 // NewClient creates a new API client
@@ -25,16 +39,17 @@ func NewClient(config *ClientConfig) *Client {
 	if config == nil {
 		config = DefaultConfig()
 	}
-	
+
 	if config.httpClient == nil {
 		config.httpClient = &http.Client{
 			Timeout: config.Timeout,
 		}
 	}
-	
+
 	return &Client{
 		config:     config,
 		httpClient: config.httpClient,
+		limiter:    NewRateLimiter(config.RateLimitMode),
 	}
 }
 
@@ -43,11 +58,38 @@ func (c *Client) SetHTTPClient(client HTTPClient) {
 	c.httpClient = client
 }
 
+// RateLimiter returns the rate limiter shared by all requests of the client
+func (c *Client) RateLimiter() *RateLimiter {
+	return c.limiter
+}
+
+// SetRateLimiter replaces the rate limiter, e.g. to share one budget between
+// several clients using the same IP or API key
+func (c *Client) SetRateLimiter(limiter *RateLimiter) {
+	c.limiter = limiter
+}
+
+// SetEndpointWeights registers the rate limit cost of endpoints, keyed by
+// "METHOD /path"
+func (c *Client) SetEndpointWeights(weights map[string]EndpointWeight) {
+	c.limiter.SetWeights(weights)
+}
+
+// SetRateLimits sets the exchange rate limits enforced by the client
+func (c *Client) SetRateLimits(limits []RateLimit) {
+	c.limiter.SetLimits(limits)
+}
+
 // GetConfig returns the client configuration
 func (c *Client) GetConfig() *ClientConfig {
 	return c.config
 }
 
+// SetSigner sets the signer used for signed requests
+func (c *Client) SetSigner(signer Signer) {
+	c.config.Signer = signer
+}
+
 // SetAPIKey sets the API key and secret
 func (c *Client) SetAPIKey(apiKey, secretKey string) {
 	c.config.APIKey = apiKey
@@ -61,106 +103,220 @@ func (c *Client) SetBaseURL(baseURL string) {
 
 // DoRequest performs an HTTP request
 func (c *Client) DoRequest(method, endpoint string, params map[string]any, signed bool) (*http.Response, error) {
-	requestURL := c.config.BaseURL + endpoint
-	
+	return c.DoRequestCtx(context.Background(), method, endpoint, params, signed)
+}
+
+// DoRequestCtx performs an HTTP request bound to ctx. Cancelling ctx aborts
+// the request, and a ctx deadline applies on top of ClientConfig.Timeout.
+func (c *Client) DoRequestCtx(ctx context.Context, method, endpoint string, params map[string]any, signed bool) (*http.Response, error) {
+	// Wait first so that the timestamp isn't aged by the rate limiter
+	if err := c.limiter.Wait(ctx, method, endpoint, params); err != nil {
+		return nil, err
+	}
+	if signed {
+		c.syncTimeIfStale(ctx)
+	}
+
+	built, err := c.BuildRequest(ctx, method, endpoint, params, signed)
+	if err != nil {
+		return nil, err
+	}
+
 	var body io.Reader
-	var queryString string
-	
-	if method == "GET" || method == "DELETE" {
-		// Add parameters to query string
-		if len(params) > 0 {
-			queryString = BuildQueryString(params)
-			requestURL += "?" + queryString
-		}
-	} else {
-		// Add parameters to request body
-		if len(params) > 0 {
-			formData := BuildQueryString(params)
-			body = bytes.NewBufferString(formData)
-		}
+	if built.Body != "" {
+		body = strings.NewReader(built.Body)
 	}
-	
-	req, err := http.NewRequest(method, requestURL, body)
+
+	req, err := http.NewRequestWithContext(ctx, method, built.URL, body)
 	if err != nil {
 		return nil, err
 	}
-	
+
+	logger := c.logger()
+	sent, _ := url.ParseQuery(built.Payload)
+	logger.DebugContext(ctx, "aster: request",
+		"method", method,
+		"endpoint", endpoint,
+		"signed", signed,
+		"params", logParams(sent),
+	)
+	start := time.Now()
+
 	// Set headers
 	if c.config.APIKey != "" {
 		req.Header.Set("X-MBX-APIKEY", c.config.APIKey)
 	}
-	
+
 	if method == "POST" || method == "PUT" {
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
-	
-	// Add signature for signed requests
-	if signed && c.config.SecretKey != "" {
-		signParams := make(map[string]string)
-		for k, v := range params {
-			signParams[k] = fmt.Sprintf("%v", v)
-		}
-		
-		// Add timestamp
-		timestamp := GetTimestamp()
-		signParams["timestamp"] = fmt.Sprintf("%d", timestamp)
-		
-		// Add recvWindow if not present
-		if _, exists := signParams["recvWindow"]; !exists {
-			signParams["recvWindow"] = fmt.Sprintf("%d", c.config.RecvWindow)
-		}
-		
-		signature := SignRequest(signParams, c.config.SecretKey)
-		
-		if method == "GET" || method == "DELETE" {
-			requestURL += "&signature=" + signature
-			req.URL, _ = url.Parse(requestURL)
-		} else {
-			// Add signature to form data
-			formData := BuildQueryString(params)
-			formData += "&timestamp=" + fmt.Sprintf("%d", timestamp)
-			formData += "&recvWindow=" + fmt.Sprintf("%d", c.config.RecvWindow)
-			formData += "&signature=" + signature
-			body = bytes.NewBufferString(formData)
-			req.Body = io.NopCloser(body)
-		}
+
+	resp, err := c.httpClient.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		logger.WarnContext(ctx, "aster: request failed",
+			"method", method,
+			"endpoint", endpoint,
+			"latency", latency,
+			"error", err,
+		)
+		return nil, err
 	}
-	
-	return c.httpClient.Do(req)
+
+	logger.DebugContext(ctx, "aster: response",
+		"method", method,
+		"endpoint", endpoint,
+		"status", resp.StatusCode,
+		"latency", latency,
+	)
+
+	c.limiter.Update(resp.Header)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+		c.limiter.Backoff(max(parseRetryAfter(resp.Header), minRateLimitBackoff))
+	}
+
+	return resp, nil
+}
+
+// logger returns the configured logger, or one that drops everything
+func (c *Client) logger() *slog.Logger {
+	return loggerOrDiscard(c.config.Logger)
+}
+
+// signer returns the configured signer, falling back to HMAC with the
+// secret key
+func (c *Client) signer() Signer {
+	if c.config.Signer != nil {
+		return c.config.Signer
+	}
+	if c.config.SecretKey != "" {
+		return NewHMACSigner(c.config.SecretKey)
+	}
+	return nil
 }
 
 // ParseResponse parses the HTTP response
 func (c *Client) ParseResponse(resp *http.Response, result any) error {
 	defer resp.Body.Close()
-	
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
-	
+
 	// Check for API errors
 	if resp.StatusCode >= 400 {
+		var method, endpoint string
+		if resp.Request != nil {
+			method = resp.Request.Method
+			endpoint = resp.Request.URL.Path
+		}
+
 		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
+		if err := json.Unmarshal(body, &apiErr); err == nil && (apiErr.Code != 0 || apiErr.Msg != "") {
+			apiErr.StatusCode = resp.StatusCode
+			apiErr.Header = resp.Header
+			apiErr.Method = method
+			apiErr.Endpoint = endpoint
 			return apiErr
 		}
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return HTTPError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			Header:     resp.Header,
+			Method:     method,
+			Endpoint:   endpoint,
+		}
 	}
-	
+
 	// Parse successful response
 	if result != nil {
 		return json.Unmarshal(body, result)
 	}
-	
+
 	return nil
 }
 
 // Do performs a request and parses the response
 func (c *Client) Do(method, endpoint string, params map[string]any, result any, signed bool) error {
-	resp, err := c.DoRequest(method, endpoint, params, signed)
-	if err != nil {
-		return err
+	return c.DoCtx(context.Background(), method, endpoint, params, result, signed)
+}
+
+// DoCtx performs a request bound to ctx and parses the response. Transient
+// failures are retried according to ClientConfig.Retry, and with TimeSync a
+// timestamp rejection re-syncs the clock and resends the request once.
+// Requests go through the middleware chain set with Use.
+func (c *Client) DoCtx(ctx context.Context, method, endpoint string, params map[string]any, result any, signed bool) error {
+	return c.handler()(ctx, &Request{
+		Method:   method,
+		Endpoint: endpoint,
+		Params:   params,
+		Signed:   signed,
+		Result:   result,
+	})
+}
+
+// do is the innermost handler of the middleware chain
+func (c *Client) do(ctx context.Context, req *Request) error {
+	policy := c.config.Retry
+	retryable := policy.retryable(req.Method, req.Endpoint, req.Params)
+	resynced := false
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.DoRequestCtx(ctx, req.Method, req.Endpoint, req.Params, req.Signed)
+		retry := retryable && attempt < policy.MaxAttempts
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if !retry || !isTransientError(err) {
+				return err
+			}
+			delay = policy.backoff(attempt)
+			c.logger().InfoContext(ctx, "aster: retrying request",
+				"method", req.Method,
+				"endpoint", req.Endpoint,
+				"attempt", attempt,
+				"delay", delay,
+				"error", err,
+			)
+		case retry && isTransientStatus(resp.StatusCode):
+			delay = max(policy.backoff(attempt), parseRetryAfter(resp.Header))
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			c.logger().InfoContext(ctx, "aster: retrying request",
+				"method", req.Method,
+				"endpoint", req.Endpoint,
+				"attempt", attempt,
+				"delay", delay,
+				"status", resp.StatusCode,
+			)
+		default:
+			err := c.ParseResponse(resp, req.Result)
+			if req.Signed && c.config.TimeSync && !resynced && errors.Is(err, ErrInvalidTimestamp) {
+				// The clock drifted since the last sync: measure it again
+				// and resend once
+				resynced = true
+				c.logger().InfoContext(ctx, "aster: timestamp rejected, syncing server time",
+					"method", req.Method,
+					"endpoint", req.Endpoint,
+				)
+				if c.SyncTime(ctx) == nil {
+					continue
+				}
+			}
+			if err != nil {
+				c.logger().DebugContext(ctx, "aster: request returned error",
+					"method", req.Method,
+					"endpoint", req.Endpoint,
+					"error", err,
+				)
+			}
+			return err
+		}
+
+		if err := sleepCtx(ctx, delay); err != nil {
+			return err
+		}
 	}
-	
-	return c.ParseResponse(resp, result)
-}
\ No newline at end of file
+}
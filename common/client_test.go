@@ -2,8 +2,11 @@ package common
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -21,11 +24,11 @@ func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
 func TestNewClient(t *testing.T) {
 	config := DefaultConfig()
 	client := NewClient(config)
-	
+
 	if client == nil {
 		t.Error("Expected client to not be nil")
 	}
-	
+
 	if client.GetConfig() != config {
 		t.Error("Expected client config to match provided config")
 	}
@@ -33,11 +36,11 @@ func TestNewClient(t *testing.T) {
 
 func TestNewClientWithNilConfig(t *testing.T) {
 	client := NewClient(nil)
-	
+
 	if client == nil {
 		t.Error("Expected client to not be nil")
 	}
-	
+
 	config := client.GetConfig()
 	if config.BaseURL != "https://sapi.asterdex.com" {
 		t.Errorf("Expected default base URL, got %s", config.BaseURL)
@@ -48,9 +51,9 @@ func TestSetAPIKey(t *testing.T) {
 	client := NewClient(nil)
 	apiKey := "test-api-key"
 	secretKey := "test-secret-key"
-	
+
 	client.SetAPIKey(apiKey, secretKey)
-	
+
 	config := client.GetConfig()
 	if config.APIKey != apiKey {
 		t.Errorf("Expected API key %s, got %s", apiKey, config.APIKey)
@@ -63,9 +66,9 @@ func TestSetAPIKey(t *testing.T) {
 func TestSetBaseURL(t *testing.T) {
 	client := NewClient(nil)
 	newURL := "https://test.example.com"
-	
+
 	client.SetBaseURL(newURL)
-	
+
 	config := client.GetConfig()
 	if config.BaseURL != newURL {
 		t.Errorf("Expected base URL %s, got %s", newURL, config.BaseURL)
@@ -75,9 +78,9 @@ func TestSetBaseURL(t *testing.T) {
 func TestSetHTTPClient(t *testing.T) {
 	client := NewClient(nil)
 	mockClient := &MockHTTPClient{}
-	
+
 	client.SetHTTPClient(mockClient)
-	
+
 	// Test that the client was set by checking if we can call DoRequest
 	// This is a basic test - in practice you'd want more comprehensive testing
 	if client.httpClient != mockClient {
@@ -88,7 +91,7 @@ func TestSetHTTPClient(t *testing.T) {
 func TestDoRequest(t *testing.T) {
 	client := NewClient(nil)
 	client.SetBaseURL("https://api.example.com")
-	
+
 	// Create a mock response
 	responseBody := `{"success": true}`
 	mockResponse := &http.Response{
@@ -96,18 +99,18 @@ func TestDoRequest(t *testing.T) {
 		Body:       io.NopCloser(bytes.NewBufferString(responseBody)),
 		Header:     make(http.Header),
 	}
-	
+
 	mockClient := &MockHTTPClient{
 		Response: mockResponse,
 	}
 	client.SetHTTPClient(mockClient)
-	
+
 	// Test GET request
 	resp, err := client.DoRequest("GET", "/test", map[string]any{"param": "value"}, false)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
-	
+
 	if resp.StatusCode != 200 {
 		t.Errorf("Expected status code 200, got %d", resp.StatusCode)
 	}
@@ -117,7 +120,7 @@ func TestDoRequestWithSignature(t *testing.T) {
 	client := NewClient(nil)
 	client.SetAPIKey("test-api-key", "test-secret-key")
 	client.SetBaseURL("https://api.example.com")
-	
+
 	// Create a mock response
 	responseBody := `{"success": true}`
 	mockResponse := &http.Response{
@@ -125,18 +128,18 @@ func TestDoRequestWithSignature(t *testing.T) {
 		Body:       io.NopCloser(bytes.NewBufferString(responseBody)),
 		Header:     make(http.Header),
 	}
-	
+
 	mockClient := &MockHTTPClient{
 		Response: mockResponse,
 	}
 	client.SetHTTPClient(mockClient)
-	
+
 	// Test signed request
 	resp, err := client.DoRequest("POST", "/test", map[string]any{"param": "value"}, true)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
-	
+
 	if resp.StatusCode != 200 {
 		t.Errorf("Expected status code 200, got %d", resp.StatusCode)
 	}
@@ -144,7 +147,7 @@ func TestDoRequestWithSignature(t *testing.T) {
 
 func TestParseResponse(t *testing.T) {
 	client := NewClient(nil)
-	
+
 	// Test successful response
 	responseBody := `{"message": "success", "data": {"id": 123}}`
 	mockResponse := &http.Response{
@@ -152,13 +155,13 @@ func TestParseResponse(t *testing.T) {
 		Body:       io.NopCloser(bytes.NewBufferString(responseBody)),
 		Header:     make(http.Header),
 	}
-	
+
 	var result map[string]any
 	err := client.ParseResponse(mockResponse, &result)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
-	
+
 	if result["message"] != "success" {
 		t.Errorf("Expected message 'success', got %v", result["message"])
 	}
@@ -166,7 +169,7 @@ func TestParseResponse(t *testing.T) {
 
 func TestParseResponseWithAPIError(t *testing.T) {
 	client := NewClient(nil)
-	
+
 	// Test API error response
 	responseBody := `{"code": -1121, "msg": "Invalid symbol."}`
 	mockResponse := &http.Response{
@@ -174,18 +177,18 @@ func TestParseResponseWithAPIError(t *testing.T) {
 		Body:       io.NopCloser(bytes.NewBufferString(responseBody)),
 		Header:     make(http.Header),
 	}
-	
+
 	var result map[string]any
 	err := client.ParseResponse(mockResponse, &result)
 	if err == nil {
 		t.Error("Expected error for API error response")
 	}
-	
+
 	apiErr, ok := err.(APIError)
 	if !ok {
 		t.Errorf("Expected APIError, got %T", err)
 	}
-	
+
 	if apiErr.Code != -1121 {
 		t.Errorf("Expected error code -1121, got %d", apiErr.Code)
 	}
@@ -194,7 +197,7 @@ func TestParseResponseWithAPIError(t *testing.T) {
 func TestDo(t *testing.T) {
 	client := NewClient(nil)
 	client.SetBaseURL("https://api.example.com")
-	
+
 	// Create a mock response
 	responseBody := `{"success": true}`
 	mockResponse := &http.Response{
@@ -202,19 +205,19 @@ func TestDo(t *testing.T) {
 		Body:       io.NopCloser(bytes.NewBufferString(responseBody)),
 		Header:     make(http.Header),
 	}
-	
+
 	mockClient := &MockHTTPClient{
 		Response: mockResponse,
 	}
 	client.SetHTTPClient(mockClient)
-	
+
 	// Test Do method
 	var result map[string]any
 	err := client.Do("GET", "/test", map[string]any{"param": "value"}, &result, false)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
-	
+
 	if result["success"] != true {
 		t.Errorf("Expected success true, got %v", result["success"])
 	}
@@ -223,13 +226,13 @@ func TestDo(t *testing.T) {
 func TestDoWithError(t *testing.T) {
 	client := NewClient(nil)
 	client.SetBaseURL("https://api.example.com")
-	
+
 	// Create a mock client that returns an error
 	mockClient := &MockHTTPClient{
 		Error: io.ErrUnexpectedEOF,
 	}
 	client.SetHTTPClient(mockClient)
-	
+
 	// Test Do method with error
 	var result map[string]any
 	err := client.Do("GET", "/test", map[string]any{"param": "value"}, &result, false)
@@ -247,10 +250,28 @@ func TestClientConfig(t *testing.T) {
 		Timeout:    60 * time.Second,
 		RecvWindow: 10000,
 	}
-	
+
 	client := NewClient(config)
-	
+
 	if client.GetConfig() != config {
 		t.Error("Expected client config to match provided config")
 	}
-}
\ No newline at end of file
+}
+
+func TestDoCtxCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewClient(nil)
+	client.SetBaseURL(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.DoCtx(ctx, "GET", "/test", nil, nil, false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
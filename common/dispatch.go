@@ -0,0 +1,172 @@
+package common
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what happens to a stream message when the queue of
+// its stream is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the queue, holding up the connection
+	// until the slow handler catches up: its other streams, the responses to
+	// Subscribe and Unsubscribe, and pongs. A handler that subscribes or
+	// unsubscribes then waits for the call timeout.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest queued message to make room
+	OverflowDropOldest
+	// OverflowDropNewest drops the incoming message
+	OverflowDropNewest
+	// OverflowConflate keeps only the latest message, replacing any queued
+	// one. Suits snapshot streams such as tickers and partial depth.
+	OverflowConflate
+)
+
+// String returns the name of the policy
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	case OverflowDropNewest:
+		return "drop_newest"
+	case OverflowConflate:
+		return "conflate"
+	default:
+		return "unknown"
+	}
+}
+
+// Default dispatch settings
+const (
+	defaultQueueSize      = 256
+	defaultOverflowPolicy = OverflowDropOldest
+)
+
+// streamQueue is the bounded queue of a stream. A single goroutine drains it
+// so that handlers see the messages of a stream one at a time, in the order
+// they were received.
+type streamQueue struct {
+	mu      sync.Mutex
+	items   []json.RawMessage
+	size    int
+	policy  OverflowPolicy
+	dropped atomic.Uint64
+
+	notEmpty chan struct{}
+	notFull  chan struct{}
+	done     chan struct{}
+	stop     sync.Once
+}
+
+func newStreamQueue(size int, policy OverflowPolicy) *streamQueue {
+	if size <= 0 || policy == OverflowConflate {
+		size = 1
+	}
+	return &streamQueue{
+		size:     size,
+		policy:   policy,
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// push queues data, applying the overflow policy when the queue is full
+func (q *streamQueue) push(data json.RawMessage, cancel <-chan struct{}) {
+	q.mu.Lock()
+	for len(q.items) >= q.size {
+		switch q.policy {
+		case OverflowDropNewest:
+			q.mu.Unlock()
+			q.dropped.Add(1)
+			return
+		case OverflowDropOldest, OverflowConflate:
+			q.items[0] = nil
+			q.items = q.items[1:]
+			q.dropped.Add(1)
+		default:
+			q.mu.Unlock()
+			select {
+			case <-q.notFull:
+			case <-q.done:
+				return
+			case <-cancel:
+				return
+			}
+			q.mu.Lock()
+		}
+	}
+	q.items = append(q.items, data)
+	q.mu.Unlock()
+
+	signal(q.notEmpty)
+}
+
+// pop waits for the next message. ok is false once the queue is closed or
+// cancel is done.
+func (q *streamQueue) pop(cancel <-chan struct{}) (data json.RawMessage, ok bool) {
+	for {
+		select {
+		case <-q.done:
+			return nil, false
+		default:
+		}
+
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			data = q.items[0]
+			q.items[0] = nil
+			q.items = q.items[1:]
+			q.mu.Unlock()
+			signal(q.notFull)
+			return data, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notEmpty:
+		case <-q.done:
+			return nil, false
+		case <-cancel:
+			return nil, false
+		}
+	}
+}
+
+// streamHandler is a handler of a stream. Handlers are registered by
+// pointer, so that one can be removed while others of the same stream stay.
+type streamHandler struct {
+	fn func(json.RawMessage)
+}
+
+// run passes the queued messages to the handlers returned by handlers until
+// the queue is closed or cancel is done
+func (q *streamQueue) run(handlers func() []*streamHandler, cancel <-chan struct{}) {
+	for {
+		data, ok := q.pop(cancel)
+		if !ok {
+			return
+		}
+		for _, handler := range handlers() {
+			handler.fn(data)
+		}
+	}
+}
+
+// close stops the queue, discarding pending messages
+func (q *streamQueue) close() {
+	q.stop.Do(func() { close(q.done) })
+}
+
+// signal wakes up a waiter on ch without blocking
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func queued(q *streamQueue) string {
+	var items []string
+	for _, item := range q.items {
+		items = append(items, string(item))
+	}
+	return strings.Join(items, ",")
+}
+
+func TestStreamQueueOverflow(t *testing.T) {
+	tests := []struct {
+		policy  OverflowPolicy
+		want    string
+		dropped uint64
+	}{
+		{OverflowDropOldest, "3,4,5", 2},
+		{OverflowDropNewest, "1,2,3", 2},
+		{OverflowConflate, "5", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			q := newStreamQueue(3, tt.policy)
+			for _, msg := range []string{"1", "2", "3", "4", "5"} {
+				q.push(json.RawMessage(msg), nil)
+			}
+			if got := queued(q); got != tt.want {
+				t.Errorf("Expected queue %s, got %s", tt.want, got)
+			}
+			if got := q.dropped.Load(); got != tt.dropped {
+				t.Errorf("Expected %d dropped, got %d", tt.dropped, got)
+			}
+		})
+	}
+}
+
+func TestStreamQueueBlock(t *testing.T) {
+	q := newStreamQueue(1, OverflowBlock)
+	q.push(json.RawMessage("1"), nil)
+
+	pushed := make(chan struct{})
+	go func() {
+		q.push(json.RawMessage("2"), nil)
+		close(pushed)
+	}()
+
+	if data, ok := q.pop(nil); !ok || string(data) != "1" {
+		t.Fatalf("Expected 1, got %s", data)
+	}
+	<-pushed
+	if data, ok := q.pop(nil); !ok || string(data) != "2" {
+		t.Fatalf("Expected 2, got %s", data)
+	}
+	if q.dropped.Load() != 0 {
+		t.Errorf("Expected nothing dropped, got %d", q.dropped.Load())
+	}
+
+	// Closing the queue releases a blocked push
+	q.push(json.RawMessage("3"), nil)
+	go q.close()
+	q.push(json.RawMessage("4"), nil)
+	if _, ok := q.pop(nil); ok {
+		t.Error("Expected pop to fail on a closed queue")
+	}
+}
@@ -0,0 +1,215 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Documented API error codes
+const (
+	ErrCodeUnknown                      = -1000
+	ErrCodeDisconnected                 = -1001
+	ErrCodeUnauthorized                 = -1002
+	ErrCodeTooManyRequests              = -1003
+	ErrCodeUnexpectedResponse           = -1006
+	ErrCodeTimeout                      = -1007
+	ErrCodeTooManyOrders                = -1015
+	ErrCodeServiceShuttingDown          = -1016
+	ErrCodeInvalidTimestamp             = -1021
+	ErrCodeInvalidSignature             = -1022
+	ErrCodeIllegalChars                 = -1100
+	ErrCodeTooManyParameters            = -1101
+	ErrCodeMandatoryParamMissing        = -1102
+	ErrCodeUnknownParam                 = -1103
+	ErrCodeBadPrecision                 = -1111
+	ErrCodeInvalidOrderType             = -1116
+	ErrCodeInvalidSide                  = -1117
+	ErrCodeBadSymbol                    = -1121
+	ErrCodeInvalidListenKey             = -1125
+	ErrCodeNewOrderRejected             = -2010
+	ErrCodeCancelRejected               = -2011
+	ErrCodeNoSuchOrder                  = -2013
+	ErrCodeBadAPIKeyFormat              = -2014
+	ErrCodeRejectedAPIKey               = -2015
+	ErrCodeBalanceNotSufficient         = -2018
+	ErrCodeMarginNotSufficient          = -2019
+	ErrCodeUnableToFill                 = -2020
+	ErrCodeOrderWouldImmediatelyTrigger = -2021
+	ErrCodeReduceOnlyReject             = -2022
+	ErrCodeNoNeedToChangeMarginType     = -4046
+	ErrCodeNoNeedToChangePositionSide   = -4059
+)
+
+// Error categories, usable with errors.Is on any error returned by a client
+var (
+	ErrRateLimited           = errors.New("rate limited")
+	ErrIPBanned              = errors.New("IP banned")
+	ErrUnauthorized          = errors.New("unauthorized")
+	ErrInvalidSignature      = errors.New("invalid signature")
+	ErrInvalidTimestamp      = errors.New("timestamp outside recvWindow")
+	ErrInvalidParameter      = errors.New("invalid parameter")
+	ErrOrderRejected         = errors.New("order rejected")
+	ErrInsufficientBalance   = errors.New("insufficient balance")
+	ErrUnknownOrder          = errors.New("unknown order")
+	ErrMarginTypeUnchanged   = errors.New("margin type unchanged")
+	ErrPositionSideUnchanged = errors.New("position side unchanged")
+	ErrServer                = errors.New("server error")
+)
+
+// codeCategories maps API error codes to the categories they belong to
+var codeCategories = map[int][]error{
+	ErrCodeUnknown:                      {ErrServer},
+	ErrCodeDisconnected:                 {ErrServer},
+	ErrCodeUnauthorized:                 {ErrUnauthorized},
+	ErrCodeTooManyRequests:              {ErrRateLimited},
+	ErrCodeUnexpectedResponse:           {ErrServer},
+	ErrCodeTimeout:                      {ErrServer},
+	ErrCodeTooManyOrders:                {ErrRateLimited},
+	ErrCodeServiceShuttingDown:          {ErrServer},
+	ErrCodeInvalidTimestamp:             {ErrInvalidTimestamp},
+	ErrCodeInvalidSignature:             {ErrInvalidSignature},
+	ErrCodeIllegalChars:                 {ErrInvalidParameter},
+	ErrCodeTooManyParameters:            {ErrInvalidParameter},
+	ErrCodeMandatoryParamMissing:        {ErrInvalidParameter},
+	ErrCodeUnknownParam:                 {ErrInvalidParameter},
+	ErrCodeBadPrecision:                 {ErrInvalidParameter},
+	ErrCodeInvalidOrderType:             {ErrInvalidParameter},
+	ErrCodeInvalidSide:                  {ErrInvalidParameter},
+	ErrCodeBadSymbol:                    {ErrInvalidParameter},
+	ErrCodeInvalidListenKey:             {ErrInvalidParameter},
+	ErrCodeNewOrderRejected:             {ErrOrderRejected},
+	ErrCodeCancelRejected:               {ErrOrderRejected},
+	ErrCodeNoSuchOrder:                  {ErrUnknownOrder},
+	ErrCodeBadAPIKeyFormat:              {ErrUnauthorized},
+	ErrCodeRejectedAPIKey:               {ErrUnauthorized},
+	ErrCodeBalanceNotSufficient:         {ErrOrderRejected, ErrInsufficientBalance},
+	ErrCodeMarginNotSufficient:          {ErrOrderRejected, ErrInsufficientBalance},
+	ErrCodeUnableToFill:                 {ErrOrderRejected},
+	ErrCodeOrderWouldImmediatelyTrigger: {ErrOrderRejected},
+	ErrCodeReduceOnlyReject:             {ErrOrderRejected},
+	ErrCodeNoNeedToChangeMarginType:     {ErrMarginTypeUnchanged},
+	ErrCodeNoNeedToChangePositionSide:   {ErrPositionSideUnchanged},
+}
+
+// APIError represents an API error response
+type APIError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+
+	// HTTP context of the failed request
+	StatusCode int         `json:"-"`
+	Header     http.Header `json:"-"`
+	Method     string      `json:"-"`
+	Endpoint   string      `json:"-"`
+}
+
+func (e APIError) Error() string {
+	if e.Endpoint != "" {
+		return fmt.Sprintf("API Error %d: %s (%s %s)", e.Code, e.Msg, e.Method, e.Endpoint)
+	}
+	return fmt.Sprintf("API Error %d: %s", e.Code, e.Msg)
+}
+
+// Is reports whether the error belongs to the target category
+func (e APIError) Is(target error) bool {
+	for _, category := range codeCategories[e.Code] {
+		if category == target {
+			return true
+		}
+	}
+
+	// Order rejections carry the actual reason in the message only
+	if target == ErrInsufficientBalance && e.Code == ErrCodeNewOrderRejected {
+		msg := strings.ToLower(e.Msg)
+		return strings.Contains(msg, "insufficient") || strings.Contains(msg, "not sufficient")
+	}
+	if target == ErrUnknownOrder && e.Code == ErrCodeCancelRejected {
+		return strings.Contains(strings.ToLower(e.Msg), "unknown order")
+	}
+
+	return statusIs(e.StatusCode, target)
+}
+
+// WebSocketError represents an error response to a WebSocket request such as
+// SUBSCRIBE
+type WebSocketError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+
+	// Method of the failed request
+	Method string `json:"-"`
+}
+
+func (e WebSocketError) Error() string {
+	return fmt.Sprintf("WebSocket Error %d: %s (%s)", e.Code, e.Msg, e.Method)
+}
+
+// ParseError is a stream message that could not be decoded
+type ParseError struct {
+	Stream string
+	Data   json.RawMessage
+	Err    error
+}
+
+func (e ParseError) Error() string {
+	if e.Stream == "" {
+		return fmt.Sprintf("failed to parse stream message: %v", e.Err)
+	}
+	return fmt.Sprintf("failed to parse %s message: %v", e.Stream, e.Err)
+}
+
+func (e ParseError) Unwrap() error {
+	return e.Err
+}
+
+// FieldsError reports the fields of a stream event that strict parsing
+// expected but did not find, and those it found but does not know
+type FieldsError struct {
+	Missing []string
+	Unknown []string
+}
+
+func (e FieldsError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing fields "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unknown) > 0 {
+		parts = append(parts, "unknown fields "+strings.Join(e.Unknown, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// HTTPError represents a failed HTTP response without an API error body
+type HTTPError struct {
+	StatusCode int
+	Body       string
+	Header     http.Header
+	Method     string
+	Endpoint   string
+}
+
+func (e HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// Is reports whether the error belongs to the target category
+func (e HTTPError) Is(target error) bool {
+	return statusIs(e.StatusCode, target)
+}
+
+// statusIs maps HTTP status codes to error categories
+func statusIs(status int, target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return status == http.StatusTooManyRequests
+	case ErrIPBanned:
+		return status == http.StatusTeapot
+	case ErrServer:
+		return status >= 500
+	}
+	return false
+}
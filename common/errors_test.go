@@ -0,0 +1,115 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAPIErrorCategories(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      APIError
+		category error
+		expected bool
+	}{
+		{"timestamp", APIError{Code: -1021}, ErrInvalidTimestamp, true},
+		{"signature", APIError{Code: -1022}, ErrInvalidSignature, true},
+		{"too many requests", APIError{Code: -1003}, ErrRateLimited, true},
+		{"unknown order", APIError{Code: -2013}, ErrUnknownOrder, true},
+		{"margin type", APIError{Code: -4046}, ErrMarginTypeUnchanged, true},
+		{"margin insufficient", APIError{Code: -2019}, ErrInsufficientBalance, true},
+		{"margin insufficient is rejection", APIError{Code: -2019}, ErrOrderRejected, true},
+		{"rejected for balance", APIError{Code: -2010, Msg: "Account has insufficient balance for requested action."}, ErrInsufficientBalance, true},
+		{"rejected for other reason", APIError{Code: -2010, Msg: "Order would trigger immediately."}, ErrInsufficientBalance, false},
+		{"cancel unknown order", APIError{Code: -2011, Msg: "Unknown order sent."}, ErrUnknownOrder, true},
+		{"bad symbol", APIError{Code: -1121}, ErrInvalidParameter, true},
+		{"bad symbol is not rate limit", APIError{Code: -1121}, ErrRateLimited, false},
+		{"status 429", APIError{Code: -1003, StatusCode: 429}, ErrRateLimited, true},
+		{"status 418", APIError{Code: -1003, StatusCode: 418}, ErrIPBanned, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := errors.Is(tt.err, tt.category); result != tt.expected {
+				t.Errorf("errors.Is(%v, %v) = %v, expected %v", tt.err, tt.category, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorCategories(t *testing.T) {
+	if !errors.Is(HTTPError{StatusCode: 429}, ErrRateLimited) {
+		t.Error("Expected 429 to be ErrRateLimited")
+	}
+	if !errors.Is(HTTPError{StatusCode: 418}, ErrIPBanned) {
+		t.Error("Expected 418 to be ErrIPBanned")
+	}
+	if !errors.Is(HTTPError{StatusCode: 502}, ErrServer) {
+		t.Error("Expected 502 to be ErrServer")
+	}
+	if errors.Is(HTTPError{StatusCode: 404}, ErrServer) {
+		t.Error("Expected 404 not to be ErrServer")
+	}
+}
+
+func TestLocalRateLimitIsRateLimited(t *testing.T) {
+	if !errors.Is(ErrRateLimitExceeded, ErrRateLimited) {
+		t.Error("Expected ErrRateLimitExceeded to be ErrRateLimited")
+	}
+}
+
+func TestParseResponseAttachesContext(t *testing.T) {
+	client := NewClient(nil)
+
+	header := make(http.Header)
+	header.Set("X-MBX-USED-WEIGHT-1M", "42")
+	resp := &http.Response{
+		StatusCode: 400,
+		Body:       io.NopCloser(bytes.NewBufferString(`{"code": -2013, "msg": "Order does not exist."}`)),
+		Header:     header,
+		Request:    &http.Request{Method: "GET", URL: &url.URL{Path: "/api/v1/order"}},
+	}
+
+	err := client.ParseResponse(resp, nil)
+
+	var apiErr APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %T", err)
+	}
+	if apiErr.StatusCode != 400 || apiErr.Method != "GET" || apiErr.Endpoint != "/api/v1/order" {
+		t.Errorf("Expected HTTP context to be attached, got %+v", apiErr)
+	}
+	if apiErr.Header.Get("X-MBX-USED-WEIGHT-1M") != "42" {
+		t.Error("Expected response headers to be attached")
+	}
+	if !errors.Is(err, ErrUnknownOrder) {
+		t.Error("Expected error to be ErrUnknownOrder")
+	}
+}
+
+func TestParseResponseNonJSONError(t *testing.T) {
+	client := NewClient(nil)
+
+	resp := &http.Response{
+		StatusCode: 503,
+		Body:       io.NopCloser(bytes.NewBufferString("Service Unavailable")),
+		Header:     make(http.Header),
+	}
+
+	err := client.ParseResponse(resp, nil)
+
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Expected HTTPError, got %T", err)
+	}
+	if httpErr.Body != "Service Unavailable" {
+		t.Errorf("Expected body to be attached, got %q", httpErr.Body)
+	}
+	if !errors.Is(err, ErrServer) {
+		t.Error("Expected error to be ErrServer")
+	}
+}
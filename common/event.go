@@ -0,0 +1,283 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// envelopeFields are carried by every stream event, the event type and the
+// event time, and are never unknown
+var envelopeFields = []string{"e", "E"}
+
+// EventFields reads the fields of a stream event by key. Read errors are
+// collected and returned by Err, which in strict mode also reports the fields
+// that were read but absent, and those present but never read.
+type EventFields struct {
+	prefix   string
+	raw      map[string]json.RawMessage
+	read     map[string]bool
+	optional map[string]bool
+	errs     []error
+	nested   []*EventFields
+}
+
+// NewEventFields parses a JSON object for reading its fields
+func NewEventFields(data json.RawMessage) (*EventFields, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("invalid event: null")
+	}
+	return newEventFields("", raw), nil
+}
+
+func newEventFields(prefix string, raw map[string]json.RawMessage) *EventFields {
+	return &EventFields{
+		prefix:   prefix,
+		raw:      raw,
+		read:     make(map[string]bool),
+		optional: make(map[string]bool),
+	}
+}
+
+// Has reports whether the event carries key
+func (f *EventFields) Has(key string) bool {
+	_, ok := f.raw[key]
+	return ok
+}
+
+// Optional marks fields events may carry or not: they are not missing when
+// absent, nor unknown when not read
+func (f *EventFields) Optional(keys ...string) {
+	for _, key := range keys {
+		f.optional[key] = true
+	}
+}
+
+// String reads a string field
+func (f *EventFields) String(key string) string {
+	var v string
+	f.Decode(key, &v)
+	return v
+}
+
+// Decimal reads a decimal field, sent as a string or a number
+func (f *EventFields) Decimal(key string) decimal.Decimal {
+	var v decimal.Decimal
+	f.Decode(key, &v)
+	return v
+}
+
+// Int reads an integer field
+func (f *EventFields) Int(key string) int64 {
+	var v int64
+	f.Decode(key, &v)
+	return v
+}
+
+// Bool reads a boolean field
+func (f *EventFields) Bool(key string) bool {
+	var v bool
+	f.Decode(key, &v)
+	return v
+}
+
+// Decode reads a field into v, leaving v unchanged when the field is absent
+// or null
+func (f *EventFields) Decode(key string, v any) {
+	f.read[key] = true
+	raw, ok := f.raw[key]
+	if !ok {
+		return
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		f.errs = append(f.errs, fmt.Errorf("field %s%s: %w", f.prefix, key, err))
+	}
+}
+
+// Object reads a field holding a nested object, whose fields are checked
+// along with those of f
+func (f *EventFields) Object(key string) *EventFields {
+	var raw map[string]json.RawMessage
+	f.Decode(key, &raw)
+	nested := newEventFields(f.prefix+key+".", raw)
+	f.nested = append(f.nested, nested)
+	return nested
+}
+
+// Objects reads a field holding an array of objects, whose fields are
+// checked along with those of f
+func (f *EventFields) Objects(key string) []*EventFields {
+	var raws []map[string]json.RawMessage
+	f.Decode(key, &raws)
+	objects := make([]*EventFields, 0, len(raws))
+	for _, raw := range raws {
+		nested := newEventFields(f.prefix+key+".", raw)
+		f.nested = append(f.nested, nested)
+		objects = append(objects, nested)
+	}
+	return objects
+}
+
+// Err returns the read errors, or in strict mode, when there are none, a
+// FieldsError listing the missing and unknown fields
+func (f *EventFields) Err(strict bool) error {
+	var errs []error
+	var fieldsErr FieldsError
+	f.collect(&errs, &fieldsErr)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if !strict || len(fieldsErr.Missing)+len(fieldsErr.Unknown) == 0 {
+		return nil
+	}
+	// The elements of an array share the names of their fields
+	sort.Strings(fieldsErr.Missing)
+	sort.Strings(fieldsErr.Unknown)
+	fieldsErr.Missing = slices.Compact(fieldsErr.Missing)
+	fieldsErr.Unknown = slices.Compact(fieldsErr.Unknown)
+	return fieldsErr
+}
+
+// collect gathers the errors and the field mismatches of f and its nested
+// objects
+func (f *EventFields) collect(errs *[]error, fieldsErr *FieldsError) {
+	*errs = append(*errs, f.errs...)
+	for key := range f.read {
+		if _, ok := f.raw[key]; !ok && !f.optional[key] {
+			fieldsErr.Missing = append(fieldsErr.Missing, f.prefix+key)
+		}
+	}
+	for key := range f.raw {
+		if !f.read[key] && !f.optional[key] && !slices.Contains(envelopeFields, key) {
+			fieldsErr.Unknown = append(fieldsErr.Unknown, f.prefix+key)
+		}
+	}
+	for _, nested := range f.nested {
+		nested.collect(errs, fieldsErr)
+	}
+}
+
+// DecodeEvent decodes a stream event, or an array of them, into v, a
+// pointer to a struct or a slice of structs with json tags. In strict mode
+// it returns a FieldsError when a tagged field without omitempty is absent
+// or a field matches no tag. Only top level fields are checked.
+func DecodeEvent(data json.RawMessage, v any, strict bool) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid event: %w", err)
+	}
+	if !strict {
+		return nil
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	required, known := structFields(t)
+
+	var events []map[string]json.RawMessage
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		json.Unmarshal(data, &events)
+	} else {
+		var event map[string]json.RawMessage
+		json.Unmarshal(data, &event)
+		events = append(events, event)
+	}
+
+	missing, unknown := map[string]bool{}, map[string]bool{}
+	for _, event := range events {
+		for _, key := range required {
+			if _, ok := event[key]; !ok {
+				missing[key] = true
+			}
+		}
+		for key := range event {
+			if !known[key] && !slices.Contains(envelopeFields, key) {
+				unknown[key] = true
+			}
+		}
+	}
+	if len(missing)+len(unknown) == 0 {
+		return nil
+	}
+	return FieldsError{Missing: sortedKeys(missing), Unknown: sortedKeys(unknown)}
+}
+
+// ParseEvents parses an array of stream events with parse. An element whose
+// fields mismatch in strict mode is kept, and the mismatches of all elements
+// are returned in one FieldsError.
+func ParseEvents[T any](data json.RawMessage, strict bool, parse func(data json.RawMessage, strict bool) (*T, error)) ([]T, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+
+	events := make([]T, 0, len(raws))
+	missing, unknown := map[string]bool{}, map[string]bool{}
+	for i, raw := range raws {
+		v, err := parse(raw, strict)
+		var fieldsErr FieldsError
+		if err != nil && !errors.As(err, &fieldsErr) {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		for _, key := range fieldsErr.Missing {
+			missing[key] = true
+		}
+		for _, key := range fieldsErr.Unknown {
+			unknown[key] = true
+		}
+		events = append(events, *v)
+	}
+	if len(missing)+len(unknown) == 0 {
+		return events, nil
+	}
+	return events, FieldsError{Missing: sortedKeys(missing), Unknown: sortedKeys(unknown)}
+}
+
+// structFields returns the json names of the fields of t that events must
+// carry, and all of the names it knows
+func structFields(t reflect.Type) (required []string, known map[string]bool) {
+	known = make(map[string]bool)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		known[name] = true
+		if !slices.Contains(strings.Split(opts, ","), "omitempty") {
+			required = append(required, name)
+		}
+	}
+	return required, known
+}
+
+// sortedKeys returns the keys of m, sorted
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
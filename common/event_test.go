@@ -0,0 +1,99 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEventFields(t *testing.T) {
+	data := json.RawMessage(`{"e":"kline","E":1,"s":"BTCUSDT","k":{"t":100,"o":"1.5","x":true,"z":1},"y":"new"}`)
+
+	f, err := NewEventFields(data)
+	if err != nil {
+		t.Fatalf("NewEventFields failed: %v", err)
+	}
+	f.Optional("s")
+	k := f.Object("k")
+	if got := k.Int("t"); got != 100 {
+		t.Errorf("Expected 100, got %d", got)
+	}
+	if got := k.Decimal("o").String(); got != "1.5" {
+		t.Errorf("Expected 1.5, got %s", got)
+	}
+	k.Bool("x")
+	k.String("c")
+
+	if err := f.Err(false); err != nil {
+		t.Errorf("Expected no error outside strict mode, got %v", err)
+	}
+	var fieldsErr FieldsError
+	if err := f.Err(true); !errors.As(err, &fieldsErr) {
+		t.Fatalf("Expected a FieldsError, got %v", err)
+	}
+	if got := strings.Join(fieldsErr.Missing, ","); got != "k.c" {
+		t.Errorf("Expected missing k.c, got %s", got)
+	}
+	if got := strings.Join(fieldsErr.Unknown, ","); got != "k.z,y" {
+		t.Errorf("Expected unknown k.z,y, got %s", got)
+	}
+
+	// Read errors are returned in both modes
+	f, _ = NewEventFields(json.RawMessage(`{"t":"abc"}`))
+	f.Int("t")
+	if err := f.Err(false); err == nil || !strings.Contains(err.Error(), "field t") {
+		t.Errorf("Expected a read error on t, got %v", err)
+	}
+
+	for _, data := range []string{`[1]`, `null`, `{`} {
+		if _, err := NewEventFields(json.RawMessage(data)); err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	var trade testTrade
+	if err := DecodeEvent(json.RawMessage(`{"e":"trade","t":1,"p":"2"}`), &trade, false); err != nil || trade.ID != 1 {
+		t.Errorf("Expected trade 1, got %d and %v", trade.ID, err)
+	}
+
+	var trades []testTrade
+	err := DecodeEvent(json.RawMessage(`[{"t":1},{"p":"2"}]`), &trades, true)
+	var fieldsErr FieldsError
+	if !errors.As(err, &fieldsErr) {
+		t.Fatalf("Expected a FieldsError, got %v", err)
+	}
+	if len(trades) != 2 || fieldsErr.Error() != "missing fields t; unknown fields p" {
+		t.Errorf("Unexpected result: %v, %v", trades, fieldsErr)
+	}
+
+	if err := DecodeEvent(json.RawMessage(`{"t":"x"}`), &trade, false); err == nil || errors.As(err, &fieldsErr) {
+		t.Errorf("Expected a decoding error, got %v", err)
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	parse := func(data json.RawMessage, strict bool) (*testTrade, error) {
+		var trade testTrade
+		err := DecodeEvent(data, &trade, strict)
+		if err != nil && !errors.As(err, &FieldsError{}) {
+			return nil, err
+		}
+		return &trade, err
+	}
+
+	trades, err := ParseEvents(json.RawMessage(`[{"t":1,"a":0},{"t":2,"b":0}]`), true, parse)
+	var fieldsErr FieldsError
+	if !errors.As(err, &fieldsErr) || strings.Join(fieldsErr.Unknown, ",") != "a,b" {
+		t.Errorf("Expected unknown fields a,b, got %v", err)
+	}
+	if len(trades) != 2 || trades[1].ID != 2 {
+		t.Errorf("Expected both trades, got %v", trades)
+	}
+
+	if _, err := ParseEvents(json.RawMessage(`[{"t":1},{"t":"x"}]`), false, parse); err == nil || !strings.HasPrefix(err.Error(), "event 1:") {
+		t.Errorf("Expected an error on event 1, got %v", err)
+	}
+}
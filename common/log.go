@@ -0,0 +1,89 @@
+package common
+
+import (
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// redacted replaces secrets in log output
+const redacted = "[REDACTED]"
+
+// sensitiveParams are request parameters that are never logged
+var sensitiveParams = map[string]bool{
+	"signature":     true,
+	"userSignature": true,
+	"apiKey":        true,
+	"secretKey":     true,
+	"privateKey":    true,
+	"listenKey":     true,
+}
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// loggerOrDiscard returns l, or a logger that drops everything if l is nil
+func loggerOrDiscard(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return discardLogger
+	}
+	return l
+}
+
+// redactStream hides the listen key naming a user data stream, which gives
+// access to the account updates. Market stream names all have an "@".
+func redactStream(stream string) string {
+	if stream == "" || strings.Contains(stream, "@") {
+		return stream
+	}
+	return redacted
+}
+
+// redactStreams applies redactStream to streams
+func redactStreams(streams []string) []string {
+	names := make([]string, len(streams))
+	for i, stream := range streams {
+		names[i] = redactStream(stream)
+	}
+	return names
+}
+
+// logParams logs request parameters as a group, redacting secrets
+type logParams url.Values
+
+// LogValue implements slog.LogValuer
+func (p logParams) LogValue() slog.Value {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		value := url.Values(p).Get(k)
+		if sensitiveParams[k] {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(k, value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements slog.LogValuer so that logging a config never leaks
+// the API key or the secret key
+func (c *ClientConfig) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("baseURL", c.BaseURL),
+		slog.Bool("testnet", c.Testnet),
+		slog.Duration("timeout", c.Timeout),
+		slog.Int64("recvWindow", c.RecvWindow),
+	}
+	if c.APIKey != "" {
+		attrs = append(attrs, slog.String("apiKey", redacted))
+	}
+	if c.SecretKey != "" {
+		attrs = append(attrs, slog.String("secretKey", redacted))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,145 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestClientLogsRequestsWithoutSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	config := DefaultConfig()
+	config.BaseURL = server.URL
+	config.APIKey = "test-api-key"
+	config.SecretKey = "test-secret-key"
+	config.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient(config)
+
+	req, err := client.BuildRequest(t.Context(), "GET", "/test", map[string]any{"symbol": "BTCUSDT"}, true)
+	if err != nil {
+		t.Fatalf("BuildRequest failed: %v", err)
+	}
+	signature := req.Payload[strings.LastIndex(req.Payload, "=")+1:]
+
+	if err := client.Do("GET", "/test", map[string]any{"symbol": "BTCUSDT"}, nil, true); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	config.Logger.Info("config", "config", config)
+
+	output := buf.String()
+	for _, secret := range []string{"test-api-key", "test-secret-key", signature} {
+		if strings.Contains(output, secret) {
+			t.Errorf("Expected %q to be redacted from logs:\n%s", secret, output)
+		}
+	}
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		messages = append(messages, entry["msg"].(string))
+		if entry["msg"] == "aster: request" {
+			params := entry["params"].(map[string]any)
+			if params["symbol"] != "BTCUSDT" || params["signature"] != redacted {
+				t.Errorf("Unexpected logged params: %v", params)
+			}
+		}
+	}
+
+	expected := "aster: request,aster: response,config"
+	if got := strings.Join(messages, ","); got != expected {
+		t.Errorf("Expected log messages %s, got %s", expected, got)
+	}
+}
+
+func TestClientLogsWithoutListenKeys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	config := DefaultConfig()
+	config.BaseURL = server.URL
+	config.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient(config)
+
+	if err := client.Do("PUT", "/test", map[string]any{"listenKey": "secret-listen-key"}, nil, false); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if output := buf.String(); strings.Contains(output, "secret-listen-key") || !strings.Contains(output, redacted) {
+		t.Errorf("Expected the listen key to be redacted from logs:\n%s", output)
+	}
+}
+
+func TestWebSocketClientLogsParseErrors(t *testing.T) {
+	var buf bytes.Buffer
+	client := NewWebSocketClient("wss://example.com/ws")
+	client.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	client.handleRawMessage(nil, []byte("not json"))
+	client.ReportParseError("btcusdt@trade", json.RawMessage(`{}`), nil)
+
+	output := buf.String()
+	if strings.Count(output, "aster: websocket message parse failed") != 2 || !strings.Contains(output, "stream=btcusdt@trade") {
+		t.Errorf("Unexpected log output:\n%s", output)
+	}
+}
+
+func TestWebSocketClientLogsWithoutListenKeys(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	var buf syncBuffer
+	client := NewWebSocketClient(server.URL())
+	client.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	client.SetReconnect(false, 0)
+	defer client.Disconnect()
+
+	const listenKey = "pqia91ma19a5s61cv6a81va65sdf19v8a65a1a5s61cv6a81va65sdf19v8a65a1"
+	client.Subscribe(listenKey, func(json.RawMessage) {})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := client.Subscribe("btcusdt@trade", func(json.RawMessage) {}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	client.ReportParseError(listenKey, json.RawMessage(`{}`), nil)
+
+	output := buf.String()
+	if strings.Contains(output, listenKey) {
+		t.Errorf("Expected the listen key to be redacted from logs:\n%s", output)
+	}
+	if !strings.Contains(output, "streams="+redacted) || !strings.Contains(output, "stream=btcusdt@trade") {
+		t.Errorf("Unexpected log output:\n%s", output)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
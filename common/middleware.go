@@ -0,0 +1,36 @@
+package common
+
+import "context"
+
+// Request is a logical REST request, as seen by middlewares
+type Request struct {
+	Method   string
+	Endpoint string
+	Params   map[string]any
+	Signed   bool
+	// Result receives the decoded response body, if not nil
+	Result any
+}
+
+// Handler performs a request and decodes its response into req.Result
+type Handler func(ctx context.Context, req *Request) error
+
+// Middleware wraps a Handler, e.g. to log, measure, trace or fail requests
+type Middleware func(next Handler) Handler
+
+// Use adds middlewares to the chain run by every request. The first
+// middleware added is the outermost one. Retries and clock re-syncs happen
+// inside the chain, so a middleware sees one call per logical request, with
+// the final error. Use is not safe to call concurrently with requests.
+func (c *Client) Use(middlewares ...Middleware) {
+	c.middlewares = append(c.middlewares, middlewares...)
+}
+
+// handler returns the middleware chain wrapped around do
+func (c *Client) handler() Handler {
+	h := c.do
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.middlewares[i](h)
+	}
+	return h
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"value": 42}`))
+	})
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) error {
+				calls = append(calls, name+" "+req.Method+" "+req.Endpoint)
+				err := next(ctx, req)
+				calls = append(calls, name+" done")
+				return err
+			}
+		}
+	}
+	client.Use(trace("outer"), trace("inner"))
+
+	var seen *Request
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) error {
+			seen = req
+			return next(ctx, req)
+		}
+	})
+
+	var result struct {
+		Value int `json:"value"`
+	}
+	if err := client.Do("GET", "/test", map[string]any{"symbol": "BTCUSDT"}, &result, false); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+
+	expected := "outer GET /test,inner GET /test,inner done,outer done"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Expected calls %s, got %s", expected, got)
+	}
+	if seen == nil || seen.Params["symbol"] != "BTCUSDT" || seen.Signed {
+		t.Errorf("Unexpected request seen by middleware: %+v", seen)
+	}
+	if result.Value != 42 {
+		t.Errorf("Expected result to be decoded, got %d", result.Value)
+	}
+}
+
+func TestMiddlewareSeesDecodedErrors(t *testing.T) {
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": -1121, "msg": "Invalid symbol."}`))
+	})
+
+	var seen error
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) error {
+			seen = next(ctx, req)
+			return seen
+		}
+	})
+
+	client.Do("GET", "/test", nil, nil, false)
+
+	var apiErr APIError
+	if !errors.As(seen, &apiErr) || apiErr.Code != -1121 {
+		t.Errorf("Expected middleware to see APIError -1121, got %v", seen)
+	}
+}
+
+func TestMiddlewareFaultInjection(t *testing.T) {
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected request not to be sent")
+	})
+
+	injected := errors.New("injected")
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) error {
+			return injected
+		}
+	})
+
+	if err := client.Do("GET", "/test", nil, nil, false); !errors.Is(err, injected) {
+		t.Errorf("Expected injected error, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// IDPaginator pages through rows returned in increasing id order, such as
+// trades fetched with fromId
+type IDPaginator[T any] struct {
+	// Limit is the page size, usually the maximum allowed by the endpoint
+	Limit int
+	// Fetch returns up to limit rows with an id of at least fromID
+	Fetch func(ctx context.Context, fromID int64, limit int) ([]T, error)
+	// ID returns the id of a row
+	ID func(T) int64
+}
+
+// All iterates over every row from fromID on. fromID is required, as the
+// endpoints return the latest rows without one; ids start at 1. The
+// iteration stops at the first error, which is yielded with a zero row.
+func (p IDPaginator[T]) All(ctx context.Context, fromID int64) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if fromID <= 0 {
+			yield(zero, fmt.Errorf("fromID is required"))
+			return
+		}
+		next := fromID
+		for {
+			page, err := p.Fetch(ctx, next, p.Limit)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			progressed := false
+			for _, row := range page {
+				id := p.ID(row)
+				// Skip rows already yielded by the previous page
+				if id < next {
+					continue
+				}
+				if !yield(row, nil) {
+					return
+				}
+				next = id + 1
+				progressed = true
+			}
+
+			if len(page) < p.Limit || !progressed {
+				return
+			}
+		}
+	}
+}
+
+// TimePaginator pages through rows returned in increasing time order,
+// splitting ranges longer than the endpoint allows into windows
+type TimePaginator[T any] struct {
+	// Limit is the page size, usually the maximum allowed by the endpoint
+	Limit int
+	// Window is the longest range accepted by the endpoint, 0 if unbounded
+	Window time.Duration
+	// Fetch returns up to limit rows between startTime and endTime
+	// inclusive, in milliseconds
+	Fetch func(ctx context.Context, startTime, endTime int64, limit int) ([]T, error)
+	// Time returns the time of a row in milliseconds
+	Time func(T) int64
+	// Key identifies a row, to drop rows that share the time at which a
+	// page ended and so come back in the next page
+	Key func(T) string
+}
+
+// All iterates over every row between startTime and endTime inclusive, in
+// milliseconds. endTime defaults to now. The iteration stops at the first
+// error, which is yielded with a zero row.
+func (p TimePaginator[T]) All(ctx context.Context, startTime, endTime int64) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if startTime <= 0 {
+			yield(zero, fmt.Errorf("startTime is required"))
+			return
+		}
+		if endTime <= 0 {
+			endTime = time.Now().UnixMilli()
+		}
+
+		window := p.Window.Milliseconds()
+		start := startTime
+		// seen holds the keys of the rows yielded at time last
+		var last int64 = -1
+		seen := make(map[string]bool)
+
+		for start <= endTime {
+			end := endTime
+			if window > 0 && start+window-1 < end {
+				end = start + window - 1
+			}
+
+			page, err := p.Fetch(ctx, start, end, p.Limit)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, row := range page {
+				t := p.Time(row)
+				if t < last || (t == last && seen[p.Key(row)]) {
+					continue
+				}
+				if t > last {
+					last = t
+					clear(seen)
+				}
+				seen[p.Key(row)] = true
+				if !yield(row, nil) {
+					return
+				}
+			}
+
+			switch {
+			case len(page) < p.Limit:
+				// The window is exhausted
+				start = end + 1
+			case last > start:
+				// Resume at the time the page ended: rows sharing it may
+				// have been cut off
+				start = last
+			default:
+				// A full page at a single time; move on to avoid looping
+				start = max(start, last) + 1
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type testRow struct {
+	id   int64
+	time int64
+}
+
+func TestIDPaginator(t *testing.T) {
+	var calls []int64
+	p := IDPaginator[testRow]{
+		Limit: 10,
+		Fetch: func(ctx context.Context, fromID int64, limit int) ([]testRow, error) {
+			calls = append(calls, fromID)
+			// Overlap the previous page by one row
+			var rows []testRow
+			for id := max(fromID-1, 1); id <= 25 && len(rows) < limit; id++ {
+				rows = append(rows, testRow{id: id})
+			}
+			return rows, nil
+		},
+		ID: func(r testRow) int64 { return r.id },
+	}
+
+	var ids []int64
+	for row, err := range p.All(context.Background(), 1) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		ids = append(ids, row.id)
+	}
+
+	if len(ids) != 25 {
+		t.Fatalf("Expected 25 rows, got %v", ids)
+	}
+	for i, id := range ids {
+		if id != int64(i+1) {
+			t.Fatalf("Expected increasing unique ids, got %v", ids)
+		}
+	}
+	if len(calls) != 3 || calls[1] != 11 || calls[2] != 20 {
+		t.Errorf("Unexpected cursors: %v", calls)
+	}
+
+	for _, err := range p.All(context.Background(), 0) {
+		if err == nil {
+			t.Error("Expected error without fromID")
+		}
+	}
+}
+
+func TestTimePaginatorWindowsAndDuplicates(t *testing.T) {
+	// Two rows per millisecond from 1000 to 1049
+	var rows []testRow
+	for ts := int64(1000); ts < 1050; ts++ {
+		rows = append(rows, testRow{id: ts * 2, time: ts}, testRow{id: ts*2 + 1, time: ts})
+	}
+
+	var windows [][2]int64
+	p := TimePaginator[testRow]{
+		Limit:  7,
+		Window: 20 * time.Millisecond,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]testRow, error) {
+			if endTime-startTime+1 > 20 {
+				t.Errorf("Window %d-%d exceeds the maximum", startTime, endTime)
+			}
+			windows = append(windows, [2]int64{startTime, endTime})
+			var page []testRow
+			for _, r := range rows {
+				if r.time >= startTime && r.time <= endTime && len(page) < limit {
+					page = append(page, r)
+				}
+			}
+			return page, nil
+		},
+		Time: func(r testRow) int64 { return r.time },
+		Key:  func(r testRow) string { return strconv.FormatInt(r.id, 10) },
+	}
+
+	var got []testRow
+	for row, err := range p.All(context.Background(), 1000, 1049) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got = append(got, row)
+	}
+
+	if len(got) != len(rows) {
+		t.Fatalf("Expected %d rows, got %d", len(rows), len(got))
+	}
+	for i := range rows {
+		if got[i] != rows[i] {
+			t.Fatalf("Row %d: expected %v, got %v", i, rows[i], got[i])
+		}
+	}
+	if windows[0] != [2]int64{1000, 1019} {
+		t.Errorf("Unexpected first window: %v", windows[0])
+	}
+}
+
+func TestTimePaginatorStopsOnError(t *testing.T) {
+	fetchErr := errors.New("boom")
+	p := TimePaginator[testRow]{
+		Limit: 10,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]testRow, error) {
+			return nil, fetchErr
+		},
+		Time: func(r testRow) int64 { return r.time },
+		Key:  func(r testRow) string { return "" },
+	}
+
+	count := 0
+	for _, err := range p.All(context.Background(), 1000, 2000) {
+		count++
+		if !errors.Is(err, fetchErr) {
+			t.Errorf("Expected fetch error, got %v", err)
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected a single error, got %d values", count)
+	}
+
+	for _, err := range p.All(context.Background(), 0, 0) {
+		if err == nil {
+			t.Error("Expected error without startTime")
+		}
+	}
+}
+
+func TestTimePaginatorBreak(t *testing.T) {
+	calls := 0
+	p := TimePaginator[testRow]{
+		Limit: 2,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]testRow, error) {
+			calls++
+			return []testRow{{id: startTime, time: startTime}, {id: startTime + 1, time: startTime + 1}}, nil
+		},
+		Time: func(r testRow) int64 { return r.time },
+		Key:  func(r testRow) string { return strconv.FormatInt(r.id, 10) },
+	}
+
+	for range p.All(context.Background(), 1000, 5000) {
+		break
+	}
+	if calls != 1 {
+		t.Errorf("Expected iteration to stop after break, got %d fetches", calls)
+	}
+}
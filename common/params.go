@@ -0,0 +1,109 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// EncodeParams encodes request parameters into url values.
+//
+// A parameter is unset, and left out, when its value is nil, a nil pointer,
+// an empty string, an empty slice or the zero time. Any other value is sent,
+// including zero numbers and false. Strings, booleans, integers, floats and
+// decimal.Decimal are formatted as plain values, time.Time as milliseconds
+// since the epoch, pointers are dereferenced, and slices and arrays are sent
+// as JSON arrays, as expected by batch endpoints such as batchOrders and
+// orderIdList. Other types are rejected.
+func EncodeParams(params map[string]any) (url.Values, error) {
+	values := url.Values{}
+	for key, value := range params {
+		encoded, ok, err := encodeParam(value)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", key, err)
+		}
+		if ok {
+			values.Set(key, encoded)
+		}
+	}
+	return values, nil
+}
+
+// encodeParam formats a single parameter value. ok is false when the value
+// is unset.
+func encodeParam(value any) (encoded string, ok bool, err error) {
+	switch v := value.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return v, v != "", nil
+	case decimal.Decimal:
+		return v.String(), true, nil
+	case time.Time:
+		return strconv.FormatInt(v.UnixMilli(), 10), !v.IsZero(), nil
+	case json.Number:
+		return v.String(), v != "", nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return "", false, nil
+		}
+		return encodeParam(rv.Elem().Interface())
+	case reflect.String:
+		return rv.String(), rv.Len() > 0, nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), true, nil
+	case reflect.Slice, reflect.Array:
+		if rv.Len() == 0 {
+			return "", false, nil
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", false, err
+		}
+		return string(data), true, nil
+	}
+
+	return "", false, fmt.Errorf("unsupported type %T", value)
+}
+
+// Ptr returns a pointer to v, to set an optional request field. Unlike the
+// zero sentinels of the positional methods, it can send a zero value.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Optional returns a pointer to v, or nil when v is the zero value
+func Optional[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return &v
+}
+
+// OptionalTime converts milliseconds since the epoch to a time, or nil for
+// zero and negative values
+func OptionalTime(ms int64) *time.Time {
+	if ms <= 0 {
+		return nil
+	}
+	t := time.UnixMilli(ms)
+	return &t
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+type testSide string
+
+func TestEncodeParams(t *testing.T) {
+	limit := 0
+	var unsetLimit *int
+
+	values, err := EncodeParams(map[string]any{
+		"symbol":                "BTCUSDT",
+		"side":                  testSide("BUY"),
+		"amount":                decimal.RequireFromString("100.50"),
+		"countdownTime":         int64(0),
+		"reduceOnly":            false,
+		"limit":                 &limit,
+		"unset":                 unsetLimit,
+		"nothing":               nil,
+		"empty":                 "",
+		"orderIdList":           []int64{1234, 5678},
+		"origClientOrderIdList": []string{"a", "b"},
+		"noIds":                 []int64{},
+		"batchOrders":           []map[string]string{{"symbol": "BTCUSDT"}},
+	})
+	if err != nil {
+		t.Fatalf("EncodeParams failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"symbol":                "BTCUSDT",
+		"side":                  "BUY",
+		"amount":                "100.5",
+		"countdownTime":         "0",
+		"reduceOnly":            "false",
+		"limit":                 "0",
+		"orderIdList":           "[1234,5678]",
+		"origClientOrderIdList": `["a","b"]`,
+		"batchOrders":           `[{"symbol":"BTCUSDT"}]`,
+	}
+	if len(values) != len(expected) {
+		t.Errorf("Expected %d values, got %v", len(expected), values)
+	}
+	for key, want := range expected {
+		if got := values.Get(key); got != want {
+			t.Errorf("Expected %s=%s, got %s", key, want, got)
+		}
+	}
+}
+
+func TestEncodeParamsUnsupportedType(t *testing.T) {
+	_, err := EncodeParams(map[string]any{"order": struct{ Symbol string }{"BTCUSDT"}})
+	if err == nil {
+		t.Error("Expected error for unsupported type")
+	}
+}
+
+func TestDoRequestRejectsUnsupportedParam(t *testing.T) {
+	client := NewClient(nil)
+	client.SetHTTPClient(&MockHTTPClient{})
+
+	_, err := client.DoRequest("GET", "/test", map[string]any{"ch": make(chan int)}, false)
+	if err == nil {
+		t.Error("Expected error for unsupported parameter")
+	}
+}
+
+func TestOptional(t *testing.T) {
+	if Optional(int64(0)) != nil || OptionalTime(0) != nil {
+		t.Error("Expected zero values to be unset")
+	}
+	if v := Optional(int64(42)); v == nil || *v != 42 {
+		t.Errorf("Expected 42, got %v", v)
+	}
+	if v := OptionalTime(1640995200000); v == nil || v.UnixMilli() != 1640995200000 {
+		t.Errorf("Expected 1640995200000, got %v", v)
+	}
+	if v := Ptr(int64(0)); v == nil || *v != 0 {
+		t.Errorf("Expected a pointer to 0, got %v", v)
+	}
+}
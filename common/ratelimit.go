@@ -0,0 +1,346 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimitExceeded is returned in fail-fast mode when a request would
+// exceed a known rate limit budget. It belongs to the ErrRateLimited category.
+var ErrRateLimitExceeded = fmt.Errorf("%w: local budget exceeded", ErrRateLimited)
+
+// Rate limit types reported by the exchange
+const (
+	RateLimitTypeRequestWeight = "REQUEST_WEIGHT"
+	RateLimitTypeOrders        = "ORDERS"
+)
+
+// RateLimit represents an exchange rate limit as reported by exchangeInfo
+type RateLimit struct {
+	RateLimitType string `json:"rateLimitType"`
+	Interval      string `json:"interval"`
+	IntervalNum   int    `json:"intervalNum"`
+	Limit         int    `json:"limit"`
+}
+
+// RateLimitMode controls what happens when a request would exceed a budget
+type RateLimitMode int
+
+const (
+	// RateLimitBlock waits until the budget frees up
+	RateLimitBlock RateLimitMode = iota
+	// RateLimitFailFast returns ErrRateLimitExceeded immediately
+	RateLimitFailFast
+	// RateLimitDisabled turns client-side limiting off
+	RateLimitDisabled
+)
+
+// EndpointWeight represents the rate limit cost of a REST endpoint
+type EndpointWeight struct {
+	Weight int // REQUEST_WEIGHT units
+	Orders int // ORDERS units
+	// WeightFunc overrides Weight for endpoints whose cost depends on params
+	WeightFunc func(params map[string]any) int
+}
+
+// rateWindow tracks usage of one budget over one fixed window
+type rateWindow struct {
+	limitType string
+	interval  time.Duration
+	limit     int
+	used      int
+	start     time.Time
+}
+
+// RateLimiter keeps track of REQUEST_WEIGHT and ORDERS budgets shared by
+// every request made through a client
+type RateLimiter struct {
+	mu          sync.Mutex
+	mode        RateLimitMode
+	weights     map[string]EndpointWeight
+	windows     []*rateWindow
+	bannedUntil time.Time
+	now         func() time.Time
+}
+
+// NewRateLimiter creates a new rate limiter with no known limits
+func NewRateLimiter(mode RateLimitMode) *RateLimiter {
+	return &RateLimiter{
+		mode:    mode,
+		weights: make(map[string]EndpointWeight),
+		now:     time.Now,
+	}
+}
+
+// SetMode sets the limiter mode
+func (l *RateLimiter) SetMode(mode RateLimitMode) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.mode = mode
+}
+
+// SetWeights registers endpoint weights keyed by "METHOD /path"
+func (l *RateLimiter) SetWeights(weights map[string]EndpointWeight) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, weight := range weights {
+		l.weights[key] = weight
+	}
+}
+
+// SetLimits replaces the known limits, keeping usage already counted for
+// windows that are still present
+func (l *RateLimiter) SetLimits(limits []RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	windows := make([]*rateWindow, 0, len(limits))
+	for _, limit := range limits {
+		if limit.RateLimitType != RateLimitTypeRequestWeight && limit.RateLimitType != RateLimitTypeOrders {
+			continue
+		}
+		interval := parseInterval(limit.Interval, limit.IntervalNum)
+		if interval <= 0 || limit.Limit <= 0 {
+			continue
+		}
+
+		w := &rateWindow{
+			limitType: limit.RateLimitType,
+			interval:  interval,
+			limit:     limit.Limit,
+		}
+		if old := l.findWindow(w.limitType, w.interval); old != nil {
+			w.used = old.used
+			w.start = old.start
+		}
+		windows = append(windows, w)
+	}
+	l.windows = windows
+}
+
+// Used returns the usage counted in the current window for a limit type and
+// interval, or 0 if the limit is unknown
+func (l *RateLimiter) Used(limitType string, interval time.Duration) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w := l.findWindow(limitType, interval)
+	if w == nil {
+		return 0
+	}
+	l.roll(w, l.now())
+	return w.used
+}
+
+// Wait reserves the cost of a request, blocking or failing according to the
+// limiter mode if any budget would be exceeded. A request costing more than
+// a whole budget fails in either mode.
+func (l *RateLimiter) Wait(ctx context.Context, method, endpoint string, params map[string]any) error {
+	weight, orders := l.cost(method, endpoint, params)
+
+	for {
+		l.mu.Lock()
+		if l.mode == RateLimitDisabled {
+			l.mu.Unlock()
+			return nil
+		}
+
+		now := l.now()
+		var wait time.Duration
+		if now.Before(l.bannedUntil) {
+			wait = l.bannedUntil.Sub(now)
+		}
+		for _, w := range l.windows {
+			l.roll(w, now)
+			cost := weight
+			if w.limitType == RateLimitTypeOrders {
+				cost = orders
+			}
+			if cost > w.limit {
+				// No window would ever have room for it
+				l.mu.Unlock()
+				return fmt.Errorf("%w: %s %s costs %d, more than the limit of %d per %s", ErrRateLimitExceeded, method, endpoint, cost, w.limit, w.interval)
+			}
+			if cost > 0 && w.used+cost > w.limit {
+				if d := w.start.Add(w.interval).Sub(now); d > wait {
+					wait = d
+				}
+			}
+		}
+
+		if wait <= 0 {
+			for _, w := range l.windows {
+				if w.limitType == RateLimitTypeOrders {
+					w.used += orders
+				} else {
+					w.used += weight
+				}
+			}
+			l.mu.Unlock()
+			return nil
+		}
+
+		mode := l.mode
+		l.mu.Unlock()
+
+		if mode == RateLimitFailFast {
+			return fmt.Errorf("%w: %s %s, retry in %s", ErrRateLimitExceeded, method, endpoint, wait)
+		}
+
+		if err := sleepCtx(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// Update reconciles local usage with the used-weight and order-count
+// headers returned by the exchange
+func (l *RateLimiter) Update(header http.Header) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, values := range header {
+		if len(values) == 0 {
+			continue
+		}
+
+		var limitType, suffix string
+		upper := strings.ToUpper(key)
+		switch {
+		case strings.HasPrefix(upper, "X-MBX-USED-WEIGHT-"):
+			limitType = RateLimitTypeRequestWeight
+			suffix = strings.TrimPrefix(upper, "X-MBX-USED-WEIGHT-")
+		case strings.HasPrefix(upper, "X-MBX-ORDER-COUNT-"):
+			limitType = RateLimitTypeOrders
+			suffix = strings.TrimPrefix(upper, "X-MBX-ORDER-COUNT-")
+		default:
+			continue
+		}
+
+		interval := parseIntervalSuffix(suffix)
+		used, err := strconv.Atoi(values[0])
+		if interval <= 0 || err != nil {
+			continue
+		}
+
+		if w := l.findWindow(limitType, interval); w != nil {
+			l.roll(w, now)
+			if used > w.used {
+				w.used = used
+			}
+		}
+	}
+}
+
+// Backoff stops all requests until the given delay has elapsed, as asked by
+// a 429 or 418 response
+func (l *RateLimiter) Backoff(delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := l.now().Add(delay); until.After(l.bannedUntil) {
+		l.bannedUntil = until
+	}
+}
+
+func (l *RateLimiter) cost(method, endpoint string, params map[string]any) (int, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	weight, ok := l.weights[method+" "+endpoint]
+	if !ok {
+		return 1, 0
+	}
+	if weight.WeightFunc != nil {
+		return weight.WeightFunc(params), weight.Orders
+	}
+	return weight.Weight, weight.Orders
+}
+
+func (l *RateLimiter) findWindow(limitType string, interval time.Duration) *rateWindow {
+	for _, w := range l.windows {
+		if w.limitType == limitType && w.interval == interval {
+			return w
+		}
+	}
+	return nil
+}
+
+// roll starts a new window once the current one has elapsed. Windows are
+// aligned to the interval like the exchange's own counters.
+func (l *RateLimiter) roll(w *rateWindow, now time.Time) {
+	if start := now.Truncate(w.interval); !start.Equal(w.start) {
+		w.start = start
+		w.used = 0
+	}
+}
+
+// parseInterval converts an exchangeInfo interval into a duration
+func parseInterval(interval string, num int) time.Duration {
+	if num <= 0 {
+		num = 1
+	}
+	var unit time.Duration
+	switch interval {
+	case "SECOND":
+		unit = time.Second
+	case "MINUTE":
+		unit = time.Minute
+	case "HOUR":
+		unit = time.Hour
+	case "DAY":
+		unit = 24 * time.Hour
+	default:
+		return 0
+	}
+	return time.Duration(num) * unit
+}
+
+// parseIntervalSuffix converts a header suffix such as "1M" or "10S" into a
+// duration
+func parseIntervalSuffix(suffix string) time.Duration {
+	if len(suffix) < 2 {
+		return 0
+	}
+	num, err := strconv.Atoi(suffix[:len(suffix)-1])
+	if err != nil {
+		return 0
+	}
+	switch suffix[len(suffix)-1] {
+	case 'S':
+		return parseInterval("SECOND", num)
+	case 'M':
+		return parseInterval("MINUTE", num)
+	case 'H':
+		return parseInterval("HOUR", num)
+	case 'D':
+		return parseInterval("DAY", num)
+	}
+	return 0
+}
+
+// minRateLimitBackoff is how long requests pause after a 429 or 418 response
+// that does not say how long to wait with Retry-After
+const minRateLimitBackoff = 5 * time.Second
+
+// parseRetryAfter returns the delay asked by a Retry-After header, which is
+// either a number of seconds or an HTTP date
+func parseRetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
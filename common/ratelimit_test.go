@@ -0,0 +1,178 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(mode RateLimitMode, now time.Time) *RateLimiter {
+	limiter := NewRateLimiter(mode)
+	limiter.now = func() time.Time { return now }
+	limiter.SetLimits([]RateLimit{
+		{RateLimitType: "REQUEST_WEIGHT", Interval: "MINUTE", IntervalNum: 1, Limit: 10},
+		{RateLimitType: "ORDERS", Interval: "SECOND", IntervalNum: 10, Limit: 2},
+	})
+	limiter.SetWeights(map[string]EndpointWeight{
+		"GET /depth":  {Weight: 4},
+		"POST /order": {Weight: 1, Orders: 1},
+	})
+	return limiter
+}
+
+func TestRateLimiterFailFast(t *testing.T) {
+	limiter := newTestLimiter(RateLimitFailFast, time.Unix(1700000000, 0))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(ctx, "GET", "/depth", nil); err != nil {
+			t.Fatalf("Expected request %d to pass, got %v", i, err)
+		}
+	}
+
+	err := limiter.Wait(ctx, "GET", "/depth", nil)
+	if !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded, got %v", err)
+	}
+
+	if used := limiter.Used("REQUEST_WEIGHT", time.Minute); used != 8 {
+		t.Errorf("Expected used weight 8, got %d", used)
+	}
+}
+
+func TestRateLimiterOrders(t *testing.T) {
+	limiter := newTestLimiter(RateLimitFailFast, time.Unix(1700000000, 0))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(ctx, "POST", "/order", nil); err != nil {
+			t.Fatalf("Expected order %d to pass, got %v", i, err)
+		}
+	}
+	if err := limiter.Wait(ctx, "POST", "/order", nil); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded for third order, got %v", err)
+	}
+
+	// Non-order requests are not limited by the ORDERS budget
+	if err := limiter.Wait(ctx, "GET", "/depth", nil); err != nil {
+		t.Errorf("Expected depth request to pass, got %v", err)
+	}
+}
+
+func TestRateLimiterBlockHonorsContext(t *testing.T) {
+	limiter := newTestLimiter(RateLimitBlock, time.Unix(1700000000, 0))
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(context.Background(), "GET", "/depth", nil); err != nil {
+			t.Fatalf("Expected request %d to pass, got %v", i, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := limiter.Wait(ctx, "GET", "/depth", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRateLimiterCostOverLimit(t *testing.T) {
+	limiter := newTestLimiter(RateLimitBlock, time.Unix(1700000000, 0))
+	limiter.SetWeights(map[string]EndpointWeight{"GET /huge": {Weight: 11}})
+
+	// Blocking would never end
+	err := limiter.Wait(context.Background(), "GET", "/huge", nil)
+	if !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded, got %v", err)
+	}
+	if used := limiter.Used("REQUEST_WEIGHT", time.Minute); used != 0 {
+		t.Errorf("Expected no weight used, got %d", used)
+	}
+}
+
+func TestRateLimiterWindowReset(t *testing.T) {
+	now := time.Unix(1700000000, 0).Truncate(time.Minute)
+	limiter := newTestLimiter(RateLimitFailFast, now)
+	ctx := context.Background()
+
+	limiter.Wait(ctx, "GET", "/depth", nil)
+	limiter.Wait(ctx, "GET", "/depth", nil)
+
+	limiter.now = func() time.Time { return now.Add(time.Minute) }
+	if err := limiter.Wait(ctx, "GET", "/depth", nil); err != nil {
+		t.Errorf("Expected request in new window to pass, got %v", err)
+	}
+}
+
+func TestRateLimiterUpdate(t *testing.T) {
+	limiter := newTestLimiter(RateLimitFailFast, time.Unix(1700000000, 0))
+
+	header := make(http.Header)
+	header.Set("X-MBX-USED-WEIGHT-1M", "9")
+	header.Set("X-MBX-ORDER-COUNT-10S", "1")
+	limiter.Update(header)
+
+	if used := limiter.Used("REQUEST_WEIGHT", time.Minute); used != 9 {
+		t.Errorf("Expected used weight 9, got %d", used)
+	}
+	if used := limiter.Used("ORDERS", 10*time.Second); used != 1 {
+		t.Errorf("Expected order count 1, got %d", used)
+	}
+
+	err := limiter.Wait(context.Background(), "GET", "/depth", nil)
+	if !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded after header update, got %v", err)
+	}
+}
+
+func TestRateLimiterBackoff(t *testing.T) {
+	limiter := newTestLimiter(RateLimitFailFast, time.Unix(1700000000, 0))
+	limiter.Backoff(30 * time.Second)
+
+	err := limiter.Wait(context.Background(), "GET", "/unknown", nil)
+	if !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded during backoff, got %v", err)
+	}
+}
+
+func TestParseIntervalSuffix(t *testing.T) {
+	tests := []struct {
+		suffix   string
+		expected time.Duration
+	}{
+		{"1M", time.Minute},
+		{"10S", 10 * time.Second},
+		{"1D", 24 * time.Hour},
+		{"1H", time.Hour},
+		{"M", 0},
+		{"1X", 0},
+	}
+
+	for _, tt := range tests {
+		if result := parseIntervalSuffix(tt.suffix); result != tt.expected {
+			t.Errorf("parseIntervalSuffix(%s) = %v, expected %v", tt.suffix, result, tt.expected)
+		}
+	}
+}
+
+func TestClientBacksOffWithoutRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.BaseURL = server.URL
+	config.RateLimitMode = RateLimitFailFast
+	config.Retry.MaxAttempts = 1
+	client := NewClient(config)
+
+	client.Do("GET", "/test", nil, nil, false)
+	err := client.Do("GET", "/test", nil, nil, false)
+	if !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded after a 429 without Retry-After, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrReconnectFailed is returned to the OnReconnectFailed hook once the
+// reconnect policy gives up
+var ErrReconnectFailed = errors.New("websocket reconnect failed")
+
+// ErrClientClosed is returned by Connect after Disconnect
+var ErrClientClosed = errors.New("websocket client closed")
+
+// ReconnectPolicy configures how a WebSocket client restores a lost
+// connection
+type ReconnectPolicy struct {
+	// MaxAttempts caps the attempts to restore a connection; 0 means no cap
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomizes each delay by up to this fraction, between 0 and 1,
+	// so that many clients dropped at once don't reconnect in lockstep
+	Jitter float64
+}
+
+// DefaultReconnectPolicy returns the reconnect policy of new WebSocket
+// clients
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// backoff returns the delay before an attempt, from 1
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	return backoffDelay(attempt, p.InitialBackoff, p.MaxBackoff, p.Multiplier, p.Jitter)
+}
+
+// SetReconnectPolicy sets how lost connections are restored
+func (c *WebSocketClient) SetReconnectPolicy(policy ReconnectPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reconnectPolicy = policy
+}
+
+// SetOnReconnectFailed sets a hook called when the reconnect policy gives up
+// on a lost connection, with an error wrapping ErrReconnectFailed and the
+// last dial error. The client stays disconnected.
+func (c *WebSocketClient) SetOnReconnectFailed(hook func(err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.onReconnectFailed = hook
+}
+
+// reconnectLoop restores a lost connection following the reconnect policy.
+// It returns once connected, when the policy gives up, or when Disconnect is
+// called.
+func (c *WebSocketClient) reconnectLoop() {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		c.mu.RLock()
+		reconnect, policy := c.reconnect, c.reconnectPolicy
+		c.mu.RUnlock()
+		if !reconnect {
+			return
+		}
+
+		if policy.MaxAttempts > 0 && attempt > policy.MaxAttempts {
+			err := fmt.Errorf("%w after %d attempts: %w", ErrReconnectFailed, policy.MaxAttempts, lastErr)
+			c.Logger().Error("aster: websocket reconnect gave up", "url", c.url, "error", err)
+			if hook := c.getHooks().onReconnectFailed; hook != nil {
+				hook(err)
+			}
+			return
+		}
+
+		delay := policy.backoff(attempt)
+		c.Logger().Info("aster: websocket reconnecting", "url", c.url, "attempt", attempt, "delay", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-c.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		writer, rest, err := c.connect(attempt)
+		if err != nil {
+			if errors.Is(err, ErrClientClosed) {
+				return
+			}
+			lastErr = err
+			c.reportError("aster: websocket reconnect failed", err, "url", c.url, "attempt", attempt)
+			continue
+		}
+
+		// The hooks run once the server has acknowledged every stream
+		failed := c.resubscribe(writer, rest)
+		hooks := c.getHooks()
+		if hooks.onReconnect != nil {
+			hooks.onReconnect(attempt)
+		}
+		if hooks.onResubscribe != nil {
+			streams := slices.DeleteFunc(c.Subscriptions(), func(stream string) bool {
+				return slices.Contains(failed, stream)
+			})
+			hooks.onResubscribe(streams, failed)
+		}
+		return
+	}
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReconnectPolicyBackoff(t *testing.T) {
+	policy := ReconnectPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     500 * time.Millisecond,
+		Multiplier:     2,
+	}
+	expected := []time.Duration{100, 200, 400, 500, 500}
+	for i, want := range expected {
+		want *= time.Millisecond
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("backoff(%d) = %v, expected %v", i+1, got, want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for range 100 {
+		if got := policy.backoff(2); got < 100*time.Millisecond || got > 200*time.Millisecond {
+			t.Fatalf("Expected a jittered delay between 100ms and 200ms, got %v", got)
+		}
+	}
+}
+
+func TestWebSocketReconnectsAfterFailedDials(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnectPolicy(ReconnectPolicy{InitialBackoff: 10 * time.Millisecond, Multiplier: 2})
+	defer client.Disconnect()
+
+	reconnected := make(chan int, 1)
+	client.SetOnReconnect(func(attempt int) { reconnected <- attempt })
+	failures := make(chan error, 4)
+	client.SetOnError(func(err error) { failures <- err })
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	// The first two dials fail; reconnecting goes on regardless
+	server.refuse.Store(2)
+	server.nextConn(t).Close()
+	server.nextConn(t)
+
+	select {
+	case attempt := <-reconnected:
+		if attempt != 3 {
+			t.Errorf("Expected to reconnect at attempt 3, got %d", attempt)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the reconnect hook to be called")
+	}
+	if len(failures) != 2 {
+		t.Errorf("Expected 2 reported failures, got %d", len(failures))
+	}
+}
+
+func TestWebSocketReconnectGivesUp(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnectPolicy(ReconnectPolicy{MaxAttempts: 2, InitialBackoff: 10 * time.Millisecond})
+	defer client.Disconnect()
+
+	failed := make(chan error, 1)
+	client.SetOnReconnectFailed(func(err error) { failed <- err })
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	server.refuse.Store(10)
+	server.nextConn(t).Close()
+
+	select {
+	case err := <-failed:
+		if !errors.Is(err, ErrReconnectFailed) {
+			t.Errorf("Expected ErrReconnectFailed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the reconnect failed hook to be called")
+	}
+	if client.State() != StateDisconnected {
+		t.Errorf("Expected disconnected state, got %s", client.State())
+	}
+}
+
+func TestWebSocketDisconnectStopsReconnecting(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnectPolicy(ReconnectPolicy{InitialBackoff: time.Hour})
+	states := client.StateChanges()
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	server.nextConn(t).Close()
+
+	// Wait for the connection loss, then disconnect during the backoff
+	for change := range states {
+		if change.State == StateDisconnected {
+			break
+		}
+	}
+	client.Disconnect()
+
+	select {
+	case <-server.conns:
+		t.Fatal("Expected no reconnect after Disconnect")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := client.Connect(); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Expected ErrClientClosed, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// CanonicalRequest is a request as it goes on the wire
+type CanonicalRequest struct {
+	Method string
+	URL    string // including the query string of GET and DELETE requests
+	Body   string // form body of POST and PUT requests
+	// Canonical is the parameter string covered by the signature: every
+	// parameter, including timestamp and recvWindow of signed requests,
+	// sorted by key and URL-encoded
+	Canonical string
+	// Payload is Canonical followed by the authentication parameters. It is
+	// sent verbatim as the query string or the form body.
+	Payload string
+}
+
+// CanonicalQuery returns the canonical parameter string of params: sorted by
+// key and URL-encoded
+func CanonicalQuery(params map[string]string) string {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	return values.Encode()
+}
+
+// BuildRequest builds the request that DoRequest would send, without
+// sending it. It helps troubleshooting signature errors: an HMAC signature
+// must match Canonical exactly. Timestamps use the current server clock
+// offset; BuildRequest never calls the server to refresh it.
+func (c *Client) BuildRequest(ctx context.Context, method, endpoint string, params map[string]any, signed bool) (*CanonicalRequest, error) {
+	values, err := EncodeParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add timestamp and recvWindow for signed requests
+	signer := c.signer()
+	if signed && signer != nil {
+		// Add timestamp, corrected by the server clock offset
+		values.Set("timestamp", strconv.FormatInt(c.Timestamp(), 10))
+
+		// Add recvWindow if not present
+		if !values.Has("recvWindow") {
+			values.Set("recvWindow", strconv.FormatInt(c.config.RecvWindow, 10))
+		}
+	}
+
+	req := &CanonicalRequest{
+		Method:    method,
+		URL:       c.config.BaseURL + endpoint,
+		Canonical: values.Encode(),
+	}
+	req.Payload = req.Canonical
+
+	if signed && signer != nil {
+		signParams := make(map[string]string, len(values))
+		for k := range values {
+			signParams[k] = values.Get(k)
+		}
+		auth, err := signer.Sign(signParams)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+
+		// Authentication parameters come last, after the signed bytes
+		if encoded := CanonicalQuery(auth); encoded != "" {
+			if req.Payload != "" {
+				req.Payload += "&"
+			}
+			req.Payload += encoded
+		}
+	}
+
+	if method == "GET" || method == "DELETE" {
+		// Add parameters to query string
+		if req.Payload != "" {
+			req.URL += "?" + req.Payload
+		}
+	} else {
+		// Add parameters to request body
+		req.Body = req.Payload
+	}
+
+	return req, nil
+}
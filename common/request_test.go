@@ -0,0 +1,74 @@
+package common
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildRequestCanonical(t *testing.T) {
+	client := NewClient(nil)
+	client.SetAPIKey("test-api-key", "test-secret-key")
+	client.SetBaseURL("https://api.example.com")
+
+	req, err := client.BuildRequest(context.Background(), "GET", "/test", map[string]any{
+		"symbol":           "BTCUSDT",
+		"newClientOrderId": "a b&c=d/+",
+	}, true)
+	if err != nil {
+		t.Fatalf("BuildRequest failed: %v", err)
+	}
+
+	if !strings.HasPrefix(req.Canonical, "newClientOrderId=a+b%26c%3Dd%2F%2B&recvWindow=5000&symbol=BTCUSDT&timestamp=") {
+		t.Errorf("Unexpected canonical string: %s", req.Canonical)
+	}
+
+	signature := signHMAC(req.Canonical, "test-secret-key")
+	if req.Payload != req.Canonical+"&signature="+signature {
+		t.Errorf("Expected payload to be the canonical string and its signature, got %s", req.Payload)
+	}
+	if req.URL != "https://api.example.com/test?"+req.Payload {
+		t.Errorf("Unexpected URL: %s", req.URL)
+	}
+	if req.Body != "" {
+		t.Errorf("Expected no body for GET, got %s", req.Body)
+	}
+}
+
+func TestSignedPayloadMatchesSentBytes(t *testing.T) {
+	for _, method := range []string{"GET", "POST"} {
+		t.Run(method, func(t *testing.T) {
+			var sent string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					sent = r.URL.RawQuery
+				} else {
+					body, _ := io.ReadAll(r.Body)
+					sent = string(body)
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			client := NewClient(nil)
+			client.SetAPIKey("test-api-key", "test-secret-key")
+			client.SetBaseURL(server.URL)
+
+			params := map[string]any{"symbol": "BTCUSDT", "newClientOrderId": "x y/z=1&2"}
+			if err := client.Do(method, "/test", params, nil, true); err != nil {
+				t.Fatalf("Do failed: %v", err)
+			}
+
+			signed, signature, ok := strings.Cut(sent, "&signature=")
+			if !ok {
+				t.Fatalf("Expected signature to be sent last, got %s", sent)
+			}
+			if want := signHMAC(signed, "test-secret-key"); signature != want {
+				t.Errorf("Signature %s doesn't match sent bytes %q", signature, signed)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy configures automatic retries of transient failures: network
+// timeouts, dropped or refused connections, 5xx, 429 and 418 responses
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts; 0 or 1 disables retries
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomizes each delay by up to this fraction, between 0 and 1
+	Jitter float64
+	// Retryable reports whether a request may safely be sent again. When nil,
+	// DefaultRetryable is used.
+	Retryable func(method, endpoint string, params map[string]any) bool
+}
+
+// DefaultRetryPolicy returns the retry policy used by DefaultConfig
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// DefaultRetryable allows retrying GET requests only. An order that timed
+// out may have been placed, and resending it would be rejected as a
+// duplicate, reporting an error for an order that exists.
+func DefaultRetryable(method, endpoint string, params map[string]any) bool {
+	return method == http.MethodGet
+}
+
+func (p RetryPolicy) retryable(method, endpoint string, params map[string]any) bool {
+	if p.MaxAttempts <= 1 {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(method, endpoint, params)
+	}
+	return DefaultRetryable(method, endpoint, params)
+}
+
+// backoff returns the delay before the next attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	return backoffDelay(attempt, p.InitialBackoff, p.MaxBackoff, p.Multiplier, p.Jitter)
+}
+
+// backoffDelay returns the delay before an attempt, from 1, growing
+// exponentially from initial up to max and randomly shortened by up to the
+// jitter fraction
+func backoffDelay(attempt int, initial, max time.Duration, multiplier, jitter float64) time.Duration {
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if max > 0 && delay > float64(max) {
+		delay = float64(max)
+	}
+	if jitter > 0 {
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// isTransientStatus reports whether a response status is worth retrying
+func isTransientStatus(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests || status == http.StatusTeapot
+}
+
+// isTransientError reports whether a transport error is worth retrying: a
+// network timeout, a connection reset or refused, or one closed early. TLS,
+// DNS and URL errors would fail again.
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// sleepCtx waits for d or until ctx is done
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,152 @@
+package common
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newRetryTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config := DefaultConfig()
+	config.BaseURL = server.URL
+	config.Retry.InitialBackoff = time.Millisecond
+	config.Retry.MaxBackoff = 5 * time.Millisecond
+	return NewClient(config)
+}
+
+func TestDoRetriesTransientGet(t *testing.T) {
+	var calls atomic.Int32
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	})
+
+	var result map[string]any
+	if err := client.Do("GET", "/test", nil, &result, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestDoGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	if err := client.Do("GET", "/test", nil, nil, false); err == nil {
+		t.Error("Expected error after exhausting retries")
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestDoDoesNotRetryUnsafePost(t *testing.T) {
+	var calls atomic.Int32
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	client.Do("POST", "/order", map[string]any{"symbol": "BTCUSDT"}, nil, false)
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 attempt for POST without client order ID, got %d", calls.Load())
+	}
+
+	calls.Store(0)
+	client.Do("POST", "/order", map[string]any{"symbol": "BTCUSDT", "newClientOrderId": "abc"}, nil, false)
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 attempt for POST with client order ID, got %d", calls.Load())
+	}
+}
+
+func TestDoDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": -1121, "msg": "Invalid symbol."}`))
+	})
+
+	client.Do("GET", "/test", nil, nil, false)
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 attempt, got %d", calls.Load())
+	}
+}
+
+func TestDoRetryHonorsContext(t *testing.T) {
+	client := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.DoCtx(ctx, "GET", "/test", nil, nil, false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Expected retry wait to stop when the context expired")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("backoff(%d) = %v, expected %v", i+1, got, want)
+		}
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"reset", &url.Error{Op: "Get", URL: "https://x", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, true},
+		{"refused", &url.Error{Op: "Get", URL: "https://x", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, true},
+		{"eof", &url.Error{Op: "Get", URL: "https://x", Err: io.EOF}, true},
+		{"timeout", &url.Error{Op: "Get", URL: "https://x", Err: &net.DNSError{Err: "timeout", IsTimeout: true}}, true},
+		{"not found", &url.Error{Op: "Get", URL: "https://x", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, false},
+		{"tls", &url.Error{Op: "Get", URL: "https://x", Err: x509.UnknownAuthorityError{}}, false},
+		{"malformed url", &url.Error{Op: "parse", URL: "://x", Err: errors.New("missing protocol scheme")}, false},
+		{"canceled", &url.Error{Op: "Get", URL: "https://x", Err: context.Canceled}, false},
+	}
+
+	for _, tt := range tests {
+		if got := isTransientError(tt.err); got != tt.want {
+			t.Errorf("%s: isTransientError(%v) = %v, expected %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// Signer authenticates signed requests
+type Signer interface {
+	// Sign returns the parameters to add to a request to authenticate it.
+	// params holds every request parameter, including timestamp and
+	// recvWindow.
+	Sign(params map[string]string) (map[string]string, error)
+}
+
+// HMACSigner signs requests with an HMAC SHA256 of the parameters, as used
+// by the spot API
+type HMACSigner struct {
+	SecretKey string
+}
+
+// NewHMACSigner creates a new HMAC signer
+func NewHMACSigner(secretKey string) *HMACSigner {
+	return &HMACSigner{SecretKey: secretKey}
+}
+
+// Sign returns the signature parameter
+func (s *HMACSigner) Sign(params map[string]string) (map[string]string, error) {
+	return map[string]string{
+		"signature": SignRequest(params, s.SecretKey),
+	}, nil
+}
+
+// Web3Signer signs requests with the wallet-based scheme of the /fapi/v3
+// futures API. The parameters are serialized as sorted JSON, ABI-encoded
+// together with the user address, the signer address and a nonce, and the
+// Keccak-256 hash of that is signed as an Ethereum personal message by the
+// signer's ECDSA key.
+type Web3Signer struct {
+	user       string
+	signer     string
+	privateKey *secp256k1.PrivateKey
+	nonce      func() int64
+}
+
+// NewWeb3Signer creates a new Web3 signer. user is the address of the main
+// account, and privateKey the hex encoded key of the API signer wallet
+// registered for it.
+func NewWeb3Signer(user, privateKey string) (*Web3Signer, error) {
+	if !isAddress(user) {
+		return nil, fmt.Errorf("invalid user address: %s", user)
+	}
+
+	key, err := hex.DecodeString(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid private key")
+	}
+
+	priv := secp256k1.PrivKeyFromBytes(key)
+	return &Web3Signer{
+		user:       user,
+		signer:     publicKeyToAddress(priv.PubKey()),
+		privateKey: priv,
+		nonce: func() int64 {
+			return time.Now().UnixMicro()
+		},
+	}, nil
+}
+
+// User returns the user address
+func (s *Web3Signer) User() string {
+	return s.user
+}
+
+// SignerAddress returns the address of the signer wallet
+func (s *Web3Signer) SignerAddress() string {
+	return s.signer
+}
+
+// Sign returns the user, signer, nonce and signature parameters
+func (s *Web3Signer) Sign(params map[string]string) (map[string]string, error) {
+	var payload bytes.Buffer
+	encoder := json.NewEncoder(&payload)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(params); err != nil {
+		return nil, err
+	}
+	message := strings.ReplaceAll(strings.TrimSuffix(payload.String(), "\n"), " ", "")
+
+	nonce := s.nonce()
+	encoded := abiEncode(message, s.user, s.signer, uint64(nonce))
+	hash := keccak256(encoded)
+
+	signature, err := s.signPersonalMessage(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"user":      s.user,
+		"signer":    s.signer,
+		"nonce":     strconv.FormatInt(nonce, 10),
+		"signature": "0x" + hex.EncodeToString(signature),
+	}, nil
+}
+
+// signPersonalMessage signs msg with the EIP-191 personal message prefix and
+// returns the signature in Ethereum's r || s || v form
+func (s *Web3Signer) signPersonalMessage(msg []byte) ([]byte, error) {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(msg))
+	digest := keccak256([]byte(prefix), msg)
+
+	compact := ecdsa.SignCompact(s.privateKey, digest, false)
+	if len(compact) != 65 {
+		return nil, fmt.Errorf("unexpected signature length %d", len(compact))
+	}
+
+	// SignCompact returns v || r || s with v = 27 + recovery id
+	signature := make([]byte, 65)
+	copy(signature, compact[1:])
+	signature[64] = compact[0]
+	return signature, nil
+}
+
+// abiEncode encodes (string, address, address, uint256) following the
+// Solidity ABI
+func abiEncode(message, user, signer string, nonce uint64) []byte {
+	out := make([]byte, 0, 6*32+len(message))
+
+	// Head: offset of the dynamic string, then the static values
+	out = append(out, uint256(4*32)...)
+	out = append(out, addressWord(user)...)
+	out = append(out, addressWord(signer)...)
+	out = append(out, uint256(nonce)...)
+
+	// Tail: string length and right-padded contents
+	out = append(out, uint256(uint64(len(message)))...)
+	out = append(out, message...)
+	if pad := len(message) % 32; pad != 0 {
+		out = append(out, make([]byte, 32-pad)...)
+	}
+
+	return out
+}
+
+func uint256(v uint64) []byte {
+	word := make([]byte, 32)
+	binary.BigEndian.PutUint64(word[24:], v)
+	return word
+}
+
+func addressWord(address string) []byte {
+	word := make([]byte, 32)
+	raw, _ := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	copy(word[32-len(raw):], raw)
+	return word
+}
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// publicKeyToAddress derives the Ethereum address of a public key
+func publicKeyToAddress(pub *secp256k1.PublicKey) string {
+	hash := keccak256(pub.SerializeUncompressed()[1:])
+	return "0x" + hex.EncodeToString(hash[12:])
+}
+
+func isAddress(address string) bool {
+	raw, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	return err == nil && len(raw) == 20
+}
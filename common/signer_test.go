@@ -0,0 +1,152 @@
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+const (
+	testPrivateKey    = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testSignerAddress = "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
+	testUserAddress   = "0x63dd5acc6b1aa0f563956c0e534dd30b6dcf7c4e"
+)
+
+func TestHMACSigner(t *testing.T) {
+	params := map[string]string{"symbol": "BTCUSDT", "timestamp": "1"}
+	auth, err := NewHMACSigner("secret").Sign(params)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if auth["signature"] != SignRequest(params, "secret") {
+		t.Errorf("Expected signature %s, got %s", SignRequest(params, "secret"), auth["signature"])
+	}
+}
+
+func TestNewWeb3SignerInvalid(t *testing.T) {
+	if _, err := NewWeb3Signer("0x1234", testPrivateKey); err == nil {
+		t.Error("Expected error for invalid user address")
+	}
+	if _, err := NewWeb3Signer(testUserAddress, "0xzz"); err == nil {
+		t.Error("Expected error for invalid private key")
+	}
+}
+
+func TestWeb3Signer(t *testing.T) {
+	signer, err := NewWeb3Signer(testUserAddress, testPrivateKey)
+	if err != nil {
+		t.Fatalf("NewWeb3Signer failed: %v", err)
+	}
+	signer.nonce = func() int64 { return 1700000000000000 }
+
+	if signer.SignerAddress() != testSignerAddress {
+		t.Errorf("Expected signer %s, got %s", testSignerAddress, signer.SignerAddress())
+	}
+
+	params := map[string]string{"symbol": "BTCUSDT", "side": "BUY", "timestamp": "1"}
+	auth, err := signer.Sign(params)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	if auth["user"] != testUserAddress || auth["signer"] != testSignerAddress || auth["nonce"] != "1700000000000000" {
+		t.Errorf("Unexpected auth params: %v", auth)
+	}
+
+	signature, err := hex.DecodeString(strings.TrimPrefix(auth["signature"], "0x"))
+	if err != nil || len(signature) != 65 {
+		t.Fatalf("Expected 65 byte hex signature, got %s", auth["signature"])
+	}
+	if v := signature[64]; v != 27 && v != 28 {
+		t.Errorf("Expected v to be 27 or 28, got %d", v)
+	}
+
+	// Signatures are deterministic (RFC 6979)
+	const expected = "0xce61161875bc1ca897927912240504ef3b1aa316aa360f3dfbb03a6693c509d713c7562694657bffc14b9b82e1859cc6c0eea5b5b392b6efdea6a7f8be66fd1c1c"
+	if auth["signature"] != expected {
+		t.Errorf("Expected signature %s, got %s", expected, auth["signature"])
+	}
+
+	// The signature must recover to the signer address
+	message := `{"side":"BUY","symbol":"BTCUSDT","timestamp":"1"}`
+	hash := keccak256(abiEncode(message, testUserAddress, testSignerAddress, 1700000000000000))
+	digest := keccak256([]byte("\x19Ethereum Signed Message:\n32"), hash)
+	compact := append([]byte{signature[64]}, signature[:64]...)
+	pub, _, err := ecdsa.RecoverCompact(compact, digest)
+	if err != nil {
+		t.Fatalf("RecoverCompact failed: %v", err)
+	}
+	if addr := publicKeyToAddress(pub); addr != testSignerAddress {
+		t.Errorf("Expected recovered address %s, got %s", testSignerAddress, addr)
+	}
+}
+
+func TestWeb3SignerPersonalMessage(t *testing.T) {
+	signer, err := NewWeb3Signer(testUserAddress, testPrivateKey)
+	if err != nil {
+		t.Fatalf("NewWeb3Signer failed: %v", err)
+	}
+
+	// Known vector from the web3.js documentation of accounts.sign
+	signature, err := signer.signPersonalMessage([]byte("Some data"))
+	if err != nil {
+		t.Fatalf("signPersonalMessage failed: %v", err)
+	}
+	const expected = "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c"
+	if got := hex.EncodeToString(signature); got != expected {
+		t.Errorf("Expected signature %s, got %s", expected, got)
+	}
+}
+
+func TestABIEncode(t *testing.T) {
+	encoded := abiEncode("abc", testUserAddress, testSignerAddress, 7)
+	if len(encoded) != 6*32 {
+		t.Fatalf("Expected %d bytes, got %d", 6*32, len(encoded))
+	}
+	if encoded[31] != 0x80 {
+		t.Errorf("Expected string offset 0x80, got %x", encoded[31])
+	}
+	if hex.EncodeToString(encoded[44:64]) != strings.TrimPrefix(testUserAddress, "0x") {
+		t.Errorf("Unexpected user word: %x", encoded[32:64])
+	}
+	if encoded[127] != 7 || encoded[159] != 3 {
+		t.Errorf("Unexpected nonce or length word")
+	}
+	if !bytes.Equal(encoded[160:163], []byte("abc")) {
+		t.Errorf("Unexpected string contents: %x", encoded[160:])
+	}
+}
+
+func TestDoRequestWithWeb3Signer(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		query = r.Form.Encode()
+		for _, key := range []string{"symbol", "timestamp", "recvWindow", "user", "signer", "nonce", "signature"} {
+			if r.Form.Get(key) == "" {
+				t.Errorf("Expected %s to be sent", key)
+			}
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	signer, err := NewWeb3Signer(testUserAddress, testPrivateKey)
+	if err != nil {
+		t.Fatalf("NewWeb3Signer failed: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.BaseURL = server.URL
+	config.Signer = signer
+	client := NewClient(config)
+
+	if err := client.Do("POST", "/fapi/v3/order", map[string]any{"symbol": "BTCUSDT"}, nil, true); err != nil {
+		t.Fatalf("Do failed: %v (sent %s)", err, query)
+	}
+}
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// Subscription delivers the decoded messages of a stream on a channel until
+// it is closed, its context is done or its client disconnects. Messages are
+// delivered one at a time: a subscription whose channel is not read holds up
+// its stream like a slow handler does.
+type Subscription[T any] struct {
+	stream string
+	ch     chan T
+	done   chan struct{}
+	remove func() error
+
+	// mu is held while a message is sent, so that the channel is only
+	// closed once no send is in flight
+	mu     sync.Mutex
+	closed bool
+	stop   sync.Once
+
+	errMu sync.Mutex
+	err   error
+}
+
+// NewSubscription adds a handler to stream on sub which decodes the messages
+// with decode and sends them on the channel of the returned subscription.
+// decode is told whether sub parses strictly. Messages that fail to decode
+// are reported with sub.ReportParseError, and dropped unless the error is a
+// FieldsError. The stream is unsubscribed once its last subscription or
+// handler is gone.
+func NewSubscription[T any](ctx context.Context, sub StreamSubscriber, stream string, decode func(data json.RawMessage, strict bool) (T, error)) (*Subscription[T], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s := &Subscription[T]{
+		stream: stream,
+		ch:     make(chan T),
+		done:   make(chan struct{}),
+	}
+	remove, err := sub.AddHandler(stream, func(data json.RawMessage) {
+		v, err := decode(data, sub.StrictParsing())
+		if err != nil {
+			sub.ReportParseError(stream, data, err)
+			if !errors.As(err, &FieldsError{}) {
+				return
+			}
+		}
+		s.send(v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.remove = remove
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.close(ctx.Err())
+		case <-sub.Done():
+			s.close(ErrClientClosed)
+		case <-s.done:
+		}
+	}()
+	return s, nil
+}
+
+// Stream returns the name of the stream
+func (s *Subscription[T]) Stream() string {
+	return s.stream
+}
+
+// C returns the channel receiving the messages, closed when the
+// subscription ends
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Err returns why the subscription ended: the error of its context,
+// ErrClientClosed when its client disconnected, or nil while it is active
+// and after Close
+func (s *Subscription[T]) Err() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription. The stream is unsubscribed, and the error of
+// the UNSUBSCRIBE request returned, when no other subscription or handler
+// needs it.
+func (s *Subscription[T]) Close() error {
+	return s.close(nil)
+}
+
+// close ends the subscription with err
+func (s *Subscription[T]) close(err error) error {
+	var removeErr error
+	s.stop.Do(func() {
+		s.errMu.Lock()
+		s.err = err
+		s.errMu.Unlock()
+
+		close(s.done)
+		removeErr = s.remove()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.closed = true
+		close(s.ch)
+	})
+	return removeErr
+}
+
+// send passes v to the reader of the channel, unless the subscription ends
+// first
+func (s *Subscription[T]) send(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- v:
+	case <-s.done:
+	}
+}
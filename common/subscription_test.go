@@ -0,0 +1,199 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testTrade struct {
+	ID int64 `json:"t"`
+}
+
+func decodeTestTrade(data json.RawMessage, strict bool) (testTrade, error) {
+	var trade testTrade
+	err := DecodeEvent(data, &trade, strict)
+	return trade, err
+}
+
+// receive waits for a message on ch
+func receive[T any](t *testing.T, ch <-chan T) (T, bool) {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting on the subscription channel")
+		var zero T
+		return zero, false
+	}
+}
+
+func TestSubscriptionSharesStream(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	defer client.Disconnect()
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	conn := server.nextConn(t)
+
+	ctx := context.Background()
+	first, err := NewSubscription(ctx, client, "btcusdt@trade", decodeTestTrade)
+	if err != nil {
+		t.Fatalf("NewSubscription failed: %v", err)
+	}
+	if msg := server.nextMessage(t); msg["method"] != "SUBSCRIBE" {
+		t.Fatalf("Unexpected message: %v", msg)
+	}
+	second, err := NewSubscription(ctx, client, "btcusdt@trade", decodeTestTrade)
+	if err != nil {
+		t.Fatalf("NewSubscription failed: %v", err)
+	}
+
+	server.send(conn.Conn, map[string]any{"stream": "btcusdt@trade", "data": map[string]any{"t": 1}})
+	for _, sub := range []*Subscription[testTrade]{first, second} {
+		if trade, _ := receive(t, sub.C()); trade.ID != 1 {
+			t.Errorf("Expected trade 1, got %d", trade.ID)
+		}
+	}
+
+	// The stream stays subscribed while the second subscription needs it
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, ok := receive(t, first.C()); ok {
+		t.Error("Expected the channel of a closed subscription to be closed")
+	}
+	if first.Err() != nil {
+		t.Errorf("Expected no error after Close, got %v", first.Err())
+	}
+	server.send(conn.Conn, map[string]any{"stream": "btcusdt@trade", "data": map[string]any{"t": 2}})
+	if trade, _ := receive(t, second.C()); trade.ID != 2 {
+		t.Errorf("Expected trade 2, got %d", trade.ID)
+	}
+
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if msg := server.nextMessage(t); msg["method"] != "UNSUBSCRIBE" || strings.Join(messageStreams(msg), ",") != "btcusdt@trade" {
+		t.Fatalf("Unexpected message: %v", msg)
+	}
+	if streams := client.Subscriptions(); len(streams) != 0 {
+		t.Errorf("Expected no subscriptions, got %v", streams)
+	}
+}
+
+func TestSubscriptionContext(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	defer client.Disconnect()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := NewSubscription(ctx, client, "btcusdt@trade", decodeTestTrade)
+	if err != nil {
+		t.Fatalf("NewSubscription failed: %v", err)
+	}
+	other, err := NewSubscription(context.Background(), client, "ethusdt@trade", decodeTestTrade)
+	if err != nil {
+		t.Fatalf("NewSubscription failed: %v", err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	server.nextConn(t)
+
+	cancel()
+	if _, ok := receive(t, sub.C()); ok {
+		t.Error("Expected the channel to be closed with its context")
+	}
+	if !errors.Is(sub.Err(), context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", sub.Err())
+	}
+	if msg := server.nextMessage(t); msg["method"] != "UNSUBSCRIBE" || strings.Join(messageStreams(msg), ",") != "btcusdt@trade" {
+		t.Fatalf("Unexpected message: %v", msg)
+	}
+
+	// Disconnecting ends the remaining subscriptions
+	client.Disconnect()
+	if _, ok := receive(t, other.C()); ok {
+		t.Error("Expected the channel to be closed on disconnect")
+	}
+	if !errors.Is(other.Err(), ErrClientClosed) {
+		t.Errorf("Expected ErrClientClosed, got %v", other.Err())
+	}
+
+	if _, err := NewSubscription(ctx, client, "btcusdt@trade", decodeTestTrade); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled for a done context, got %v", err)
+	}
+}
+
+func TestSubscriptionParseErrors(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	defer client.Disconnect()
+	parseErrs := make(chan ParseError, 4)
+	client.SetOnParseError(func(err ParseError) {
+		parseErrs <- err
+	})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	conn := server.nextConn(t)
+
+	sub, err := NewSubscription(context.Background(), client, "btcusdt@trade", decodeTestTrade)
+	if err != nil {
+		t.Fatalf("NewSubscription failed: %v", err)
+	}
+	defer sub.Close()
+	server.nextMessage(t)
+
+	// A malformed message is reported and dropped
+	server.send(conn.Conn, map[string]any{"stream": "btcusdt@trade", "data": map[string]any{"t": "x"}})
+	parseErr, _ := receive(t, parseErrs)
+	if parseErr.Stream != "btcusdt@trade" || string(parseErr.Data) != `{"t":"x"}` {
+		t.Errorf("Unexpected parse error: %v (%s)", parseErr, parseErr.Data)
+	}
+
+	// In strict mode, unknown fields are reported but the message delivered
+	client.SetStrictParsing(true)
+	server.send(conn.Conn, map[string]any{"stream": "btcusdt@trade", "data": map[string]any{"t": 1, "x": 2}})
+	if trade, _ := receive(t, sub.C()); trade.ID != 1 {
+		t.Errorf("Expected trade 1, got %d", trade.ID)
+	}
+	parseErr, _ = receive(t, parseErrs)
+	var fieldsErr FieldsError
+	if !errors.As(parseErr, &fieldsErr) || strings.Join(fieldsErr.Unknown, ",") != "x" {
+		t.Errorf("Expected unknown field x, got %v", parseErr)
+	}
+}
+
+func TestSubscriptionOnPool(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	pool := NewWebSocketPool(func() *WebSocketClient {
+		return NewWebSocketClient(server.URL())
+	}, 1)
+	defer pool.Disconnect()
+
+	ctx := context.Background()
+	first, _ := NewSubscription(ctx, pool, "btcusdt@trade", decodeTestTrade)
+	second, _ := NewSubscription(ctx, pool, "btcusdt@trade", decodeTestTrade)
+	if err := pool.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	server.nextConn(t)
+
+	first.Close()
+	if n := len(pool.Clients()); n != 1 {
+		t.Fatalf("Expected the connection to stay for the second subscription, got %d", n)
+	}
+	second.Close()
+	waitForClients(t, pool, 0)
+}
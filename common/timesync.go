@@ -0,0 +1,110 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultTimeSyncInterval is used when TimeSync is enabled without an interval
+const defaultTimeSyncInterval = 30 * time.Minute
+
+// timeSyncRetry is the delay before retrying a failed automatic sync, unless
+// the interval is shorter
+const timeSyncRetry = time.Minute
+
+// SetServerTimeEndpoint sets the endpoint used to measure the server clock
+// offset. It must return a JSON object with a serverTime field.
+func (c *Client) SetServerTimeEndpoint(endpoint string) {
+	c.timeMu.Lock()
+	defer c.timeMu.Unlock()
+	c.timeEndpoint = endpoint
+}
+
+// SyncTime measures the offset between the local and the server clock and
+// applies it to the timestamp of every signed request
+func (c *Client) SyncTime(ctx context.Context) error {
+	c.timeMu.Lock()
+	endpoint := c.timeEndpoint
+	c.timeMu.Unlock()
+
+	if endpoint == "" {
+		return fmt.Errorf("server time endpoint is not set")
+	}
+
+	var result struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	before := GetTimestamp()
+	if err := c.DoCtx(ctx, "GET", endpoint, nil, &result, false); err != nil {
+		return err
+	}
+	after := GetTimestamp()
+
+	// Assume the server read its clock halfway through the round trip
+	offset := result.ServerTime - (before+after)/2
+
+	c.timeMu.Lock()
+	c.timeOffset = offset
+	c.lastTimeSync = time.Now()
+	c.timeMu.Unlock()
+
+	return nil
+}
+
+// TimeOffset returns the measured server clock offset
+func (c *Client) TimeOffset() time.Duration {
+	c.timeMu.Lock()
+	defer c.timeMu.Unlock()
+	return time.Duration(c.timeOffset) * time.Millisecond
+}
+
+// Timestamp returns the current server time in milliseconds, as estimated
+// from the local clock and the measured offset
+func (c *Client) Timestamp() int64 {
+	c.timeMu.Lock()
+	defer c.timeMu.Unlock()
+	return GetTimestamp() + c.timeOffset
+}
+
+// syncTimeIfStale re-syncs the clock offset when TimeSync is enabled and the
+// last measurement is older than TimeSyncInterval. Concurrent requests wait
+// for a single sync. Failures keep the previous offset and are retried after
+// a minute; the request then goes out and reports its own error.
+func (c *Client) syncTimeIfStale(ctx context.Context) {
+	if !c.config.TimeSync {
+		return
+	}
+
+	interval := c.config.TimeSyncInterval
+	if interval <= 0 {
+		interval = defaultTimeSyncInterval
+	}
+
+	c.timeMu.Lock()
+	if syncing := c.timeSyncing; syncing != nil {
+		c.timeMu.Unlock()
+		select {
+		case <-syncing:
+		case <-ctx.Done():
+		}
+		return
+	}
+	stale := time.Since(c.lastTimeSync) >= interval &&
+		time.Since(c.timeSyncAttempt) >= min(interval, timeSyncRetry)
+	if !stale {
+		c.timeMu.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	c.timeSyncing = done
+	c.timeSyncAttempt = time.Now()
+	c.timeMu.Unlock()
+
+	c.SyncTime(ctx)
+
+	c.timeMu.Lock()
+	c.timeSyncing = nil
+	c.timeMu.Unlock()
+	close(done)
+}
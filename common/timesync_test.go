@@ -0,0 +1,150 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testClockSkew = 10 * time.Second
+
+func newTimeSyncServer(t *testing.T, handler http.HandlerFunc) (*Client, *atomic.Int32) {
+	var timeCalls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/time", func(w http.ResponseWriter, r *http.Request) {
+		timeCalls.Add(1)
+		serverTime := time.Now().Add(testClockSkew).UnixMilli()
+		json.NewEncoder(w).Encode(map[string]int64{"serverTime": serverTime})
+	})
+	if handler != nil {
+		mux.HandleFunc("/test", handler)
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	config := DefaultConfig()
+	config.BaseURL = server.URL
+	config.APIKey = "test-api-key"
+	config.SecretKey = "test-secret-key"
+	config.TimeSync = true
+
+	client := NewClient(config)
+	client.SetServerTimeEndpoint("/time")
+	return client, &timeCalls
+}
+
+func TestSyncTime(t *testing.T) {
+	client, _ := newTimeSyncServer(t, nil)
+
+	if err := client.SyncTime(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if diff := client.TimeOffset() - testClockSkew; diff < -time.Second || diff > time.Second {
+		t.Errorf("Expected offset close to %v, got %v", testClockSkew, client.TimeOffset())
+	}
+
+	expected := time.Now().Add(testClockSkew).UnixMilli()
+	if diff := client.Timestamp() - expected; diff < -1000 || diff > 1000 {
+		t.Errorf("Expected timestamp close to %d, got %d", expected, client.Timestamp())
+	}
+}
+
+func TestSyncTimeWithoutEndpoint(t *testing.T) {
+	client := NewClient(nil)
+	if err := client.SyncTime(context.Background()); err == nil {
+		t.Error("Expected error without server time endpoint")
+	}
+}
+
+func TestTimestampErrorResyncs(t *testing.T) {
+	var calls atomic.Int32
+	client, timeCalls := newTimeSyncServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": -1021, "msg": "Timestamp for this request is outside of the recvWindow."}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+
+	if err := client.Do("POST", "/test", nil, nil, true); err != nil {
+		t.Fatalf("Expected no error after resync, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls.Load())
+	}
+	// One sync before the first signed request, one after the rejection
+	if timeCalls.Load() != 2 {
+		t.Errorf("Expected 2 time syncs, got %d", timeCalls.Load())
+	}
+}
+
+func TestSyncTimeIfStaleOnce(t *testing.T) {
+	var timeCalls atomic.Int32
+	failing := atomic.Bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/time" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		timeCalls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]int64{"serverTime": time.Now().UnixMilli()})
+	}))
+	t.Cleanup(server.Close)
+
+	config := DefaultConfig()
+	config.BaseURL = server.URL
+	config.TimeSync = true
+	client := NewClient(config)
+	client.SetServerTimeEndpoint("/time")
+
+	// Concurrent requests share one sync
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.syncTimeIfStale(context.Background())
+		}()
+	}
+	wg.Wait()
+	if n := timeCalls.Load(); n != 1 {
+		t.Errorf("Expected 1 time sync, got %d", n)
+	}
+
+	// A failed sync is not retried by every request
+	failing.Store(true)
+	client.timeMu.Lock()
+	client.lastTimeSync = time.Time{}
+	client.timeSyncAttempt = time.Time{}
+	client.timeMu.Unlock()
+	for range 3 {
+		client.syncTimeIfStale(context.Background())
+	}
+	if n := timeCalls.Load(); n != 2 {
+		t.Errorf("Expected 1 more time sync after a failure, got %d", n-1)
+	}
+}
+
+func TestBuildRequestDoesNotSyncTime(t *testing.T) {
+	client, timeCalls := newTimeSyncServer(t, nil)
+
+	if _, err := client.BuildRequest(context.Background(), "GET", "/test", nil, true); err != nil {
+		t.Fatalf("BuildRequest failed: %v", err)
+	}
+	if timeCalls.Load() != 0 {
+		t.Errorf("Expected BuildRequest not to sync time, got %d syncs", timeCalls.Load())
+	}
+}
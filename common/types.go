@@ -4,24 +4,12 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
-	"fmt"
+	"log/slog"
 	"net/url"
-	"sort"
 	"strconv"
-	"strings"
 	"time"
 )
 
-// APIError represents an API error response
-type APIError struct {
-	Code int    `json:"code"`
-	Msg  string `json:"msg"`
-}
-
-func (e APIError) Error() string {
-	return fmt.Sprintf("API Error %d: %s", e.Code, e.Msg)
-}
-
 // ClientConfig holds the configuration for the API client
 type ClientConfig struct {
 	APIKey     string
@@ -30,6 +18,22 @@ type ClientConfig struct {
 	Testnet    bool
 	Timeout    time.Duration
 	RecvWindow int64
+	// RateLimitMode selects whether requests block or fail fast when a
+	// rate limit budget is exhausted
+	RateLimitMode RateLimitMode
+	// Retry configures automatic retries of transient failures
+	Retry RetryPolicy
+	// TimeSync corrects signed request timestamps by the server clock
+	// offset, measured every TimeSyncInterval (30 minutes by default)
+	TimeSync         bool
+	TimeSyncInterval time.Duration
+	// Signer authenticates signed requests. When nil, requests are signed
+	// with HMAC SHA256 using SecretKey.
+	Signer Signer
+	// Logger receives structured events about requests, responses and
+	// retries. Secrets and signatures are redacted. Nothing is logged when
+	// nil.
+	Logger     *slog.Logger
 	httpClient HTTPClient
 }
 
@@ -40,50 +44,32 @@ func DefaultConfig() *ClientConfig {
 		Testnet:    false,
 		Timeout:    30 * time.Second,
 		RecvWindow: 5000,
+		Retry:      DefaultRetryPolicy(),
 	}
 }
 
-// SignRequest creates a signature for authenticated requests
+// SignRequest returns the HMAC SHA256 signature of the canonical query
+// string of params, the exact bytes sent by the client
 func SignRequest(params map[string]string, secretKey string) string {
-	// Create query string
-	var queryParts []string
-	for key, value := range params {
-		queryParts = append(queryParts, fmt.Sprintf("%s=%s", key, value))
-	}
-
-	// Sort parameters
-	sort.Strings(queryParts)
-	queryString := strings.Join(queryParts, "&")
+	return signHMAC(CanonicalQuery(params), secretKey)
+}
 
-	// Create HMAC SHA256 signature
+// signHMAC returns the hex encoded HMAC SHA256 of payload
+func signHMAC(payload, secretKey string) string {
 	h := hmac.New(sha256.New, []byte(secretKey))
-	h.Write([]byte(queryString))
+	h.Write([]byte(payload))
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-// BuildQueryString builds a query string from parameters
+// BuildQueryString builds a query string from parameters, leaving out
+// values that EncodeParams can't encode
+//
+// Deprecated: use EncodeParams, which reports unsupported values.
 func BuildQueryString(params map[string]any) string {
 	values := url.Values{}
 	for key, value := range params {
-		switch v := value.(type) {
-		case string:
-			if v != "" {
-				values.Add(key, v)
-			}
-		case int:
-			if v != 0 {
-				values.Add(key, strconv.Itoa(v))
-			}
-		case int64:
-			if v != 0 {
-				values.Add(key, strconv.FormatInt(v, 10))
-			}
-		case float64:
-			if v != 0 {
-				values.Add(key, strconv.FormatFloat(v, 'f', -1, 64))
-			}
-		case bool:
-			values.Add(key, strconv.FormatBool(v))
+		if encoded, ok, err := encodeParam(value); err == nil && ok {
+			values.Set(key, encoded)
 		}
 	}
 	return values.Encode()
@@ -92,7 +92,7 @@ func TestBuildQueryString(t *testing.T) {
 				"limit":  0,
 				"price":  0.0,
 			},
-			expected: "symbol=BTCUSDT",
+			expected: "limit=0&price=0&symbol=BTCUSDT",
 		},
 		{
 			name: "empty string",
@@ -3,25 +3,77 @@ package common
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/url"
+	"log/slog"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// Default heartbeat settings
+const (
+	defaultPingInterval = 30 * time.Second
+	defaultReadTimeout  = 60 * time.Second
+	writeWait           = 10 * time.Second
+	defaultCallTimeout  = 10 * time.Second
+)
+
+// ErrNotConnected is returned by requests made while the client is not
+// connected
+var ErrNotConnected = errors.New("websocket not connected")
+
 // WebSocketClient represents a WebSocket client
 type WebSocketClient struct {
-	conn              *websocket.Conn
-	url               string
-	mu                sync.RWMutex
-	handlers          map[string][]func(json.RawMessage)
-	connected         bool
-	reconnect         bool
-	reconnectInterval time.Duration
-	ctx               context.Context
-	cancel            context.CancelFunc
+	conn            *websocket.Conn
+	url             string
+	mu              sync.RWMutex
+	handlers        map[string][]*streamHandler
+	connected       bool
+	reconnect       bool
+	reconnectPolicy ReconnectPolicy
+	ctx             context.Context
+	cancel          context.CancelFunc
+	logger          *slog.Logger
+	hooks           wsHooks
+	state           ConnState
+	stateChans      []chan StateChange
+	pingInterval    time.Duration
+	readTimeout     time.Duration
+	staleTimeout    time.Duration
+	queues          map[string]*streamQueue
+	queueSize       int
+	overflowPolicy  OverflowPolicy
+	callTimeout     time.Duration
+	messageRate     int
+	mode            StreamMode
+	rotateAfter     time.Duration
+	rotateOverlap   time.Duration
+	writer          *wsWriter
+	nextID          atomic.Int64 // id of the last request
+
+	seenMu   sync.Mutex
+	lastSeen map[string]time.Time // last message per stream
+
+	// handoff moves the streams from the old to the new connection while
+	// both overlap during a rotation
+	handoffMu sync.Mutex
+	handoff   *rotationHandoff
+
+	strictParsing bool
+}
+
+// StreamSubscriber is what stream subscriptions are made on, a
+// WebSocketClient or a WebSocketPool
+type StreamSubscriber interface {
+	AddHandler(stream string, handler func(json.RawMessage)) (remove func() error, err error)
+	ReportParseError(stream string, data json.RawMessage, err error)
+	StrictParsing() bool
+	Done() <-chan struct{}
 }
 
 // WebSocketMessage represents a WebSocket message
@@ -30,111 +82,508 @@ type WebSocketMessage struct {
 	Data   json.RawMessage `json:"data"`
 }
 
+// wsResponse is the response to a request, matched to it by ID. Errors come
+// either as a top level code and msg or as an error object.
+type wsResponse struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+	ID     *int64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Code   int             `json:"code"`
+	Msg    string          `json:"msg"`
+	Error  *WebSocketError `json:"error"`
+}
+
 // NewWebSocketClient creates a new WebSocket client
 func NewWebSocketClient(baseURL string) *WebSocketClient {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &WebSocketClient{
-		url:               baseURL,
-		handlers:          make(map[string][]func(json.RawMessage)),
-		reconnect:         true,
-		reconnectInterval: 5 * time.Second,
-		ctx:               ctx,
-		cancel:            cancel,
+		url:             baseURL,
+		handlers:        make(map[string][]*streamHandler),
+		reconnect:       true,
+		reconnectPolicy: DefaultReconnectPolicy(),
+		ctx:             ctx,
+		cancel:          cancel,
+		logger:          discardLogger,
+		pingInterval:    defaultPingInterval,
+		readTimeout:     defaultReadTimeout,
+		lastSeen:        make(map[string]time.Time),
+		queues:          make(map[string]*streamQueue),
+		queueSize:       defaultQueueSize,
+		overflowPolicy:  defaultOverflowPolicy,
+		callTimeout:     defaultCallTimeout,
+		messageRate:     defaultMessageRate,
 	}
 }
 
+// SetLogger sets the logger receiving connection, subscription and parse
+// events. A nil logger drops them.
+func (c *WebSocketClient) SetLogger(logger *slog.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = loggerOrDiscard(logger)
+}
+
+// Logger returns the logger of the client
+func (c *WebSocketClient) Logger() *slog.Logger {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.logger
+}
+
+// ReportParseError reports a stream message that could not be decoded to
+// the logger and the parse error hook
+func (c *WebSocketClient) ReportParseError(stream string, data json.RawMessage, err error) {
+	c.Logger().Warn("aster: websocket message parse failed",
+		"stream", redactStream(stream),
+		"size", len(data),
+		"error", err,
+	)
+	if hook := c.getHooks().onParseError; hook != nil {
+		hook(ParseError{Stream: stream, Data: data, Err: err})
+	}
+}
+
+// SetStrictParsing makes the Subscribe* helpers report the events with
+// missing or unknown fields as parse errors, to catch changes of the event
+// schemas. Such events are still delivered.
+func (c *WebSocketClient) SetStrictParsing(strict bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.strictParsing = strict
+}
+
+// StrictParsing returns whether strict parsing is enabled
+func (c *WebSocketClient) StrictParsing() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.strictParsing
+}
+
 // Connect establishes a WebSocket connection
 func (c *WebSocketClient) Connect() error {
+	writer, rest, err := c.connect(0)
+	if err != nil {
+		return err
+	}
+	c.subscribeRest(writer, rest)
+	return nil
+}
+
+// connect connects for the first time, or for the given reconnect attempt,
+// and reports the outcome. It returns the writer of the connection and the
+// streams that didn't fit in its URL.
+func (c *WebSocketClient) connect(attempt int) (*wsWriter, []string, error) {
+	if attempt > 0 {
+		c.setState(StateChange{State: StateReconnecting, Attempt: attempt})
+	} else {
+		c.setState(StateChange{State: StateConnecting})
+	}
+
+	writer, rest, err := c.dial()
+	if err != nil {
+		c.setState(StateChange{State: StateDisconnected, Err: err})
+		return nil, nil, err
+	}
+
+	c.setState(StateChange{State: StateConnected})
+	if hook := c.getHooks().onConnect; hook != nil {
+		hook()
+	}
+	return writer, rest, nil
+}
+
+// dial opens a connection and starts its goroutines
+func (c *WebSocketClient) dial() (*wsWriter, []string, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	u, err := url.Parse(c.url)
+	if c.ctx.Err() != nil {
+		return nil, nil, ErrClientClosed
+	}
+
+	writer, rest, err := c.open()
 	if err != nil {
-		return fmt.Errorf("invalid WebSocket URL: %w", err)
+		return nil, nil, err
+	}
+	c.connected = true
+	c.resetSeen(c.streams())
+	return writer, rest, nil
+}
+
+// open dials a connection for the tracked streams, makes it the current one
+// and starts its goroutines. It returns the streams that didn't fit in the
+// URL. The caller must hold c.mu.
+func (c *WebSocketClient) open() (*wsWriter, []string, error) {
+	// The subscriptions made before connecting or lost with the previous
+	// connection go in the URL when possible
+	conn, rest, err := dialStreams(c.logger, c.url, c.mode, c.streams())
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.start(conn), rest, nil
+}
+
+// dialStreams dials a connection to the streams on baseURL, and returns the
+// streams that didn't fit in the URL
+func dialStreams(logger *slog.Logger, baseURL string, mode StreamMode, streams []string) (*websocket.Conn, []string, error) {
+	streamURL, rest, err := endpoint(baseURL, mode, streams)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	// The URL names the streams, listen keys included
+	logURL, _, _ := endpoint(baseURL, mode, redactStreams(streams))
+	conn, _, err := websocket.DefaultDialer.Dial(streamURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+		logger.Warn("aster: websocket connect failed", "url", logURL, "error", err)
+		return nil, nil, fmt.Errorf("failed to connect to WebSocket: %w", err)
 	}
+	logger.Info("aster: websocket connected", "url", logURL, "mode", mode)
+	return conn, rest, nil
+}
 
+// start makes conn the current connection and starts its message handler and
+// writer. The caller must hold c.mu.
+func (c *WebSocketClient) start(conn *websocket.Conn) *wsWriter {
+	writer := newWSWriter(conn, c.messageRate, &c.nextID)
 	c.conn = conn
-	c.connected = true
+	c.writer = writer
+	go writer.run()
+	go c.handleMessages(conn, writer)
+	return writer
+}
+
+// subscribeRest subscribes, in the background, to the streams that didn't
+// fit in the URL of the connection of writer
+func (c *WebSocketClient) subscribeRest(writer *wsWriter, streams []string) {
+	if len(streams) == 0 {
+		return
+	}
+	go func() {
+		if _, err := c.call(writer, "SUBSCRIBE", streams); err != nil {
+			c.reportError("aster: websocket resubscribe failed", err, "streams", redactStreams(streams))
+			return
+		}
+		c.Logger().Debug("aster: websocket resubscribed", "streams", redactStreams(streams))
+	}()
+}
 
-	// Start message handler
-	go c.handleMessages()
+// resubscribe subscribes to the streams that didn't fit in the URL of the
+// connection of writer, and waits for the server to acknowledge them. Each
+// stream is its own request, merged into one message by the writer, so that
+// a rejection fails only the streams at fault. It returns the streams that
+// failed.
+func (c *WebSocketClient) resubscribe(writer *wsWriter, streams []string) []string {
+	if len(streams) == 0 {
+		return nil
+	}
 
-	return nil
+	results := make([]<-chan wsResult, len(streams))
+	for i, stream := range streams {
+		results[i] = writer.enqueue(c.nextID.Add(1), "SUBSCRIBE", []string{stream})
+	}
+
+	c.mu.RLock()
+	timeout := c.callTimeout
+	c.mu.RUnlock()
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+
+	var failed []string
+	for i, done := range results {
+		var err error
+		select {
+		case res := <-done:
+			err = res.err
+		case <-ctx.Done():
+			err = fmt.Errorf("SUBSCRIBE timed out after %s", timeout)
+			if c.ctx.Err() != nil {
+				err = ErrNotConnected
+			}
+		}
+		if err != nil {
+			c.reportError("aster: websocket resubscribe failed", err, "stream", redactStream(streams[i]))
+			failed = append(failed, streams[i])
+		}
+	}
+	if len(failed) < len(streams) {
+		c.Logger().Debug("aster: websocket resubscribed", "streams", len(streams)-len(failed))
+	}
+	return failed
 }
 
 // Disconnect closes the WebSocket connection
 func (c *WebSocketClient) Disconnect() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	c.cancel()
+	wasConnected := c.connected
 	c.connected = false
+	c.logger.Info("aster: websocket disconnected", "url", c.url)
 
+	var err error
 	if c.conn != nil {
-		return c.conn.Close()
+		err = c.conn.Close()
 	}
+	c.mu.Unlock()
 
-	return nil
+	c.setState(StateChange{State: StateDisconnected})
+	if hook := c.getHooks().onDisconnect; wasConnected && hook != nil {
+		hook(nil)
+	}
+	return err
+}
+
+// Subscribe subscribes to a stream. While connected, it waits for the server
+// to acknowledge a new stream and returns the error of a rejected one, which
+// is then no longer tracked. If the request fails otherwise, e.g. because the
+// connection drops, the stream stays tracked and is subscribed again on
+// reconnect.
+func (c *WebSocketClient) Subscribe(stream string, handler func(json.RawMessage)) error {
+	_, err := c.AddHandler(stream, handler)
+	return err
 }
 
-// Subscribe subscribes to a stream
-func (c *WebSocketClient) Subscribe(stream string, handler func(json.RawMessage)) {
+// AddHandler subscribes handler to a stream like Subscribe, and returns a
+// function removing that handler alone. Once the last handler of the stream
+// is removed, the stream is unsubscribed.
+func (c *WebSocketClient) AddHandler(stream string, handler func(json.RawMessage)) (remove func() error, err error) {
+	h := &streamHandler{fn: handler}
+	remove = func() error { return c.removeHandler(stream, h) }
+
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	_, tracked := c.handlers[stream]
+	c.handlers[stream] = append(c.handlers[stream], h)
+	if !tracked {
+		c.resetSeen([]string{stream})
+		queue := newStreamQueue(c.queueSize, c.overflowPolicy)
+		c.queues[stream] = queue
+		go queue.run(func() []*streamHandler {
+			c.mu.RLock()
+			defer c.mu.RUnlock()
+			return c.handlers[stream]
+		}, c.ctx.Done())
+	}
+	writer, connected := c.writer, c.connected
+	c.mu.Unlock()
 
-	if c.handlers[stream] == nil {
-		c.handlers[stream] = make([]func(json.RawMessage), 0)
+	// Send subscription message if connected and the stream is new
+	if tracked || !connected || writer == nil {
+		return remove, nil
 	}
-	c.handlers[stream] = append(c.handlers[stream], handler)
 
-	// Send subscription message if connected
-	if c.connected && c.conn != nil {
-		subscribeMsg := map[string]any{
-			"method": "SUBSCRIBE",
-			"params": []string{stream},
-			"id":     time.Now().Unix(),
+	if _, err := c.call(writer, "SUBSCRIBE", []string{stream}); err != nil {
+		c.Logger().Warn("aster: websocket subscribe failed", "stream", redactStream(stream), "error", err)
+		if errors.As(err, &WebSocketError{}) {
+			c.mu.Lock()
+			c.untrack(stream)
+			c.mu.Unlock()
+			return nil, err
 		}
+		return remove, err
+	}
+	c.Logger().Debug("aster: websocket subscribed", "stream", redactStream(stream))
+	return remove, nil
+}
+
+// removeHandler removes h from the handlers of stream, and unsubscribes the
+// stream when it was the last one
+func (c *WebSocketClient) removeHandler(stream string, h *streamHandler) error {
+	c.mu.Lock()
+	handlers := c.handlers[stream]
+	i := slices.Index(handlers, h)
+	if i < 0 {
+		// Already removed, or the stream was unsubscribed
+		c.mu.Unlock()
+		return nil
+	}
+	if len(handlers) > 1 {
+		// The queue may be iterating over the current slice
+		c.handlers[stream] = slices.Delete(slices.Clone(handlers), i, i+1)
+		c.mu.Unlock()
+		return nil
+	}
+	c.untrack(stream)
+	writer, connected := c.writer, c.connected
+	c.mu.Unlock()
 
-		c.conn.WriteJSON(subscribeMsg)
+	if !connected || writer == nil {
+		return nil
 	}
+	return c.unsubscribe(writer, stream)
 }
 
-// Unsubscribe unsubscribes from a stream
-func (c *WebSocketClient) Unsubscribe(stream string) {
+// Unsubscribe unsubscribes from a stream, removing all of its handlers.
+// While connected, it waits for the server to acknowledge the request.
+func (c *WebSocketClient) Unsubscribe(stream string) error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	_, tracked := c.handlers[stream]
+	c.untrack(stream)
+	writer, connected := c.writer, c.connected
+	c.mu.Unlock()
+
+	// Send unsubscription message if connected
+	if !tracked || !connected || writer == nil {
+		return nil
+	}
+	return c.unsubscribe(writer, stream)
+}
+
+// unsubscribe sends the UNSUBSCRIBE request of stream
+func (c *WebSocketClient) unsubscribe(writer *wsWriter, stream string) error {
+	if _, err := c.call(writer, "UNSUBSCRIBE", []string{stream}); err != nil {
+		c.Logger().Warn("aster: websocket unsubscribe failed", "stream", redactStream(stream), "error", err)
+		return err
+	}
+	c.Logger().Debug("aster: websocket unsubscribed", "stream", redactStream(stream))
+	return nil
+}
 
+// untrack forgets stream and stops its queue. The caller must hold c.mu.
+func (c *WebSocketClient) untrack(stream string) {
 	delete(c.handlers, stream)
+	if queue := c.queues[stream]; queue != nil {
+		queue.close()
+		delete(c.queues, stream)
+	}
+	c.seenMu.Lock()
+	delete(c.lastSeen, stream)
+	c.seenMu.Unlock()
+}
 
-	// Send unsubscription message if connected
-	if c.connected && c.conn != nil {
-		unsubscribeMsg := map[string]any{
-			"method": "UNSUBSCRIBE",
-			"params": []string{stream},
-			"id":     time.Now().Unix(),
-		}
+// ListSubscriptions asks the server for the streams the connection is
+// subscribed to
+func (c *WebSocketClient) ListSubscriptions() ([]string, error) {
+	result, err := c.Call("LIST_SUBSCRIPTIONS", nil)
+	if err != nil {
+		return nil, err
+	}
 
-		c.conn.WriteJSON(unsubscribeMsg)
+	var streams []string
+	if err := json.Unmarshal(result, &streams); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal subscriptions: %w", err)
+	}
+	return streams, nil
+}
+
+// SetProperty sets a property of the connection, e.g. "combined"
+func (c *WebSocketClient) SetProperty(name string, value any) error {
+	_, err := c.Call("SET_PROPERTY", []any{name, value})
+	return err
+}
+
+// GetProperty returns the value of a property of the connection
+func (c *WebSocketClient) GetProperty(name string) (json.RawMessage, error) {
+	return c.Call("GET_PROPERTY", []string{name})
+}
+
+// Call sends a request with method and params, nil when the method takes
+// none, and returns the result of its response
+func (c *WebSocketClient) Call(method string, params any) (json.RawMessage, error) {
+	c.mu.RLock()
+	writer, connected := c.writer, c.connected
+	c.mu.RUnlock()
+
+	if !connected || writer == nil {
+		return nil, ErrNotConnected
+	}
+	return c.call(writer, method, params)
+}
+
+// call queues a request with a new id on writer and waits for its response.
+// Concurrent subscription changes may be sent together as one request.
+func (c *WebSocketClient) call(writer *wsWriter, method string, params any) (json.RawMessage, error) {
+	done := writer.enqueue(c.nextID.Add(1), method, params)
+
+	c.mu.RLock()
+	timeout := c.callTimeout
+	c.mu.RUnlock()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-done:
+		return res.result, res.err
+	case <-timer.C:
+		return nil, fmt.Errorf("%s timed out after %s", method, timeout)
+	case <-c.ctx.Done():
+		return nil, ErrNotConnected
+	}
+}
+
+// resolve passes a response to the request waiting for it
+func (c *WebSocketClient) resolve(writer *wsWriter, resp wsResponse) {
+	var res wsResult
+	switch {
+	case resp.Error != nil:
+		res.err = *resp.Error
+	case resp.Msg != "":
+		res.err = WebSocketError{Code: resp.Code, Msg: resp.Msg}
+	default:
+		res.result = resp.Result
+	}
+
+	if !writer.resolve(*resp.ID, res) {
+		c.Logger().Debug("aster: websocket response without request", "id", *resp.ID)
 	}
 }
 
 // handleMessages handles incoming WebSocket messages
-func (c *WebSocketClient) handleMessages() {
+func (c *WebSocketClient) handleMessages(conn *websocket.Conn, writer *wsWriter) {
+	c.mu.RLock()
+	readTimeout := c.readTimeout
+	c.mu.RUnlock()
+
+	// Any frame from the server, pongs included, proves the connection is
+	// alive and pushes the read deadline back
+	extendDeadline := func() {
+		if readTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(readTimeout))
+		}
+	}
+	extendDeadline()
+	conn.SetPongHandler(func(string) error {
+		extendDeadline()
+		return nil
+	})
+	conn.SetPingHandler(func(data string) error {
+		extendDeadline()
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeWait))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+
+	done := make(chan struct{})
+	go c.watch(conn, done)
+
+	var readErr error
 	defer func() {
+		close(done)
+		if readErr == nil {
+			readErr = ErrNotConnected
+		}
+		writer.close(fmt.Errorf("connection lost: %w", readErr))
+
 		c.mu.Lock()
-		c.connected = false
+		current := c.conn == conn
+		if current {
+			c.connected = false
+		}
 		c.mu.Unlock()
 
-		// Attempt to reconnect if enabled
-		if c.reconnect {
-			time.Sleep(c.reconnectInterval)
-			c.Connect()
+		// Disconnect has already reported a deliberate close, and a
+		// connection replaced by rotation closes quietly
+		if c.ctx.Err() != nil || !current {
+			return
+		}
+		c.setState(StateChange{State: StateDisconnected, Err: readErr})
+		if hook := c.getHooks().onDisconnect; hook != nil {
+			hook(readErr)
 		}
+
+		c.reconnectLoop()
 	}()
 
 	for {
@@ -142,53 +591,280 @@ func (c *WebSocketClient) handleMessages() {
 		case <-c.ctx.Done():
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := conn.ReadMessage()
 			if err != nil {
-				fmt.Printf("WebSocket read error: %v\n", err)
+				c.Logger().Warn("aster: websocket read failed", "url", c.url, "error", err)
+				readErr = err
 				return
 			}
+			extendDeadline()
+
+			// Combined stream messages and responses are objects with known
+			// fields; anything else is a raw payload, which arrays fail to
+			// decode as
+			var wsMsg wsResponse
+			err = json.Unmarshal(message, &wsMsg)
+			switch {
+			case err == nil && wsMsg.Stream != "":
+				c.handleStreamMessage(conn, wsMsg.Stream, wsMsg.Data)
+			case err == nil && wsMsg.ID != nil:
+				c.resolve(writer, wsMsg)
+			default:
+				c.handleRawMessage(conn, message)
+			}
+		}
+	}
+}
+
+// watch pings the server every ping interval and closes conn when a ping
+// can't be sent or a subscribed stream stays quiet for longer than the stale
+// timeout. Closing conn makes handleMessages return and reconnect. It also
+// starts the rotation of conn once due.
+func (c *WebSocketClient) watch(conn *websocket.Conn, done <-chan struct{}) {
+	c.mu.RLock()
+	pingInterval, staleTimeout, rotateAfter := c.pingInterval, c.staleTimeout, c.rotateAfter
+	c.mu.RUnlock()
 
-			var wsMsg WebSocketMessage
-			if err := json.Unmarshal(message, &wsMsg); err != nil {
-				// Try to parse as direct data
-				c.handleRawMessage(message)
-				continue
+	var pingC, staleC, rotateC <-chan time.Time
+	if pingInterval > 0 {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		pingC = ticker.C
+	}
+	if staleTimeout > 0 {
+		ticker := time.NewTicker(staleTimeout / 4)
+		defer ticker.Stop()
+		staleC = ticker.C
+	}
+	if rotateAfter > 0 {
+		timer := time.NewTimer(rotateAfter)
+		defer timer.Stop()
+		rotateC = timer.C
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-pingC:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.reportError("aster: websocket ping failed, reconnecting", fmt.Errorf("ping failed: %w", err), "url", c.url)
+				conn.Close()
+				return
+			}
+		case now := <-staleC:
+			if stream := c.staleStream(now, staleTimeout); stream != "" {
+				err := fmt.Errorf("no message on %s for %s", redactStream(stream), staleTimeout)
+				c.reportError("aster: websocket stream stale, reconnecting", err, "stream", redactStream(stream))
+				conn.Close()
+				return
 			}
+		case <-rotateC:
+			go c.rotate(conn)
+		}
+	}
+}
 
-			c.handleStreamMessage(wsMsg.Stream, wsMsg.Data)
+// staleStream returns a subscribed stream without messages for longer than
+// timeout, if any
+func (c *WebSocketClient) staleStream(now time.Time, timeout time.Duration) string {
+	c.mu.RLock()
+	streams := c.streams()
+	c.mu.RUnlock()
+
+	c.seenMu.Lock()
+	defer c.seenMu.Unlock()
+	for _, stream := range streams {
+		if seen, ok := c.lastSeen[stream]; ok && now.Sub(seen) > timeout {
+			return stream
 		}
 	}
+	return ""
+}
+
+// resetSeen restarts the stale timer of streams
+func (c *WebSocketClient) resetSeen(streams []string) {
+	now := time.Now()
+	c.seenMu.Lock()
+	defer c.seenMu.Unlock()
+	for _, stream := range streams {
+		c.lastSeen[stream] = now
+	}
 }
 
-// handleRawMessage handles raw messages
-func (c *WebSocketClient) handleRawMessage(message []byte) {
-	// Try to parse as WebSocketMessage
-	var wsMsg WebSocketMessage
-	if err := json.Unmarshal(message, &wsMsg); err != nil {
+// handleRawMessage passes a payload received without the name of its stream
+// on conn to the subscribed streams it belongs to, or to the only subscribed
+// stream
+func (c *WebSocketClient) handleRawMessage(conn *websocket.Conn, message []byte) {
+	match, err := rawStreamMatcher(message)
+	if err != nil {
+		c.ReportParseError("", message, err)
 		return
 	}
 
-	// Handle the stream message
-	c.handleStreamMessage(wsMsg.Stream, wsMsg.Data)
+	c.mu.RLock()
+	streams := c.streams()
+	c.mu.RUnlock()
+
+	var targets []string
+	if len(streams) == 1 {
+		targets = streams
+	} else {
+		for _, stream := range streams {
+			if match(stream) {
+				targets = append(targets, stream)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		c.ReportParseError("", message, fmt.Errorf("no subscribed stream matches the message"))
+		return
+	}
+
+	for _, stream := range targets {
+		c.handleStreamMessage(conn, stream, message)
+	}
 }
 
-// handleStreamMessage handles messages for a specific stream
-func (c *WebSocketClient) handleStreamMessage(stream string, data json.RawMessage) {
+// handleStreamMessage handles messages for a specific stream received on conn
+func (c *WebSocketClient) handleStreamMessage(conn *websocket.Conn, stream string, data json.RawMessage) {
+	c.seenMu.Lock()
+	if _, ok := c.lastSeen[stream]; ok {
+		c.lastSeen[stream] = time.Now()
+	}
+	c.seenMu.Unlock()
+
 	c.mu.RLock()
-	handlers := c.handlers[stream]
+	queue := c.queues[stream]
 	c.mu.RUnlock()
 
-	for _, handler := range handlers {
-		go handler(data)
+	if queue != nil {
+		c.deliver(conn, queue, stream, data)
 	}
 }
 
-// SetReconnect sets the reconnect behavior
+// SetReconnect enables or disables reconnecting, with a fixed delay between
+// unlimited attempts. Use SetReconnectPolicy for exponential backoff.
 func (c *WebSocketClient) SetReconnect(reconnect bool, interval time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.reconnect = reconnect
-	c.reconnectInterval = interval
+	c.reconnectPolicy = ReconnectPolicy{InitialBackoff: interval}
+}
+
+// SetCallTimeout sets how long requests such as SUBSCRIBE wait for their
+// response. The default is 10 seconds.
+func (c *WebSocketClient) SetCallTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.callTimeout = timeout
+}
+
+// SetMode sets whether the client connects to the combined stream endpoint,
+// the default, or the raw stream endpoint. Subscriptions made before
+// connecting are part of the connection URL, as far as it allows. The
+// setting applies from the next connection.
+func (c *WebSocketClient) SetMode(mode StreamMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mode = mode
+}
+
+// Mode returns the stream mode of the client
+func (c *WebSocketClient) Mode() StreamMode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mode
+}
+
+// SetMessageRate limits the requests, such as SUBSCRIBE, sent per second on
+// a connection, to stay below the limit of the exchange. Subscription changes
+// waiting for their turn are merged into a single request. Zero removes the
+// limit. The default is 5. The setting applies from the next connection.
+func (c *WebSocketClient) SetMessageRate(perSecond int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messageRate = perSecond
+}
+
+// SetHeartbeat sets how often the client pings the server, and how long it
+// waits for any frame, pongs included, before treating the connection as
+// dead and reconnecting. Zero disables either. The defaults are 30 seconds
+// and 60 seconds. The settings apply from the next connection.
+func (c *WebSocketClient) SetHeartbeat(pingInterval, readTimeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pingInterval = pingInterval
+	c.readTimeout = readTimeout
+}
+
+// SetStaleTimeout makes the client reconnect when a subscribed stream gets
+// no message for longer than timeout. Zero, the default, disables the check.
+// The setting applies from the next connection.
+func (c *WebSocketClient) SetStaleTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.staleTimeout = timeout
+}
+
+// SetDispatch sets the size of the queue holding the pending messages of each
+// stream, and what to do when a queue is full. Handlers of a stream are
+// called one message at a time, in the order the messages arrived, from a
+// goroutine of that stream. The default is a queue of 256 messages with
+// OverflowDropOldest, so that a slow handler never stalls the connection. The
+// settings apply to streams subscribed afterwards.
+func (c *WebSocketClient) SetDispatch(queueSize int, policy OverflowPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queueSize = queueSize
+	c.overflowPolicy = policy
+}
+
+// Dropped returns the number of messages of stream dropped by the overflow
+// policy since it was subscribed
+func (c *WebSocketClient) Dropped(stream string) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if queue := c.queues[stream]; queue != nil {
+		return queue.dropped.Load()
+	}
+	return 0
+}
+
+// DroppedMessages returns the number of dropped messages of every subscribed
+// stream that dropped any
+func (c *WebSocketClient) DroppedMessages() map[string]uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	dropped := make(map[string]uint64)
+	for stream, queue := range c.queues {
+		if n := queue.dropped.Load(); n > 0 {
+			dropped[stream] = n
+		}
+	}
+	return dropped
+}
+
+// Subscriptions returns the streams the client keeps subscribed, sorted
+func (c *WebSocketClient) Subscriptions() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.streams()
+}
+
+// streams returns the tracked streams, sorted. The caller must hold c.mu.
+func (c *WebSocketClient) streams() []string {
+	streams := make([]string, 0, len(c.handlers))
+	for stream := range c.handlers {
+		streams = append(streams, stream)
+	}
+	sort.Strings(streams)
+	return streams
+}
+
+// Done returns a channel closed once Disconnect is called
+func (c *WebSocketClient) Done() <-chan struct{} {
+	return c.ctx.Done()
 }
 
 // IsConnected returns whether the client is connected
@@ -0,0 +1,676 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default maximum number of streams on a pooled connection, the limit the
+// exchange enforces per connection
+const defaultPoolStreams = 200
+
+// Default overlap of the old and new connections of a migrated stream
+const defaultHandoverOverlap = 5 * time.Second
+
+// WebSocketPool spreads streams across as many WebSocket connections as
+// needed to keep at most a given number of streams on each. Subscribe puts a
+// new stream on the least loaded connection with room, opening a new one when
+// all are full. When Unsubscribe leaves a connection empty, it is closed, and
+// when the streams of the least loaded connection fit on the others, they
+// are moved there and that connection is closed too.
+type WebSocketPool struct {
+	newClient    func() *WebSocketClient
+	maxStreams   int
+	overlap      time.Duration
+	onNewClient  func(*WebSocketClient)
+	onParseError func(err ParseError)
+	logger       *slog.Logger
+	strict       bool
+
+	// opMu lets Subscribe and Unsubscribe run concurrently, and stops them
+	// while a migration subscribes streams on their new connections
+	opMu sync.RWMutex
+
+	mu        sync.RWMutex
+	conns     []*poolConn
+	owners    map[string]*poolConn
+	handlers  map[string][]*streamHandler
+	handovers map[string]*handover
+	connected bool
+	migrating bool
+
+	done chan struct{}
+	stop sync.Once
+}
+
+// poolConn is a connection of a pool and the streams assigned to it
+type poolConn struct {
+	client  *WebSocketClient
+	streams map[string]bool
+
+	// connecting is set while the connection opened for a new stream
+	// connects, ready is closed once it is done, with err set on failure
+	connecting bool
+	ready      chan struct{}
+	err        error
+
+	// draining is set while the streams of the connection move away, closed
+	// once it is, or is about to be, disconnected
+	draining bool
+	closed   bool
+}
+
+// handover switches the messages of a stream from its old to its new
+// connection while it moves between them
+type handover struct {
+	mu     sync.Mutex
+	from   *poolConn
+	stream *streamHandoff
+}
+
+// NewWebSocketPool creates a pool whose connections are made by newClient,
+// with at most maxStreams streams each. A maxStreams of 0 or less means 200.
+func NewWebSocketPool(newClient func() *WebSocketClient, maxStreams int) *WebSocketPool {
+	if maxStreams <= 0 {
+		maxStreams = defaultPoolStreams
+	}
+	return &WebSocketPool{
+		newClient:  newClient,
+		maxStreams: maxStreams,
+		overlap:    defaultHandoverOverlap,
+		logger:     loggerOrDiscard(nil),
+		owners:     make(map[string]*poolConn),
+		handlers:   make(map[string][]*streamHandler),
+		handovers:  make(map[string]*handover),
+		done:       make(chan struct{}),
+	}
+}
+
+// SetOnNewClient sets a hook called with every connection the pool creates,
+// before it connects, e.g. to set its hooks, heartbeat or dispatch settings
+func (p *WebSocketPool) SetOnNewClient(hook func(*WebSocketClient)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onNewClient = hook
+}
+
+// SetLogger sets the logger of the pool and of the connections it creates
+// from then on. A nil logger drops the events.
+func (p *WebSocketPool) SetLogger(logger *slog.Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logger = loggerOrDiscard(logger)
+}
+
+// SetOnParseError sets a hook called with the stream messages of any
+// connection of the pool that could not be decoded
+func (p *WebSocketPool) SetOnParseError(hook func(err ParseError)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onParseError = hook
+}
+
+// SetStrictParsing makes the Subscribe* helpers report the events with
+// missing or unknown fields as parse errors, like
+// WebSocketClient.SetStrictParsing
+func (p *WebSocketPool) SetStrictParsing(strict bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.strict = strict
+}
+
+// StrictParsing returns whether strict parsing is enabled
+func (p *WebSocketPool) StrictParsing() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.strict
+}
+
+// Logger returns the logger of the pool
+func (p *WebSocketPool) Logger() *slog.Logger {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.logger
+}
+
+// Connect connects every connection of the pool. Connections opened later
+// for new streams connect as soon as they are created.
+func (p *WebSocketPool) Connect() error {
+	p.mu.Lock()
+	p.connected = true
+	conns := p.liveConns()
+	p.mu.Unlock()
+
+	var errs []error
+	for _, conn := range conns {
+		if conn.ready != nil || conn.client.IsConnected() {
+			continue
+		}
+		if err := conn.client.Connect(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Disconnect closes every connection of the pool
+func (p *WebSocketPool) Disconnect() error {
+	p.stop.Do(func() { close(p.done) })
+
+	p.mu.Lock()
+	p.connected = false
+	conns := p.conns
+	p.mu.Unlock()
+
+	var errs []error
+	for _, conn := range conns {
+		if err := conn.client.Disconnect(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Subscribe subscribes to a stream on the least loaded connection with room,
+// with the same semantics as WebSocketClient.Subscribe. When a new
+// connection has to be opened while the pool is connected, it waits for the
+// connection and returns its error.
+func (p *WebSocketPool) Subscribe(stream string, handler func(json.RawMessage)) error {
+	_, err := p.AddHandler(stream, handler)
+	return err
+}
+
+// AddHandler subscribes handler to a stream like Subscribe, and returns a
+// function removing that handler alone. Once the last handler of the stream
+// is removed, the stream is unsubscribed.
+func (p *WebSocketPool) AddHandler(stream string, handler func(json.RawMessage)) (remove func() error, err error) {
+	h := &streamHandler{fn: handler}
+	remove = func() error { return p.removeHandler(stream, h) }
+
+	p.opMu.RLock()
+	defer p.opMu.RUnlock()
+
+	p.mu.Lock()
+	_, tracked := p.handlers[stream]
+	p.handlers[stream] = append(p.handlers[stream], h)
+	if tracked {
+		conn := p.owners[stream]
+		p.mu.Unlock()
+		return remove, conn.wait()
+	}
+	conn, created := p.assign(stream)
+	connect := created && conn.connecting
+	p.mu.Unlock()
+
+	err = conn.client.Subscribe(stream, p.dispatcher(stream, conn))
+	if errors.As(err, &WebSocketError{}) {
+		p.mu.Lock()
+		p.release(stream)
+		p.mu.Unlock()
+		p.rebalance()
+		return nil, err
+	}
+	if connect {
+		p.open(conn)
+	}
+	if waitErr := conn.wait(); waitErr != nil {
+		return nil, waitErr
+	}
+	return remove, err
+}
+
+// removeHandler removes h from the handlers of stream, and unsubscribes the
+// stream when it was the last one
+func (p *WebSocketPool) removeHandler(stream string, h *streamHandler) error {
+	p.mu.Lock()
+	handlers := p.handlers[stream]
+	i := slices.Index(handlers, h)
+	if i < 0 {
+		// Already removed, or the stream was unsubscribed
+		p.mu.Unlock()
+		return nil
+	}
+	if len(handlers) > 1 {
+		// Dispatchers may be iterating over the current slice
+		p.handlers[stream] = slices.Delete(slices.Clone(handlers), i, i+1)
+		p.mu.Unlock()
+		return nil
+	}
+	p.mu.Unlock()
+	return p.unsubscribe(stream, h)
+}
+
+// Unsubscribe unsubscribes from a stream, removing all of its handlers, and
+// rebalances the connections
+func (p *WebSocketPool) Unsubscribe(stream string) error {
+	return p.unsubscribe(stream, nil)
+}
+
+// unsubscribe unsubscribes from a stream, if only is nil or its only
+// handler, and rebalances the connections
+func (p *WebSocketPool) unsubscribe(stream string, only *streamHandler) error {
+	p.opMu.RLock()
+	p.mu.Lock()
+	conn := p.owners[stream]
+	if conn == nil || (only != nil && !slices.Equal(p.handlers[stream], []*streamHandler{only})) {
+		p.mu.Unlock()
+		p.opMu.RUnlock()
+		return nil
+	}
+	p.release(stream)
+	p.mu.Unlock()
+
+	err := conn.client.Unsubscribe(stream)
+	p.opMu.RUnlock()
+
+	p.rebalance()
+	return err
+}
+
+// ReportParseError reports a stream message that could not be decoded on
+// the connection carrying the stream, which passes it to the parse error
+// hook of the pool
+func (p *WebSocketPool) ReportParseError(stream string, data json.RawMessage, err error) {
+	p.mu.RLock()
+	conn := p.owners[stream]
+	p.mu.RUnlock()
+
+	if conn != nil {
+		conn.client.ReportParseError(stream, data, err)
+		return
+	}
+	p.Logger().Warn("aster: websocket message parse failed",
+		"stream", stream,
+		"size", len(data),
+		"error", err,
+	)
+	p.parseError(ParseError{Stream: stream, Data: data, Err: err})
+}
+
+// parseError passes err to the parse error hook
+func (p *WebSocketPool) parseError(err ParseError) {
+	p.mu.RLock()
+	hook := p.onParseError
+	p.mu.RUnlock()
+	if hook != nil {
+		hook(err)
+	}
+}
+
+// Done returns a channel closed once Disconnect is called
+func (p *WebSocketPool) Done() <-chan struct{} {
+	return p.done
+}
+
+// Subscriptions returns the streams the pool keeps subscribed, sorted
+func (p *WebSocketPool) Subscriptions() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	streams := make([]string, 0, len(p.handlers))
+	for stream := range p.handlers {
+		streams = append(streams, stream)
+	}
+	sort.Strings(streams)
+	return streams
+}
+
+// Clients returns the connections of the pool
+func (p *WebSocketPool) Clients() []*WebSocketClient {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	clients := make([]*WebSocketClient, 0, len(p.conns))
+	for _, conn := range p.liveConns() {
+		clients = append(clients, conn.client)
+	}
+	return clients
+}
+
+// IsConnected returns whether the pool is connected and every one of its
+// connections is up
+func (p *WebSocketPool) IsConnected() bool {
+	p.mu.RLock()
+	connected, conns := p.connected, p.liveConns()
+	p.mu.RUnlock()
+
+	if !connected {
+		return false
+	}
+	for _, conn := range conns {
+		if !conn.client.IsConnected() {
+			return false
+		}
+	}
+	return true
+}
+
+// liveConns returns the connections that are not closed. The caller must
+// hold p.mu.
+func (p *WebSocketPool) liveConns() []*poolConn {
+	conns := make([]*poolConn, 0, len(p.conns))
+	for _, conn := range p.conns {
+		if !conn.closed {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+// assign picks the connection of a new stream, creating one if all are
+// full. The caller must hold p.mu.
+func (p *WebSocketPool) assign(stream string) (conn *poolConn, created bool) {
+	for _, c := range p.conns {
+		if c.draining || c.closed || len(c.streams) >= p.maxStreams {
+			continue
+		}
+		if conn == nil || len(c.streams) < len(conn.streams) {
+			conn = c
+		}
+	}
+	if conn == nil {
+		client := p.newClient()
+		client.SetLogger(p.logger)
+		client.SetOnParseError(p.parseError)
+		if p.onNewClient != nil {
+			p.onNewClient(client)
+		}
+		conn = &poolConn{client: client, streams: make(map[string]bool)}
+		if p.connected {
+			conn.connecting = true
+			conn.ready = make(chan struct{})
+		}
+		p.conns = append(p.conns, conn)
+		created = true
+	}
+	conn.streams[stream] = true
+	p.owners[stream] = conn
+	return conn, created
+}
+
+// release forgets stream. The caller must hold p.mu.
+func (p *WebSocketPool) release(stream string) {
+	if conn := p.owners[stream]; conn != nil {
+		delete(conn.streams, stream)
+	}
+	delete(p.owners, stream)
+	delete(p.handlers, stream)
+	delete(p.handovers, stream)
+}
+
+// open connects a connection created for a new stream. On failure the
+// connection is dropped with its streams.
+func (p *WebSocketPool) open(conn *poolConn) {
+	err := conn.client.Connect()
+
+	p.mu.Lock()
+	conn.connecting = false
+	conn.err = err
+	if err != nil {
+		for stream := range conn.streams {
+			p.release(stream)
+		}
+		conn.closed = true
+		p.removeConn(conn)
+	} else {
+		p.logger.Debug("aster: websocket pool connection added", "connections", len(p.conns))
+	}
+	empty := len(conn.streams) == 0
+	p.mu.Unlock()
+
+	close(conn.ready)
+	if err != nil {
+		conn.client.Disconnect()
+	} else if empty {
+		// Its streams were unsubscribed while it connected
+		p.rebalance()
+	}
+}
+
+// wait waits for a connection created for a new stream to connect
+func (c *poolConn) wait() error {
+	if c.ready == nil {
+		return nil
+	}
+	<-c.ready
+	return c.err
+}
+
+// removeConn drops conn from the pool. The caller must hold p.mu.
+func (p *WebSocketPool) removeConn(conn *poolConn) {
+	for i, c := range p.conns {
+		if c == conn {
+			p.conns = append(p.conns[:i], p.conns[i+1:]...)
+			return
+		}
+	}
+}
+
+// dispatcher returns the handler of stream on conn, which passes its
+// messages to the handlers of the stream
+func (p *WebSocketPool) dispatcher(stream string, conn *poolConn) func(json.RawMessage) {
+	return func(data json.RawMessage) {
+		p.mu.RLock()
+		handlers := p.handlers[stream]
+		h := p.handovers[stream]
+		owned := p.owners[stream] == conn
+		closed := conn.closed
+		p.mu.RUnlock()
+
+		if closed {
+			return
+		}
+		if h == nil {
+			// A stream moved away may still arrive on its old connection
+			if owned {
+				dispatch(handlers, data)
+			}
+			return
+		}
+
+		// While the stream moves, both connections deliver it: pass the
+		// messages of one at a time
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		var messages []json.RawMessage
+		if conn == h.from {
+			messages = h.stream.fromOld(data)
+		} else {
+			messages = h.stream.fromNew(data)
+		}
+		for _, message := range messages {
+			dispatch(handlers, message)
+		}
+	}
+}
+
+// dispatch passes a message to handlers
+func dispatch(handlers []*streamHandler, data json.RawMessage) {
+	for _, handler := range handlers {
+		handler.fn(data)
+	}
+}
+
+// migration is the move of a stream to another connection
+type migration struct {
+	stream   string
+	to       *poolConn
+	handover *handover
+}
+
+// rebalance closes the empty connections, then moves the streams of the
+// least loaded connection to the others if they have room for them
+func (p *WebSocketPool) rebalance() {
+	p.mu.Lock()
+	var idle []*poolConn
+	for _, conn := range p.liveConns() {
+		if len(conn.streams) == 0 && !conn.connecting && !conn.draining {
+			conn.closed = true
+			p.removeConn(conn)
+			idle = append(idle, conn)
+		}
+	}
+	victim, moves := p.plan()
+	p.mu.Unlock()
+
+	for _, conn := range idle {
+		conn.client.Disconnect()
+		p.Logger().Debug("aster: websocket pool connection closed", "reason", "empty")
+	}
+	if victim != nil {
+		go p.migrate(victim, moves)
+	}
+}
+
+// plan picks the least loaded connection and spreads its streams over the
+// others, if they have room for all of them. The caller must hold p.mu.
+func (p *WebSocketPool) plan() (*poolConn, []migration) {
+	if p.migrating {
+		return nil, nil
+	}
+
+	var candidates []*poolConn
+	for _, conn := range p.conns {
+		if !conn.connecting && !conn.draining && !conn.closed {
+			candidates = append(candidates, conn)
+		}
+	}
+	if len(candidates) < 2 {
+		return nil, nil
+	}
+
+	var victim *poolConn
+	for _, conn := range candidates {
+		if victim == nil || len(conn.streams) < len(victim.streams) {
+			victim = conn
+		}
+	}
+	room := 0
+	for _, conn := range candidates {
+		if conn != victim {
+			room += p.maxStreams - len(conn.streams)
+		}
+	}
+	if len(victim.streams) > room {
+		return nil, nil
+	}
+
+	streams := make([]string, 0, len(victim.streams))
+	for stream := range victim.streams {
+		streams = append(streams, stream)
+	}
+	sort.Strings(streams)
+
+	moves := make([]migration, 0, len(streams))
+	for _, stream := range streams {
+		var to *poolConn
+		for _, conn := range candidates {
+			if conn == victim || len(conn.streams) >= p.maxStreams {
+				continue
+			}
+			if to == nil || len(conn.streams) < len(to.streams) {
+				to = conn
+			}
+		}
+		h := &handover{from: victim, stream: newStreamHandoff()}
+		delete(victim.streams, stream)
+		to.streams[stream] = true
+		p.owners[stream] = to
+		p.handovers[stream] = h
+		moves = append(moves, migration{stream: stream, to: to, handover: h})
+	}
+	victim.draining = true
+	p.migrating = true
+	return victim, moves
+}
+
+// migrate subscribes the streams of victim on their new connections, and
+// closes victim once both have run side by side for the overlap
+func (p *WebSocketPool) migrate(victim *poolConn, moves []migration) {
+	var failed []migration
+	p.opMu.Lock()
+	for _, m := range moves {
+		p.mu.RLock()
+		current := p.handovers[m.stream] == m.handover
+		p.mu.RUnlock()
+		if !current {
+			// Unsubscribed meanwhile
+			continue
+		}
+		if err := m.to.client.Subscribe(m.stream, p.dispatcher(m.stream, m.to)); err != nil {
+			p.Logger().Warn("aster: websocket pool migration failed", "stream", redactStream(m.stream), "error", err)
+			failed = append(failed, m)
+		}
+	}
+	p.opMu.Unlock()
+
+	timer := time.NewTimer(p.overlap)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-p.done:
+	}
+
+	// The streams whose new connection hasn't caught up yet switch to it
+	// now, with the messages it received that the old one didn't deliver
+	for _, m := range moves {
+		if slices.Contains(failed, m) {
+			continue
+		}
+		m.handover.mu.Lock()
+		p.mu.RLock()
+		current := p.handovers[m.stream] == m.handover
+		handlers := p.handlers[m.stream]
+		p.mu.RUnlock()
+		if current {
+			for _, data := range m.handover.stream.finish() {
+				dispatch(handlers, data)
+			}
+		}
+		m.handover.mu.Unlock()
+	}
+
+	p.mu.Lock()
+	var restored []migration
+	for _, m := range failed {
+		if p.handovers[m.stream] != m.handover {
+			continue
+		}
+		// Keep the stream on the connection that still carries it
+		delete(m.to.streams, m.stream)
+		victim.streams[m.stream] = true
+		p.owners[m.stream] = victim
+		restored = append(restored, m)
+	}
+	for _, m := range moves {
+		if p.handovers[m.stream] == m.handover {
+			delete(p.handovers, m.stream)
+		}
+	}
+	victim.draining = false
+	p.migrating = false
+	closing := len(victim.streams) == 0
+	if closing {
+		victim.closed = true
+		p.removeConn(victim)
+	}
+	p.mu.Unlock()
+
+	for _, m := range restored {
+		m.to.client.Unsubscribe(m.stream)
+	}
+	if !closing {
+		for _, m := range moves {
+			if !slices.Contains(restored, m) {
+				victim.client.Unsubscribe(m.stream)
+			}
+		}
+	}
+	if closing {
+		victim.client.Disconnect()
+		p.Logger().Debug("aster: websocket pool connection closed", "reason", "rebalanced", "streams", len(moves))
+	}
+}
@@ -0,0 +1,158 @@
+package common
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForClients waits for the pool to have n connections
+func waitForClients(t *testing.T, pool *WebSocketPool, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(pool.Clients()) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d connections, got %d", n, len(pool.Clients()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebSocketPoolSpreadsStreams(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	pool := NewWebSocketPool(func() *WebSocketClient {
+		return NewWebSocketClient(server.URL())
+	}, 2)
+	defer pool.Disconnect()
+
+	received := make(chan string, 16)
+	for _, stream := range []string{"a@trade", "b@trade", "c@trade", "d@trade", "e@trade"} {
+		pool.Subscribe(stream, func(data json.RawMessage) {
+			received <- stream
+		})
+	}
+	if n := len(pool.Clients()); n != 3 {
+		t.Fatalf("Expected 3 connections, got %d", n)
+	}
+
+	if err := pool.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	var conns []*fakeConn
+	for _, want := range []string{"a@trade,b@trade", "c@trade,d@trade", "e@trade"} {
+		conn := server.nextConn(t)
+		if conn.streams() != want {
+			t.Fatalf("Expected streams %s, got %s", want, conn.streams())
+		}
+		conns = append(conns, conn)
+	}
+	if !pool.IsConnected() {
+		t.Error("Expected the pool to be connected")
+	}
+
+	server.send(conns[1].Conn, map[string]any{"stream": "d@trade", "data": map[string]any{"t": 1}})
+	select {
+	case stream := <-received:
+		if stream != "d@trade" {
+			t.Errorf("Expected a message on d@trade, got %s", stream)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a message")
+	}
+
+	// A new stream goes on the connection with room, then on a new one
+	if err := pool.Subscribe("f@trade", func(json.RawMessage) {}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if msg := server.nextMessage(t); strings.Join(messageStreams(msg), ",") != "f@trade" {
+		t.Fatalf("Unexpected subscribe message: %v", msg)
+	}
+	if err := pool.Subscribe("g@trade", func(json.RawMessage) {}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if conn := server.nextConn(t); conn.streams() != "g@trade" {
+		t.Fatalf("Expected a new connection for g@trade, got %s", conn.URL)
+	}
+	if n := len(pool.Clients()); n != 4 {
+		t.Errorf("Expected 4 connections, got %d", n)
+	}
+}
+
+func TestWebSocketPoolRebalances(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	pool := NewWebSocketPool(func() *WebSocketClient {
+		return NewWebSocketClient(server.URL())
+	}, 2)
+	pool.overlap = 200 * time.Millisecond
+	defer pool.Disconnect()
+
+	received := make(chan int64, 16)
+	for _, stream := range []string{"a@trade", "b@trade", "c@trade", "d@trade"} {
+		pool.Subscribe(stream, func(data json.RawMessage) {
+			if stream != "b@trade" {
+				return
+			}
+			var trade struct {
+				ID int64 `json:"t"`
+			}
+			json.Unmarshal(data, &trade)
+			received <- trade.ID
+		})
+	}
+	if err := pool.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	old, conn := server.nextConn(t), server.nextConn(t)
+
+	// Unsubscribing a and d leaves one stream on each connection, so b
+	// moves next to c
+	pool.Unsubscribe("a@trade")
+	if msg := server.nextMessage(t); msg["method"] != "UNSUBSCRIBE" {
+		t.Fatalf("Unexpected message: %v", msg)
+	}
+	pool.Unsubscribe("d@trade")
+	if msg := server.nextMessage(t); msg["method"] != "UNSUBSCRIBE" {
+		t.Fatalf("Unexpected message: %v", msg)
+	}
+	if msg := server.nextMessage(t); msg["method"] != "SUBSCRIBE" || strings.Join(messageStreams(msg), ",") != "b@trade" {
+		t.Fatalf("Unexpected message: %v", msg)
+	}
+
+	// While both connections carry b, each trade is delivered once and in
+	// order, though the new connection gets ahead of the old one
+	send := func(conn *fakeConn, ids ...int64) {
+		for _, id := range ids {
+			server.send(conn.Conn, map[string]any{
+				"stream": "b@trade",
+				"data":   map[string]any{"e": "trade", "E": 1000 + id, "s": "B", "t": id},
+			})
+		}
+	}
+	send(conn, 3, 4)
+	time.Sleep(50 * time.Millisecond)
+	send(old, 1, 2, 3, 4)
+	send(conn, 5)
+	for want := int64(1); want <= 5; want++ {
+		select {
+		case id := <-received:
+			if id != want {
+				t.Fatalf("Expected trade %d, got %d", want, id)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for trade %d", want)
+		}
+	}
+
+	waitForClients(t, pool, 1)
+	if got := strings.Join(pool.Subscriptions(), ","); got != "b@trade,c@trade" {
+		t.Errorf("Unexpected subscriptions: %s", got)
+	}
+
+	// Emptying the last connection closes it
+	pool.Unsubscribe("b@trade")
+	pool.Unsubscribe("c@trade")
+	waitForClients(t, pool, 0)
+}
@@ -0,0 +1,302 @@
+package common
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Default overlap of the old and new connections during a rotation
+const defaultRotateOverlap = 10 * time.Second
+
+// SetRotation makes the client replace its connection every interval, ahead
+// of the exchange closing connections after 24 hours. A new connection with
+// the same subscriptions is opened first and both run side by side for
+// overlap, so that handlers see no gap, and only then is the old one closed.
+// Each stream is delivered from the old connection until the new one catches
+// up with it, and from the new one after that. An interval of 0, the
+// default, disables rotation; an overlap of 0 means 10 seconds. The setting
+// applies from the next connection.
+func (c *WebSocketClient) SetRotation(interval, overlap time.Duration) {
+	if overlap <= 0 {
+		overlap = defaultRotateOverlap
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rotateAfter = interval
+	c.rotateOverlap = overlap
+}
+
+// rotate replaces conn, if it is still the current connection, with a new
+// one carrying the same streams, and closes conn after the overlap
+func (c *WebSocketClient) rotate(conn *websocket.Conn) {
+	// conn may be the new connection of a rotation still in progress
+	c.handoffMu.Lock()
+	previous := c.handoff
+	c.handoffMu.Unlock()
+	if previous != nil {
+		select {
+		case <-previous.done:
+		case <-c.ctx.Done():
+			return
+		}
+	}
+
+	c.mu.RLock()
+	current := c.conn == conn && c.ctx.Err() == nil
+	logger, baseURL, mode, streams := c.logger, c.url, c.mode, c.streams()
+	overlap := c.rotateOverlap
+	c.mu.RUnlock()
+	if !current {
+		return
+	}
+
+	// Dialing may take a while, and must not hold up subscriptions or
+	// Disconnect
+	newConn, rest, err := dialStreams(logger, baseURL, mode, streams)
+	if err != nil {
+		// The old connection stays until the exchange closes it, which
+		// triggers a regular reconnect
+		c.reportError("aster: websocket rotation failed", err, "url", baseURL)
+		return
+	}
+
+	c.mu.Lock()
+	if c.conn != conn || c.ctx.Err() != nil {
+		c.mu.Unlock()
+		newConn.Close()
+		return
+	}
+	// Streams may have been subscribed or unsubscribed during the dial
+	tracked := c.streams()
+	for _, stream := range tracked {
+		if !slices.Contains(streams, stream) {
+			rest = append(rest, stream)
+		}
+	}
+	var removed []string
+	for _, stream := range streams {
+		if !slices.Contains(tracked, stream) {
+			removed = append(removed, stream)
+		}
+	}
+	h := newRotationHandoff(conn, tracked)
+	c.handoffMu.Lock()
+	c.handoff = h
+	c.handoffMu.Unlock()
+	writer := c.start(newConn)
+	c.mu.Unlock()
+	defer close(h.done)
+
+	c.subscribeRest(writer, rest)
+	if len(removed) > 0 {
+		go func() {
+			for _, stream := range removed {
+				c.unsubscribe(writer, stream)
+			}
+		}()
+	}
+
+	timer := time.NewTimer(overlap)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.ctx.Done():
+		conn.Close()
+		return
+	}
+
+	// The streams the new connection hasn't caught up with yet switch to it
+	// now, with the messages it received that the old one didn't deliver
+	c.mu.RLock()
+	queues := maps.Clone(c.queues)
+	c.mu.RUnlock()
+	c.handoffMu.Lock()
+	for stream, sh := range h.streams {
+		if queue := queues[stream]; queue != nil {
+			for _, data := range sh.finish() {
+				queue.push(data, c.ctx.Done())
+			}
+		}
+	}
+	c.handoffMu.Unlock()
+	conn.Close()
+	c.Logger().Info("aster: websocket connection rotated", "url", baseURL)
+
+	// The new connection may still lag behind what the old one delivered
+	timer.Reset(overlap)
+	select {
+	case <-timer.C:
+	case <-c.ctx.Done():
+	}
+	c.handoffMu.Lock()
+	c.handoff = nil
+	c.handoffMu.Unlock()
+}
+
+// deliver queues a stream message received on conn, unless the stream is
+// being handed over to another connection and the message comes from the
+// wrong one
+func (c *WebSocketClient) deliver(conn *websocket.Conn, queue *streamQueue, stream string, data json.RawMessage) {
+	c.handoffMu.Lock()
+	var sh *streamHandoff
+	if h := c.handoff; h != nil {
+		sh = h.streams[stream]
+	}
+	if sh == nil {
+		c.handoffMu.Unlock()
+		queue.push(data, c.ctx.Done())
+		return
+	}
+
+	// While connections overlap, handing over and queueing happen together
+	// so that the two readers can't reorder messages
+	defer c.handoffMu.Unlock()
+	var messages []json.RawMessage
+	if conn == c.handoff.old {
+		messages = sh.fromOld(data)
+	} else {
+		messages = sh.fromNew(data)
+	}
+	for _, message := range messages {
+		queue.push(message, c.ctx.Done())
+	}
+}
+
+// rotationHandoff hands the streams carried by the old connection of a
+// rotation over to the new one
+type rotationHandoff struct {
+	old     *websocket.Conn
+	streams map[string]*streamHandoff
+	done    chan struct{} // closed once the rotation is over
+}
+
+func newRotationHandoff(old *websocket.Conn, streams []string) *rotationHandoff {
+	h := &rotationHandoff{
+		old:     old,
+		streams: make(map[string]*streamHandoff, len(streams)),
+		done:    make(chan struct{}),
+	}
+	for _, stream := range streams {
+		h.streams[stream] = newStreamHandoff()
+	}
+	return h
+}
+
+// streamHandoff switches the source of a stream from an old connection to a
+// new one carrying it too. The new connection starts at the current position
+// of the stream while the old one may have older messages in flight, so
+// messages come from the old connection until both have carried the same
+// event, and from the new one after that; the old connection's are then
+// dropped. Payloads without an event identity, such as arrays, can't be
+// matched, and switch the stream to the new connection right away.
+type streamHandoff struct {
+	switched  bool
+	delivered map[string]bool  // identities of the old connection's messages
+	pending   []handoffMessage // new connection's messages before the switch
+}
+
+type handoffMessage struct {
+	id   string
+	data json.RawMessage
+}
+
+func newStreamHandoff() *streamHandoff {
+	return &streamHandoff{delivered: make(map[string]bool)}
+}
+
+// fromOld returns the messages to deliver upon a message of the old
+// connection
+func (h *streamHandoff) fromOld(data json.RawMessage) []json.RawMessage {
+	if h.switched {
+		return nil
+	}
+	id, ok := eventIdentity(data)
+	if !ok {
+		return []json.RawMessage{data}
+	}
+	h.delivered[id] = true
+	for i, m := range h.pending {
+		if m.id == id {
+			// The old connection caught up with the new one, which goes on
+			// from there
+			messages := append([]json.RawMessage{data}, h.undelivered(h.pending[i+1:])...)
+			h.switched = true
+			h.pending = nil
+			return messages
+		}
+	}
+	return []json.RawMessage{data}
+}
+
+// fromNew returns the messages to deliver upon a message of the new
+// connection
+func (h *streamHandoff) fromNew(data json.RawMessage) []json.RawMessage {
+	id, ok := eventIdentity(data)
+	switch {
+	case h.switched:
+		if ok && h.delivered[id] {
+			return nil
+		}
+		return []json.RawMessage{data}
+	case !ok:
+		h.switched = true
+		h.pending = nil
+		return []json.RawMessage{data}
+	case h.delivered[id]:
+		// The new connection caught up with the old one
+		h.switched = true
+		h.pending = nil
+		return nil
+	}
+	h.pending = append(h.pending, handoffMessage{id: id, data: data})
+	return nil
+}
+
+// finish switches to the new connection, once the old one is done, and
+// returns the messages it received that the old one didn't deliver
+func (h *streamHandoff) finish() []json.RawMessage {
+	messages := h.undelivered(h.pending)
+	h.switched = true
+	h.pending = nil
+	return messages
+}
+
+// undelivered returns the messages not delivered from the old connection
+func (h *streamHandoff) undelivered(pending []handoffMessage) []json.RawMessage {
+	var messages []json.RawMessage
+	for _, m := range pending {
+		if !h.delivered[m.id] {
+			messages = append(messages, m.data)
+		}
+	}
+	return messages
+}
+
+// eventIdentity identifies a stream payload by its event time and event id:
+// the update id of depth and book ticker events, the trade id of trades or
+// the aggregate trade id. It reports false for payloads without either, such
+// as arrays.
+func eventIdentity(data json.RawMessage) (string, bool) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return "", false
+	}
+	var eventTime int64
+	json.Unmarshal(fields["E"], &eventTime)
+	id := ""
+	for _, key := range []string{"u", "lastUpdateId", "t", "a"} {
+		if raw, ok := fields[key]; ok {
+			id = key + ":" + string(raw)
+			break
+		}
+	}
+	if eventTime == 0 && id == "" {
+		return "", false
+	}
+	return strconv.FormatInt(eventTime, 10) + "|" + id, true
+}
@@ -0,0 +1,168 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStreamHandoff(t *testing.T) {
+	trade := func(id int) json.RawMessage {
+		return json.RawMessage(fmt.Sprintf(`{"e":"trade","E":%d,"t":%d}`, 1000+id, id))
+	}
+	ids := func(messages []json.RawMessage) []int64 {
+		var ids []int64
+		for _, data := range messages {
+			var trade struct {
+				ID int64 `json:"t"`
+			}
+			json.Unmarshal(data, &trade)
+			ids = append(ids, trade.ID)
+		}
+		return ids
+	}
+
+	// The new connection is ahead: its messages wait for the old one to
+	// catch up, and go on from there
+	h := newStreamHandoff()
+	for _, step := range []struct {
+		old  bool
+		id   int
+		want []int64
+	}{
+		{true, 1, []int64{1}},
+		{false, 4, nil},
+		{false, 5, nil},
+		{true, 2, []int64{2}},
+		{true, 3, []int64{3}},
+		{true, 4, []int64{4, 5}},
+		{true, 5, nil},
+		{false, 6, []int64{6}},
+	} {
+		var got []json.RawMessage
+		if step.old {
+			got = h.fromOld(trade(step.id))
+		} else {
+			got = h.fromNew(trade(step.id))
+		}
+		if !slices.Equal(ids(got), step.want) {
+			t.Fatalf("Trade %d from old=%v delivered %v, expected %v", step.id, step.old, ids(got), step.want)
+		}
+	}
+
+	// The new connection is behind: it takes over once it carries a trade
+	// delivered before, skipping those delivered already
+	h = newStreamHandoff()
+	h.fromOld(trade(1))
+	h.fromOld(trade(2))
+	if got := h.fromNew(trade(1)); got != nil {
+		t.Errorf("Expected trade 1 to be dropped, got %v", ids(got))
+	}
+	if got := h.fromOld(trade(3)); got != nil {
+		t.Errorf("Expected the old connection to be dropped after the switch, got %v", ids(got))
+	}
+	if got := ids(h.fromNew(trade(2))); got != nil {
+		t.Errorf("Expected trade 2 to be dropped, got %v", got)
+	}
+	if got := ids(h.fromNew(trade(3))); !slices.Equal(got, []int64{3}) {
+		t.Errorf("Expected trade 3, got %v", got)
+	}
+
+	// Unmatched messages of the new connection are delivered when the old
+	// one is done
+	h = newStreamHandoff()
+	h.fromOld(trade(1))
+	h.fromNew(trade(2))
+	if got := ids(h.finish()); !slices.Equal(got, []int64{2}) {
+		t.Errorf("Expected trade 2 on finish, got %v", got)
+	}
+
+	// Arrays can't be matched, and identical snapshots are not duplicates
+	h = newStreamHandoff()
+	snapshot := json.RawMessage(`[{"e":"24hrTicker","E":1}]`)
+	if got := h.fromOld(snapshot); len(got) != 1 {
+		t.Errorf("Expected the snapshot of the old connection, got %d messages", len(got))
+	}
+	if got := h.fromNew(snapshot); len(got) != 1 {
+		t.Errorf("Expected the snapshot of the new connection, got %d messages", len(got))
+	}
+	if got := h.fromOld(snapshot); got != nil {
+		t.Errorf("Expected the old connection to be dropped after the switch, got %d messages", len(got))
+	}
+}
+
+func TestWebSocketRotation(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetRotation(150*time.Millisecond, 300*time.Millisecond)
+	defer client.Disconnect()
+
+	disconnected := make(chan error, 1)
+	client.SetOnDisconnect(func(err error) { disconnected <- err })
+
+	received := make(chan int64, 16)
+	client.Subscribe("btcusdt@trade", func(data json.RawMessage) {
+		var trade struct {
+			ID int64 `json:"t"`
+		}
+		json.Unmarshal(data, &trade)
+		received <- trade.ID
+	})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	send := func(conn *fakeConn, ids ...int64) {
+		for _, id := range ids {
+			server.send(conn.Conn, map[string]any{
+				"stream": "btcusdt@trade",
+				"data":   map[string]any{"e": "trade", "E": 1000 + id, "s": "BTCUSDT", "t": id},
+			})
+		}
+	}
+
+	old := server.nextConn(t)
+	send(old, 1, 2)
+
+	// The new connection carries the same streams and overlaps the old one:
+	// both get the trades made from then on
+	conn := server.nextConn(t)
+	if conn.streams() != "btcusdt@trade" {
+		t.Fatalf("Unexpected stream URL of the new connection: %s", conn.URL)
+	}
+	// The new connection gets ahead of trades the old one has yet to
+	// deliver, which come first
+	send(conn, 5, 6)
+	time.Sleep(50 * time.Millisecond)
+	go send(old, 3, 4, 5, 6, 7)
+	send(conn, 7, 8)
+
+	for want := int64(1); want <= 8; want++ {
+		select {
+		case id := <-received:
+			if id != want {
+				t.Fatalf("Expected trade %d, got %d", want, id)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for trade %d", want)
+		}
+	}
+	select {
+	case id := <-received:
+		t.Fatalf("Unexpected duplicate trade %d", id)
+	case <-time.After(400 * time.Millisecond):
+	}
+
+	// Closing the old connection is not a disconnection
+	select {
+	case err := <-disconnected:
+		t.Errorf("Unexpected disconnect: %v", err)
+	default:
+	}
+	if !client.IsConnected() {
+		t.Error("Expected the client to stay connected")
+	}
+}
@@ -0,0 +1,158 @@
+package common
+
+// ConnState is the state of the connection of a WebSocket client
+type ConnState int
+
+const (
+	// StateDisconnected means there is no connection
+	StateDisconnected ConnState = iota
+	// StateConnecting means Connect is dialing the server
+	StateConnecting
+	// StateConnected means the connection is up
+	StateConnected
+	// StateReconnecting means the connection was lost and is being restored
+	StateReconnecting
+)
+
+// String returns the name of the state
+func (s ConnState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChange is sent on the channels returned by StateChanges
+type StateChange struct {
+	State ConnState
+	// Err is the cause of a disconnection or of a failed connection, nil
+	// when Disconnect was called
+	Err error
+	// Attempt counts the reconnect attempts, from 1, while reconnecting
+	Attempt int
+}
+
+// stateBuffer is the capacity of the channels returned by StateChanges
+const stateBuffer = 16
+
+// wsHooks are the lifecycle hooks of a WebSocket client
+type wsHooks struct {
+	onConnect         func()
+	onDisconnect      func(err error)
+	onReconnect       func(attempt int)
+	onError           func(err error)
+	onResubscribe     func(streams, failed []string)
+	onReconnectFailed func(err error)
+	onParseError      func(err ParseError)
+}
+
+// SetOnConnect sets a hook called every time a connection is established,
+// reconnections included
+func (c *WebSocketClient) SetOnConnect(hook func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.onConnect = hook
+}
+
+// SetOnDisconnect sets a hook called when the connection is lost, with the
+// cause, or closed by Disconnect, with a nil error
+func (c *WebSocketClient) SetOnDisconnect(hook func(err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.onDisconnect = hook
+}
+
+// SetOnReconnect sets a hook called once a lost connection is restored and
+// its streams subscribed again, with the number of attempts it took
+func (c *WebSocketClient) SetOnReconnect(hook func(attempt int)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.onReconnect = hook
+}
+
+// SetOnError sets a hook called with errors that no call returns, such as a
+// failed reconnect attempt, a failed resubscription or a stale stream
+func (c *WebSocketClient) SetOnError(hook func(err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.onError = hook
+}
+
+// SetOnResubscribe sets a hook called after a reconnect once the server has
+// acknowledged the tracked subscriptions, e.g. to resync order books from a
+// REST snapshot. It receives the streams subscribed again, and those that
+// failed, which stay tracked and are retried on the next reconnect.
+func (c *WebSocketClient) SetOnResubscribe(hook func(streams, failed []string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.onResubscribe = hook
+}
+
+// SetOnParseError sets a hook called with the stream messages that could not
+// be decoded, along with their stream and raw payload
+func (c *WebSocketClient) SetOnParseError(hook func(err ParseError)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.onParseError = hook
+}
+
+// State returns the state of the connection
+func (c *WebSocketClient) State() ConnState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// StateChanges returns a channel receiving every change of the connection
+// state. Changes are dropped, rather than holding up the client, while the
+// channel is full.
+func (c *WebSocketClient) StateChanges() <-chan StateChange {
+	ch := make(chan StateChange, stateBuffer)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stateChans = append(c.stateChans, ch)
+	return ch
+}
+
+// setState records a state change and notifies the listeners. The caller
+// must not hold c.mu.
+func (c *WebSocketClient) setState(change StateChange) {
+	c.mu.Lock()
+	if c.state == change.State && change.State != StateReconnecting {
+		c.mu.Unlock()
+		return
+	}
+	c.state = change.State
+	chans := c.stateChans
+	c.mu.Unlock()
+
+	for _, ch := range chans {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+// getHooks returns the hooks of the client
+func (c *WebSocketClient) getHooks() wsHooks {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hooks
+}
+
+// reportError logs err and passes it to the error hook
+func (c *WebSocketClient) reportError(msg string, err error, args ...any) {
+	c.Logger().Warn(msg, append(args, "error", err)...)
+	if hook := c.getHooks().onError; hook != nil {
+		hook(err)
+	}
+}
@@ -0,0 +1,165 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// StreamMode selects the endpoint a WebSocket client connects to, and so the
+// format of the stream messages it receives
+type StreamMode int
+
+const (
+	// CombinedMode connects to /stream?streams=..., where every message is
+	// wrapped with the name of its stream: {"stream":"...","data":{...}}
+	CombinedMode StreamMode = iota
+	// RawMode connects to /ws, or /ws/<stream> for a single stream, where
+	// messages are the bare event payloads. The stream of a payload is
+	// inferred from its fields, which is only reliable for streams whose
+	// events carry a symbol; prefer CombinedMode for several partial depth
+	// streams on one connection.
+	RawMode
+)
+
+// String returns the name of the mode
+func (m StreamMode) String() string {
+	switch m {
+	case CombinedMode:
+		return "combined"
+	case RawMode:
+		return "raw"
+	default:
+		return "unknown"
+	}
+}
+
+// maxURLStreams caps the streams encoded in a combined stream URL, to keep
+// it short. Further streams are subscribed once connected.
+const maxURLStreams = 100
+
+// endpoint returns the URL of a connection in the given mode to baseURL,
+// with as many of streams as fit in it, and the streams left to subscribe
+// once connected
+func endpoint(baseURL string, mode StreamMode, streams []string) (string, []string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid WebSocket URL: %w", err)
+	}
+	base := strings.TrimSuffix(u.Path, "/")
+
+	switch mode {
+	case RawMode:
+		if len(streams) == 1 {
+			u.Path = base + "/ws/" + streams[0]
+			return u.String(), nil, nil
+		}
+		u.Path = base + "/ws"
+		return u.String(), streams, nil
+	case CombinedMode:
+		u.Path = base + "/stream"
+		n := min(len(streams), maxURLStreams)
+		if n > 0 {
+			// Stream names are valid query characters and sent as is
+			u.RawQuery = "streams=" + strings.Join(streams[:n], "/")
+		}
+		return u.String(), streams[n:], nil
+	default:
+		return "", nil, fmt.Errorf("unknown stream mode %d", mode)
+	}
+}
+
+// eventStreams maps event types to the name of their stream
+var eventStreams = map[string]string{
+	"24hrTicker":      "ticker",
+	"24hrMiniTicker":  "miniTicker",
+	"bookTicker":      "bookTicker",
+	"trade":           "trade",
+	"aggTrade":        "aggTrade",
+	"kline":           "kline",
+	"depthUpdate":     "depth",
+	"markPriceUpdate": "markPrice",
+	"forceOrder":      "forceOrder",
+}
+
+// rawStreamMatcher returns a function reporting whether a raw payload,
+// received without the name of its stream, belongs to stream
+func rawStreamMatcher(data json.RawMessage) (func(stream string) bool, error) {
+	// Keys are matched exactly: "e" and "E", or "s" and "S", are different
+	// fields
+	var event map[string]json.RawMessage
+	array := false
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		var events []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &events); err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			return func(string) bool { return false }, nil
+		}
+		event, array = events[0], true
+	} else if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+
+	field := func(fields map[string]json.RawMessage, key string) string {
+		var value string
+		json.Unmarshal(fields[key], &value)
+		return value
+	}
+
+	eventType := field(event, "e")
+	symbol := strings.ToLower(field(event, "s"))
+	name := eventStreams[eventType]
+	switch {
+	case eventType == "kline":
+		var kline map[string]json.RawMessage
+		json.Unmarshal(event["k"], &kline)
+		name += "_" + field(kline, "i")
+	case eventType == "forceOrder":
+		var order map[string]json.RawMessage
+		json.Unmarshal(event["o"], &order)
+		symbol = strings.ToLower(field(order, "s"))
+	case eventType == "" && event["lastUpdateId"] != nil:
+		// Partial depth snapshots carry neither event type nor symbol
+		return isPartialDepth, nil
+	case eventType == "" && event["u"] != nil && event["b"] != nil:
+		// Spot book tickers carry no event type
+		name = "bookTicker"
+	}
+	if name == "" {
+		return func(string) bool { return false }, nil
+	}
+
+	if array {
+		return func(stream string) bool {
+			return hasStreamPrefix(stream, "!"+name+"@arr")
+		}, nil
+	}
+	return func(stream string) bool {
+		if hasStreamPrefix(stream, symbol+"@"+name) {
+			return true
+		}
+		// All market streams push single events, except the @arr ones
+		// which push arrays. Liquidations come one by one on
+		// !forceOrder@arr.
+		if !hasStreamPrefix(stream, "!"+name) {
+			return false
+		}
+		return !strings.Contains(stream, "@arr") || name == "forceOrder"
+	}, nil
+}
+
+// hasStreamPrefix reports whether stream is name, possibly followed by
+// options such as @100ms
+func hasStreamPrefix(stream, name string) bool {
+	return stream == name || strings.HasPrefix(stream, name+"@")
+}
+
+// isPartialDepth reports whether stream is a partial depth stream such as
+// btcusdt@depth5 or btcusdt@depth20@100ms
+func isPartialDepth(stream string) bool {
+	_, rest, ok := strings.Cut(stream, "@depth")
+	return ok && rest != "" && rest[0] >= '0' && rest[0] <= '9'
+}
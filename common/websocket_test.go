@@ -0,0 +1,786 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeStreamServer is a local WebSocket server recording the control
+// messages it receives and answering them
+type fakeStreamServer struct {
+	*httptest.Server
+	conns    chan *fakeConn
+	messages chan map[string]any
+	// mute stops new connections from reading, so pings are never answered
+	mute atomic.Bool
+	// refuse is the number of upcoming connections to refuse
+	refuse atomic.Int32
+	// reject lists streams whose subscription is refused
+	reject []string
+
+	writeMu sync.Mutex
+}
+
+func newFakeStreamServer(t *testing.T) *fakeStreamServer {
+	s := &fakeStreamServer{
+		conns:    make(chan *fakeConn, 16),
+		messages: make(chan map[string]any, 64),
+	}
+
+	quit := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for n := s.refuse.Load(); n > 0; n = s.refuse.Load() {
+			if s.refuse.CompareAndSwap(n, n-1) {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.conns <- &fakeConn{Conn: conn, URL: r.URL}
+		if s.mute.Load() {
+			<-quit
+			return
+		}
+		// Streams come in the URL of combined and single raw streams
+		var streams []string
+		if query := r.URL.Query().Get("streams"); query != "" {
+			streams = strings.Split(query, "/")
+		} else if stream, ok := strings.CutPrefix(r.URL.Path, "/ws/"); ok {
+			streams = []string{stream}
+		}
+		properties := map[string]any{"combined": true}
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg map[string]any
+			if json.Unmarshal(data, &msg) != nil {
+				continue
+			}
+			s.messages <- msg
+
+			params, _ := msg["params"].([]any)
+			var result any
+			switch msg["method"] {
+			case "SUBSCRIBE":
+				rejected := slices.ContainsFunc(messageStreams(msg), func(stream string) bool {
+					return slices.Contains(s.reject, stream)
+				})
+				if rejected {
+					s.send(conn, map[string]any{
+						"error": map[string]any{"code": 2, "msg": "Invalid request: unknown stream"},
+						"id":    msg["id"],
+					})
+					continue
+				}
+				streams = append(streams, messageStreams(msg)...)
+			case "UNSUBSCRIBE":
+				streams = slices.DeleteFunc(streams, func(stream string) bool {
+					return slices.Contains(messageStreams(msg), stream)
+				})
+			case "LIST_SUBSCRIPTIONS":
+				result = streams
+			case "SET_PROPERTY":
+				properties[params[0].(string)] = params[1]
+			case "GET_PROPERTY":
+				result = properties[params[0].(string)]
+			}
+			s.send(conn, map[string]any{"result": result, "id": msg["id"]})
+		}
+	}))
+	t.Cleanup(func() {
+		close(quit)
+		s.Close()
+	})
+	return s
+}
+
+// send writes v to conn, which may be used by the server handler at the
+// same time
+func (s *fakeStreamServer) send(conn *websocket.Conn, v any) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return conn.WriteJSON(v)
+}
+
+// URL returns the ws:// URL of the server
+func (s *fakeStreamServer) URL() string {
+	return "ws" + strings.TrimPrefix(s.Server.URL, "http")
+}
+
+// fakeConn is a connection accepted by fakeStreamServer
+type fakeConn struct {
+	*websocket.Conn
+	URL *url.URL
+}
+
+// streams returns the streams requested in the URL of the connection
+func (c *fakeConn) streams() string {
+	if stream, ok := strings.CutPrefix(c.URL.Path, "/ws/"); ok {
+		return stream
+	}
+	return strings.ReplaceAll(c.URL.Query().Get("streams"), "/", ",")
+}
+
+func (s *fakeStreamServer) nextConn(t *testing.T) *fakeConn {
+	t.Helper()
+	select {
+	case conn := <-s.conns:
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a connection")
+		return nil
+	}
+}
+
+func (s *fakeStreamServer) nextMessage(t *testing.T) map[string]any {
+	t.Helper()
+	select {
+	case msg := <-s.messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a message")
+		return nil
+	}
+}
+
+func messageStreams(msg map[string]any) []string {
+	var streams []string
+	params, _ := msg["params"].([]any)
+	for _, p := range params {
+		if stream, ok := p.(string); ok {
+			streams = append(streams, stream)
+		}
+	}
+	return streams
+}
+
+func TestWebSocketResubscribesOnReconnect(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(true, 10*time.Millisecond)
+	defer client.Disconnect()
+
+	resubscribed := make(chan []string, 1)
+	client.SetOnResubscribe(func(streams, failed []string) {
+		resubscribed <- streams
+	})
+
+	// Subscriptions made before connecting are part of the stream URL
+	client.Subscribe("btcusdt@trade", func(json.RawMessage) {})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	conn := server.nextConn(t)
+	if conn.URL.Path != "/stream" || conn.streams() != "btcusdt@trade" {
+		t.Fatalf("Unexpected stream URL: %s", conn.URL)
+	}
+
+	client.Subscribe("ethusdt@depth", func(json.RawMessage) {})
+	if msg := server.nextMessage(t); msg["method"] != "SUBSCRIBE" || strings.Join(messageStreams(msg), ",") != "ethusdt@depth" {
+		t.Fatalf("Unexpected subscribe message: %v", msg)
+	}
+
+	// Drop the connection: every tracked stream is subscribed again
+	conn.Close()
+	if conn := server.nextConn(t); conn.streams() != "btcusdt@trade,ethusdt@depth" {
+		t.Fatalf("Unexpected stream URL after reconnect: %s", conn.URL)
+	}
+
+	select {
+	case streams := <-resubscribed:
+		if strings.Join(streams, ",") != "btcusdt@trade,ethusdt@depth" {
+			t.Errorf("Unexpected streams passed to hook: %v", streams)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the resubscribe hook to be called")
+	}
+
+	if got := client.Subscriptions(); strings.Join(got, ",") != "btcusdt@trade,ethusdt@depth" {
+		t.Errorf("Unexpected subscriptions: %v", got)
+	}
+}
+
+func TestWebSocketReconnectHooksWaitForResubscription(t *testing.T) {
+	server := newFakeStreamServer(t)
+	server.reject = []string{"bad@stream"}
+
+	client := NewWebSocketClient(server.URL())
+	client.SetMode(RawMode)
+	client.SetReconnect(true, 10*time.Millisecond)
+	defer client.Disconnect()
+
+	type resubscription struct{ streams, failed []string }
+	reconnected := make(chan int, 1)
+	resubscribed := make(chan resubscription, 1)
+	client.SetOnReconnect(func(attempt int) { reconnected <- len(server.messages) })
+	client.SetOnResubscribe(func(streams, failed []string) {
+		resubscribed <- resubscription{streams, failed}
+	})
+
+	// Several raw streams don't fit in the URL and are subscribed after
+	// connecting; a rejected one stays tracked
+	client.Subscribe("btcusdt@trade", func(json.RawMessage) {})
+	client.Subscribe("bad@stream", func(json.RawMessage) {})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	conn := server.nextConn(t)
+	server.nextMessage(t)
+
+	conn.Close()
+	server.nextConn(t)
+
+	// The hooks run once the server has answered the SUBSCRIBE messages
+	select {
+	case n := <-reconnected:
+		if n == 0 {
+			t.Error("Expected the reconnect hook to run after resubscribing")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the reconnect hook to be called")
+	}
+	select {
+	case r := <-resubscribed:
+		if strings.Join(r.streams, ",") != "btcusdt@trade" || strings.Join(r.failed, ",") != "bad@stream" {
+			t.Errorf("Unexpected streams passed to hook: %v, failed %v", r.streams, r.failed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the resubscribe hook to be called")
+	}
+}
+
+func TestWebSocketReconnectsWhenPongsStop(t *testing.T) {
+	server := newFakeStreamServer(t)
+	server.mute.Store(true)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(true, 10*time.Millisecond)
+	client.SetHeartbeat(20*time.Millisecond, 100*time.Millisecond)
+	defer client.Disconnect()
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	server.nextConn(t)
+
+	// No pong arrives before the read deadline: the client reconnects
+	server.nextConn(t)
+}
+
+func TestWebSocketHeartbeatKeepsConnection(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(true, 10*time.Millisecond)
+	client.SetHeartbeat(20*time.Millisecond, 100*time.Millisecond)
+	defer client.Disconnect()
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	server.nextConn(t)
+
+	// The server answers pings, so the connection outlives the read timeout
+	select {
+	case <-server.conns:
+		t.Fatal("Expected no reconnect while pongs arrive")
+	case <-time.After(400 * time.Millisecond):
+	}
+	if !client.IsConnected() {
+		t.Error("Expected the client to stay connected")
+	}
+}
+
+func TestWebSocketReconnectsOnStaleStream(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(true, 10*time.Millisecond)
+	client.SetHeartbeat(20*time.Millisecond, time.Second)
+	client.SetStaleTimeout(100 * time.Millisecond)
+	defer client.Disconnect()
+
+	client.Subscribe("btcusdt@trade", func(json.RawMessage) {})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	server.nextConn(t)
+
+	// Pongs keep arriving but the stream stays silent: the client reconnects
+	// and subscribes again
+	if conn := server.nextConn(t); conn.streams() != "btcusdt@trade" {
+		t.Fatalf("Unexpected stream URL after reconnect: %s", conn.URL)
+	}
+}
+
+func TestWebSocketDeliversStreamInOrder(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(false, 0)
+	defer client.Disconnect()
+
+	const count = 200
+	received := make(chan int, count)
+	client.Subscribe("btcusdt@depth", func(data json.RawMessage) {
+		var n int
+		json.Unmarshal(data, &n)
+		received <- n
+	})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	conn := server.nextConn(t)
+
+	for i := range count {
+		server.send(conn.Conn, map[string]any{"stream": "btcusdt@depth", "data": i})
+	}
+	for i := range count {
+		select {
+		case n := <-received:
+			if n != i {
+				t.Fatalf("Expected message %d, got %d", i, n)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for message %d", i)
+		}
+	}
+	if n := client.Dropped("btcusdt@depth"); n != 0 {
+		t.Errorf("Expected nothing dropped, got %d", n)
+	}
+}
+
+func TestWebSocketUnsubscribeFromHandler(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(false, 0)
+	client.SetCallTimeout(2 * time.Second)
+	defer client.Disconnect()
+
+	flooded := make(chan struct{})
+	unsubscribed := make(chan error, 1)
+	var once sync.Once
+	client.Subscribe("ethusdt@depth", func(json.RawMessage) {})
+	client.Subscribe("btcusdt@depth", func(json.RawMessage) {
+		once.Do(func() {
+			<-flooded
+			unsubscribed <- client.Unsubscribe("ethusdt@depth")
+		})
+	})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	conn := server.nextConn(t)
+
+	// More messages than the queue holds arrive ahead of the response to
+	// UNSUBSCRIBE, while the handler waits for it
+	for i := range 2 * defaultQueueSize {
+		server.send(conn.Conn, map[string]any{"stream": "btcusdt@depth", "data": i})
+	}
+	close(flooded)
+
+	select {
+	case err := <-unsubscribed:
+		if err != nil {
+			t.Errorf("Expected Unsubscribe to succeed from a handler, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Unsubscribe to return")
+	}
+	if got := client.Subscriptions(); strings.Join(got, ",") != "btcusdt@depth" {
+		t.Errorf("Unexpected subscriptions: %v", got)
+	}
+}
+
+func TestWebSocketSubscribeAcknowledged(t *testing.T) {
+	server := newFakeStreamServer(t)
+	server.reject = []string{"bad@stream"}
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(false, 0)
+	defer client.Disconnect()
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	if err := client.Subscribe("btcusdt@trade", func(json.RawMessage) {}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	first := server.nextMessage(t)
+
+	// A rejected stream returns the server error and is not tracked
+	err := client.Subscribe("bad@stream", func(json.RawMessage) {})
+	var wsErr WebSocketError
+	if !errors.As(err, &wsErr) || wsErr.Code != 2 || wsErr.Method != "SUBSCRIBE" {
+		t.Fatalf("Expected a SUBSCRIBE WebSocketError, got %v", err)
+	}
+	second := server.nextMessage(t)
+	if first["id"] == second["id"] {
+		t.Errorf("Expected unique request ids, got %v twice", first["id"])
+	}
+	if got := client.Subscriptions(); strings.Join(got, ",") != "btcusdt@trade" {
+		t.Errorf("Unexpected subscriptions: %v", got)
+	}
+
+	streams, err := client.ListSubscriptions()
+	if err != nil {
+		t.Fatalf("ListSubscriptions failed: %v", err)
+	}
+	if strings.Join(streams, ",") != "btcusdt@trade" {
+		t.Errorf("Unexpected server subscriptions: %v", streams)
+	}
+
+	if err := client.Unsubscribe("btcusdt@trade"); err != nil {
+		t.Fatalf("Unsubscribe failed: %v", err)
+	}
+	if streams, _ := client.ListSubscriptions(); len(streams) != 0 {
+		t.Errorf("Expected no server subscriptions, got %v", streams)
+	}
+}
+
+func TestWebSocketProperties(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(false, 0)
+	defer client.Disconnect()
+
+	if _, err := client.GetProperty("combined"); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Expected ErrNotConnected, got %v", err)
+	}
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := client.SetProperty("combined", false); err != nil {
+		t.Fatalf("SetProperty failed: %v", err)
+	}
+	value, err := client.GetProperty("combined")
+	if err != nil {
+		t.Fatalf("GetProperty failed: %v", err)
+	}
+	if string(value) != "false" {
+		t.Errorf("Expected false, got %s", value)
+	}
+}
+
+func TestWebSocketBatchesSubscriptions(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(false, 0)
+	defer client.Disconnect()
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	const count = 50
+	var wg sync.WaitGroup
+	for i := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stream := fmt.Sprintf("sym%d@trade", i)
+			if err := client.Subscribe(stream, func(json.RawMessage) {}); err != nil {
+				t.Errorf("Subscribe %s failed: %v", stream, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Subscriptions waiting for the rate limit are merged
+	messages, streams := 0, 0
+	for streams < count {
+		msg := server.nextMessage(t)
+		messages++
+		streams += len(messageStreams(msg))
+	}
+	if streams != count || messages > 5 {
+		t.Errorf("Expected %d streams in a few messages, got %d in %d", count, streams, messages)
+	}
+}
+
+func TestWebSocketBatchRejection(t *testing.T) {
+	server := newFakeStreamServer(t)
+	server.reject = []string{"bad@stream"}
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(false, 0)
+	defer client.Disconnect()
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	// The rejection of a merged request fails only the rejected stream
+	const count = 10
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	for i := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stream := fmt.Sprintf("sym%d@trade", i)
+			if i == count/2 {
+				stream = "bad@stream"
+			}
+			errs[i] = client.Subscribe(stream, func(json.RawMessage) {})
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if i == count/2 {
+			if !errors.As(err, &WebSocketError{}) {
+				t.Errorf("Expected a WebSocketError for the rejected stream, got %v", err)
+			}
+		} else if err != nil {
+			t.Errorf("Subscribe sym%d@trade failed: %v", i, err)
+		}
+	}
+	if got := client.Subscriptions(); len(got) != count-1 || slices.Contains(got, "bad@stream") {
+		t.Errorf("Unexpected subscriptions: %v", got)
+	}
+	streams, err := client.ListSubscriptions()
+	if err != nil {
+		t.Fatalf("ListSubscriptions failed: %v", err)
+	}
+	if len(streams) != count-1 {
+		t.Errorf("Expected %d server subscriptions, got %v", count-1, streams)
+	}
+}
+
+func TestWebSocketThrottlesRequests(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(false, 0)
+	client.SetMessageRate(10)
+	defer client.Disconnect()
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	start := time.Now()
+	for range 4 {
+		if _, err := client.ListSubscriptions(); err != nil {
+			t.Fatalf("ListSubscriptions failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("Expected 4 requests at 10 per second to take at least 300ms, took %s", elapsed)
+	}
+}
+
+func TestWebSocketRawMode(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(true, 10*time.Millisecond)
+	client.SetMode(RawMode)
+	defer client.Disconnect()
+
+	received := make(chan string, 16)
+	for _, stream := range []string{"btcusdt@trade", "btcusdt@kline_1m", "ethusdt@trade", "!ticker@arr"} {
+		client.Subscribe(stream, func(data json.RawMessage) {
+			received <- stream + " " + string(data)
+		})
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	// Several streams don't fit a raw stream URL and are subscribed instead
+	conn := server.nextConn(t)
+	if conn.URL.Path != "/ws" {
+		t.Fatalf("Unexpected raw stream URL: %s", conn.URL)
+	}
+	if msg := server.nextMessage(t); len(messageStreams(msg)) != 4 {
+		t.Fatalf("Unexpected subscribe message: %v", msg)
+	}
+
+	// Bare payloads are routed by their event type and symbol
+	payloads := []string{
+		`{"e":"trade","E":1,"s":"ETHUSDT","t":1}`,
+		`{"e":"kline","E":1,"s":"BTCUSDT","k":{"i":"1m"}}`,
+		`[{"e":"24hrTicker","E":1,"s":"BTCUSDT"}]`,
+		`{"e":"trade","E":1,"s":"BTCUSDT","t":2}`,
+	}
+	want := []string{"ethusdt@trade", "btcusdt@kline_1m", "!ticker@arr", "btcusdt@trade"}
+	for _, payload := range payloads {
+		server.send(conn.Conn, json.RawMessage(payload))
+	}
+	got := map[string]string{}
+	for range payloads {
+		select {
+		case msg := <-received:
+			stream, data, _ := strings.Cut(msg, " ")
+			got[stream] = strings.TrimSpace(data)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for a message")
+		}
+	}
+	for i, stream := range want {
+		if got[stream] != payloads[i] {
+			t.Errorf("Expected %s to receive %s, got %q", stream, payloads[i], got[stream])
+		}
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		mode    StreamMode
+		streams []string
+		want    string
+		rest    int
+	}{
+		{CombinedMode, nil, "wss://fstream.asterdex.com/stream", 0},
+		{CombinedMode, []string{"btcusdt@trade", "!ticker@arr"}, "wss://fstream.asterdex.com/stream?streams=btcusdt@trade/!ticker@arr", 0},
+		{RawMode, nil, "wss://fstream.asterdex.com/ws", 0},
+		{RawMode, []string{"btcusdt@trade"}, "wss://fstream.asterdex.com/ws/btcusdt@trade", 0},
+		{RawMode, []string{"btcusdt@trade", "ethusdt@trade"}, "wss://fstream.asterdex.com/ws", 2},
+	}
+
+	for _, tt := range tests {
+		got, rest, err := endpoint("wss://fstream.asterdex.com", tt.mode, tt.streams)
+		if err != nil {
+			t.Fatalf("endpoint failed: %v", err)
+		}
+		if got != tt.want || len(rest) != tt.rest {
+			t.Errorf("%s %v: expected %s with %d left, got %s with %d", tt.mode, tt.streams, tt.want, tt.rest, got, len(rest))
+		}
+	}
+
+	// Long stream lists are split between the URL and a subscription
+	streams := make([]string, maxURLStreams+5)
+	for i := range streams {
+		streams[i] = fmt.Sprintf("sym%d@trade", i)
+	}
+	if _, rest, _ := endpoint("wss://fstream.asterdex.com", CombinedMode, streams); len(rest) != 5 {
+		t.Errorf("Expected 5 streams left, got %d", len(rest))
+	}
+}
+
+func TestRawStreamMatcher(t *testing.T) {
+	tests := []struct {
+		payload string
+		stream  string
+		want    bool
+	}{
+		{`{"e":"depthUpdate","E":1,"s":"BTCUSDT"}`, "btcusdt@depth@100ms", true},
+		{`{"e":"depthUpdate","E":1,"s":"BTCUSDT"}`, "btcusdt@depth5", false},
+		{`{"lastUpdateId":1,"bids":[],"asks":[]}`, "btcusdt@depth5", true},
+		{`{"lastUpdateId":1,"bids":[],"asks":[]}`, "btcusdt@depth", false},
+		{`{"u":1,"s":"BTCUSDT","b":"1","B":"1","a":"2","A":"1"}`, "btcusdt@bookTicker", true},
+		{`{"e":"bookTicker","E":1,"s":"BTCUSDT"}`, "!bookTicker", true},
+		{`{"e":"24hrTicker","E":1,"s":"BTCUSDT"}`, "!ticker@arr", false},
+		{`{"e":"forceOrder","E":1,"o":{"s":"BTCUSDT","S":"SELL"}}`, "btcusdt@forceOrder", true},
+		{`{"e":"forceOrder","E":1,"o":{"s":"BTCUSDT","S":"SELL"}}`, "!forceOrder@arr", true},
+		{`[{"e":"markPriceUpdate","E":1,"s":"BTCUSDT"}]`, "!markPrice@arr@1s", true},
+		{`{"e":"markPriceUpdate","E":1,"s":"BTCUSDT"}`, "ethusdt@markPrice", false},
+	}
+
+	for _, tt := range tests {
+		match, err := rawStreamMatcher(json.RawMessage(tt.payload))
+		if err != nil {
+			t.Fatalf("rawStreamMatcher(%s) failed: %v", tt.payload, err)
+		}
+		if got := match(tt.stream); got != tt.want {
+			t.Errorf("%s on %s: expected %v, got %v", tt.payload, tt.stream, tt.want, got)
+		}
+	}
+}
+
+func TestWebSocketLifecycleHooks(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	client := NewWebSocketClient(server.URL())
+	client.SetReconnect(true, 10*time.Millisecond)
+	states := client.StateChanges()
+
+	events := make(chan string, 16)
+	client.SetOnConnect(func() { events <- "connect" })
+	client.SetOnDisconnect(func(err error) { events <- fmt.Sprintf("disconnect %v", err != nil) })
+	client.SetOnReconnect(func(attempt int) { events <- fmt.Sprintf("reconnect %d", attempt) })
+
+	expect := func(want ...string) {
+		t.Helper()
+		for _, w := range want {
+			select {
+			case got := <-events:
+				if got != w {
+					t.Fatalf("Expected %q, got %q", w, got)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Timed out waiting for %q", w)
+			}
+		}
+	}
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	expect("connect")
+
+	// A lost connection is reported with its cause, then restored
+	server.nextConn(t).Close()
+	expect("disconnect true", "connect", "reconnect 1")
+	if client.State() != StateConnected {
+		t.Errorf("Expected connected state, got %s", client.State())
+	}
+
+	// Disconnect is reported without error
+	client.Disconnect()
+	expect("disconnect false")
+
+	var got []string
+	for len(states) > 0 {
+		change := <-states
+		got = append(got, change.State.String())
+	}
+	want := "connecting,connected,disconnected,reconnecting,connected,disconnected"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Expected states %s, got %s", want, strings.Join(got, ","))
+	}
+}
+
+func TestWebSocketConnectFailureState(t *testing.T) {
+	server := newFakeStreamServer(t)
+	server.Close()
+
+	client := NewWebSocketClient(server.URL())
+	states := client.StateChanges()
+
+	if err := client.Connect(); err == nil {
+		t.Fatal("Expected Connect to fail")
+	}
+	if change := <-states; change.State != StateConnecting {
+		t.Errorf("Expected connecting, got %s", change.State)
+	}
+	if change := <-states; change.State != StateDisconnected || change.Err == nil {
+		t.Errorf("Expected disconnected with an error, got %s (%v)", change.State, change.Err)
+	}
+}
@@ -0,0 +1,254 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Default number of requests sent per second on a connection
+const defaultMessageRate = 5
+
+// wsRequest is a request waiting to be sent or answered. SUBSCRIBE and
+// UNSUBSCRIBE requests queued back to back are merged into one, answered
+// with a single response. When the server rejects a merged request, its
+// requests are sent again one by one, so that only those at fault fail.
+type wsRequest struct {
+	id      int64
+	method  string
+	params  any
+	waiters []wsWaiter
+}
+
+// wsWaiter is the caller of a request, waiting for its response
+type wsWaiter struct {
+	ch     chan wsResult
+	params any // params of the caller, before merging
+}
+
+// wsResult is what a waiter of a request receives
+type wsResult struct {
+	result json.RawMessage
+	err    error
+}
+
+// wsWriter is the only goroutine writing data messages to a connection. It
+// sends the queued requests in order, at most rate per second, and matches
+// the responses read from the connection to them. Ping and pong frames are
+// written with WriteControl, which gorilla/websocket allows concurrently.
+type wsWriter struct {
+	conn     *websocket.Conn
+	interval time.Duration // minimum gap between requests, 0 if unlimited
+	ids      *atomic.Int64 // last request id, shared with the client
+
+	mu      sync.Mutex
+	queue   []*wsRequest
+	pending map[int64]*wsRequest // sent, waiting for their response
+	err     error                // set once the connection is lost
+
+	wake chan struct{}
+	done chan struct{}
+	stop sync.Once
+}
+
+func newWSWriter(conn *websocket.Conn, rate int, ids *atomic.Int64) *wsWriter {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Second / time.Duration(rate)
+	}
+	return &wsWriter{
+		conn:     conn,
+		interval: interval,
+		ids:      ids,
+		pending:  make(map[int64]*wsRequest),
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// enqueue queues a request with the given id, or merges it into the last
+// queued request when both change subscriptions the same way. The returned
+// channel receives the response.
+func (w *wsWriter) enqueue(id int64, method string, params any) <-chan wsResult {
+	waiter := wsWaiter{ch: make(chan wsResult, 1), params: params}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		waiter.ch <- wsResult{err: w.err}
+		return waiter.ch
+	}
+
+	if streams, ok := params.([]string); ok && (method == "SUBSCRIBE" || method == "UNSUBSCRIBE") {
+		if n := len(w.queue); n > 0 && w.queue[n-1].method == method {
+			last := w.queue[n-1]
+			merged := last.params.([]string)
+			for _, stream := range streams {
+				if !slices.Contains(merged, stream) {
+					merged = append(merged, stream)
+				}
+			}
+			last.params = merged
+			last.waiters = append(last.waiters, waiter)
+			return waiter.ch
+		}
+		// Copy, as later requests may be merged into it
+		params = slices.Clone(streams)
+	}
+
+	w.queue = append(w.queue, &wsRequest{
+		id:      id,
+		method:  method,
+		params:  params,
+		waiters: []wsWaiter{waiter},
+	})
+	signal(w.wake)
+	return waiter.ch
+}
+
+// run sends queued requests until the writer is closed
+func (w *wsWriter) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.wake:
+		}
+
+		for {
+			req := w.next()
+			if req == nil {
+				break
+			}
+
+			if err := w.send(req); err != nil {
+				// Closing the connection makes the reader fail and close
+				// the writer
+				w.conn.Close()
+				return
+			}
+
+			if w.interval > 0 {
+				select {
+				case <-w.done:
+					return
+				case <-time.After(w.interval):
+				}
+			}
+		}
+	}
+}
+
+// next pops the first queued request and marks it pending
+func (w *wsWriter) next() *wsRequest {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.queue) == 0 || w.err != nil {
+		return nil
+	}
+	req := w.queue[0]
+	w.queue[0] = nil
+	w.queue = w.queue[1:]
+	w.pending[req.id] = req
+	return req
+}
+
+// send writes req to the connection
+func (w *wsWriter) send(req *wsRequest) error {
+	msg := map[string]any{
+		"method": req.method,
+		"id":     req.id,
+	}
+	if req.params != nil {
+		msg["params"] = req.params
+	}
+
+	w.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := w.conn.WriteJSON(msg); err != nil {
+		err = fmt.Errorf("failed to send %s: %w", req.method, err)
+		w.mu.Lock()
+		delete(w.pending, req.id)
+		w.mu.Unlock()
+		req.reply(wsResult{err: err})
+		return err
+	}
+	return nil
+}
+
+// resolve passes the response with id to the waiters of its request. It
+// returns false when no request is waiting for it.
+func (w *wsWriter) resolve(id int64, res wsResult) bool {
+	w.mu.Lock()
+	req := w.pending[id]
+	delete(w.pending, id)
+	w.mu.Unlock()
+
+	if req == nil {
+		return false
+	}
+	if wsErr, ok := res.err.(WebSocketError); ok {
+		wsErr.Method = req.method
+		res.err = wsErr
+		if len(req.waiters) > 1 && w.split(req) {
+			return true
+		}
+	}
+	req.reply(res)
+	return true
+}
+
+// split queues the requests merged into req again, first in line and one
+// by one. It returns false when the writer is closed.
+func (w *wsWriter) split(req *wsRequest) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return false
+	}
+
+	requests := make([]*wsRequest, 0, len(req.waiters))
+	for _, waiter := range req.waiters {
+		requests = append(requests, &wsRequest{
+			id:      w.ids.Add(1),
+			method:  req.method,
+			params:  waiter.params,
+			waiters: []wsWaiter{waiter},
+		})
+	}
+	w.queue = append(requests, w.queue...)
+	signal(w.wake)
+	return true
+}
+
+// close stops the writer and fails every queued and pending request with err
+func (w *wsWriter) close(err error) {
+	w.stop.Do(func() {
+		close(w.done)
+
+		w.mu.Lock()
+		w.err = err
+		requests := w.queue
+		for _, req := range w.pending {
+			requests = append(requests, req)
+		}
+		w.queue = nil
+		clear(w.pending)
+		w.mu.Unlock()
+
+		for _, req := range requests {
+			req.reply(wsResult{err: err})
+		}
+	})
+}
+
+// reply sends res to every waiter of the request
+func (r *wsRequest) reply(res wsResult) {
+	for _, waiter := range r.waiters {
+		waiter.ch <- res
+	}
+}
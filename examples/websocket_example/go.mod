@@ -5,8 +5,11 @@ go 1.24.2
 require github.com/yiplee/aster-go v0.0.0
 
 require (
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.1 // indirect
 	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/shopspring/decimal v1.4.0 // indirect
+	golang.org/x/crypto v0.40.0 // indirect
+	golang.org/x/sys v0.34.0 // indirect
 )
 
 replace github.com/yiplee/aster-go => ../../
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/yiplee/aster-go/common"
 	"github.com/yiplee/aster-go/futures"
 	"github.com/yiplee/aster-go/spot"
 )
@@ -21,6 +23,22 @@ func main() {
 	testFuturesWebSocket()
 }
 
+// consume returns a function passing the events of a subscription to a
+// handler, or logs why the subscription failed
+func consume[T any](sub *common.Subscription[T], err error) func(handler func(T)) {
+	return func(handler func(T)) {
+		if err != nil {
+			log.Printf("Failed to subscribe: %v", err)
+			return
+		}
+		go func() {
+			for event := range sub.C() {
+				handler(event)
+			}
+		}()
+	}
+}
+
 func testSpotWebSocket() {
 	fmt.Println("--- Spot WebSocket Example ---")
 
@@ -36,28 +54,30 @@ func testSpotWebSocket() {
 
 	fmt.Println("✓ Connected to spot WebSocket")
 
+	ctx := context.Background()
+
 	// Subscribe to BTCUSDT ticker
-	wsClient.SubscribeTicker("BTCUSDT", func(ticker *spot.Ticker24hr) {
+	consume(wsClient.SubscribeTicker(ctx, "BTCUSDT"))(func(ticker *spot.Ticker24hr) {
 		fmt.Printf("BTCUSDT Ticker: Price=%s, Change=%s%%\n",
 			ticker.LastPrice.String(), ticker.PriceChangePercent.String())
 	})
 
 	// Subscribe to BTCUSDT mini ticker
-	wsClient.SubscribeMiniTicker("BTCUSDT", func(ticker *spot.MiniTicker) {
+	consume(wsClient.SubscribeMiniTicker(ctx, "BTCUSDT"))(func(ticker *spot.MiniTicker) {
 		fmt.Printf("BTCUSDT Mini Ticker: Open=%s, High=%s, Low=%s, Close=%s, Volume=%s\n",
 			ticker.Open.String(), ticker.High.String(), ticker.Low.String(),
 			ticker.Close.String(), ticker.Volume.String())
 	})
 
 	// Subscribe to BTCUSDT book ticker
-	wsClient.SubscribeBookTicker("BTCUSDT", func(bookTicker *spot.BookTicker) {
+	consume(wsClient.SubscribeBookTicker(ctx, "BTCUSDT"))(func(bookTicker *spot.BookTicker) {
 		fmt.Printf("BTCUSDT Book Ticker: Bid=%s@%s, Ask=%s@%s\n",
 			bookTicker.BidQty.String(), bookTicker.BidPrice.String(),
 			bookTicker.AskQty.String(), bookTicker.AskPrice.String())
 	})
 
 	// Subscribe to BTCUSDT trades
-	wsClient.SubscribeTrade("BTCUSDT", func(trade *spot.Trade) {
+	consume(wsClient.SubscribeTrade(ctx, "BTCUSDT"))(func(trade *spot.Trade) {
 		side := "SELL"
 		if trade.IsBuyerMaker {
 			side = "BUY"
@@ -67,7 +87,7 @@ func testSpotWebSocket() {
 	})
 
 	// Subscribe to BTCUSDT aggregated trades
-	wsClient.SubscribeAggTrade("BTCUSDT", func(aggTrade *spot.AggTrade) {
+	consume(wsClient.SubscribeAggTrade(ctx, "BTCUSDT"))(func(aggTrade *spot.AggTrade) {
 		side := "SELL"
 		if aggTrade.M {
 			side = "BUY"
@@ -77,20 +97,20 @@ func testSpotWebSocket() {
 	})
 
 	// Subscribe to BTCUSDT klines (1 minute)
-	wsClient.SubscribeKline("BTCUSDT", spot.Interval1m, func(kline *spot.Kline) {
+	consume(wsClient.SubscribeKline(ctx, "BTCUSDT", spot.Interval1m))(func(kline *spot.Kline) {
 		fmt.Printf("BTCUSDT Kline: O=%s H=%s L=%s C=%s V=%s\n",
 			kline.Open.String(), kline.High.String(), kline.Low.String(),
 			kline.Close.String(), kline.Volume.String())
 	})
 
 	// Subscribe to BTCUSDT depth
-	wsClient.SubscribeDepth("BTCUSDT", 5, func(depth *spot.OrderBook) {
+	consume(wsClient.SubscribeDepth(ctx, "BTCUSDT", 5))(func(depth *spot.OrderBook) {
 		fmt.Printf("BTCUSDT Depth: LastUpdateID=%d, Bids=%d, Asks=%d\n",
 			depth.LastUpdateID, len(depth.Bids), len(depth.Asks))
 	})
 
 	// Subscribe to all tickers
-	wsClient.SubscribeAllTickers(func(tickers []spot.Ticker24hr) {
+	consume(wsClient.SubscribeAllTickers(ctx))(func(tickers []spot.Ticker24hr) {
 		fmt.Printf("All Tickers: Received %d tickers\n", len(tickers))
 		if len(tickers) > 0 {
 			fmt.Printf("  First ticker: %s = %s\n", tickers[0].Symbol, tickers[0].LastPrice.String())
@@ -126,27 +146,29 @@ func testFuturesWebSocket() {
 
 	fmt.Println("✓ Connected to futures WebSocket")
 
+	ctx := context.Background()
+
 	// Subscribe to BTCUSDT ticker
-	wsClient.SubscribeTicker("BTCUSDT", func(ticker *futures.Ticker24hr) {
+	consume(wsClient.SubscribeTicker(ctx, "BTCUSDT"))(func(ticker *futures.Ticker24hr) {
 		fmt.Printf("BTCUSDT Futures Ticker: Price=%s, Change=%s%%\n",
 			ticker.LastPrice.String(), ticker.PriceChangePercent.String())
 	})
 
 	// Subscribe to BTCUSDT mini ticker
-	wsClient.SubscribeMiniTicker("BTCUSDT", func(ticker *futures.MiniTicker) {
+	consume(wsClient.SubscribeMiniTicker(ctx, "BTCUSDT"))(func(ticker *futures.MiniTicker) {
 		fmt.Printf("BTCUSDT Futures Mini Ticker: Open=%s, High=%s, Low=%s, Close=%s, Volume=%s\n",
 			ticker.Open.String(), ticker.High.String(), ticker.Low.String(),
 			ticker.Close.String(), ticker.Volume.String())
 	})
 
 	// Subscribe to BTCUSDT mark price
-	wsClient.SubscribeMarkPrice("BTCUSDT", func(markPrice *futures.MarkPrice) {
+	consume(wsClient.SubscribeMarkPrice(ctx, "BTCUSDT"))(func(markPrice *futures.MarkPrice) {
 		fmt.Printf("BTCUSDT Mark Price: %s (Index: %s, Funding: %s)\n",
 			markPrice.MarkPrice.String(), markPrice.IndexPrice.String(), markPrice.LastFundingRate.String())
 	})
 
 	// Subscribe to BTCUSDT trades
-	wsClient.SubscribeTrade("BTCUSDT", func(trade *futures.Trade) {
+	consume(wsClient.SubscribeTrade(ctx, "BTCUSDT"))(func(trade *futures.Trade) {
 		side := "SELL"
 		if trade.IsBuyerMaker {
 			side = "BUY"
@@ -156,7 +178,7 @@ func testFuturesWebSocket() {
 	})
 
 	// Subscribe to BTCUSDT aggregated trades
-	wsClient.SubscribeAggTrade("BTCUSDT", func(aggTrade *futures.AggTrade) {
+	consume(wsClient.SubscribeAggTrade(ctx, "BTCUSDT"))(func(aggTrade *futures.AggTrade) {
 		side := "SELL"
 		if aggTrade.IsBuyerMaker {
 			side = "BUY"
@@ -166,20 +188,20 @@ func testFuturesWebSocket() {
 	})
 
 	// Subscribe to BTCUSDT klines (1 minute)
-	wsClient.SubscribeKline("BTCUSDT", futures.Interval1m, func(kline *futures.Kline) {
+	consume(wsClient.SubscribeKline(ctx, "BTCUSDT", futures.Interval1m))(func(kline *futures.Kline) {
 		fmt.Printf("BTCUSDT Futures Kline: O=%s H=%s L=%s C=%s V=%s\n",
 			kline.Open.String(), kline.High.String(), kline.Low.String(),
 			kline.Close.String(), kline.Volume.String())
 	})
 
 	// Subscribe to BTCUSDT depth
-	wsClient.SubscribeDepth("BTCUSDT", 5, func(depth *futures.OrderBook) {
+	consume(wsClient.SubscribeDepth(ctx, "BTCUSDT", 5))(func(depth *futures.OrderBook) {
 		fmt.Printf("BTCUSDT Futures Depth: LastUpdateID=%d, Bids=%d, Asks=%d\n",
 			depth.LastUpdateID, len(depth.Bids), len(depth.Asks))
 	})
 
 	// Subscribe to all tickers
-	wsClient.SubscribeAllTickers(func(tickers []futures.Ticker24hr) {
+	consume(wsClient.SubscribeAllTickers(ctx))(func(tickers []futures.Ticker24hr) {
 		fmt.Printf("All Futures Tickers: Received %d tickers\n", len(tickers))
 		if len(tickers) > 0 {
 			fmt.Printf("  First ticker: %s = %s\n", tickers[0].Symbol, tickers[0].LastPrice.String())
@@ -187,7 +209,7 @@ func testFuturesWebSocket() {
 	})
 
 	// Subscribe to all mark prices
-	wsClient.SubscribeAllMarkPrices(func(markPrices []futures.MarkPrice) {
+	consume(wsClient.SubscribeAllMarkPrices(ctx))(func(markPrices []futures.MarkPrice) {
 		fmt.Printf("All Mark Prices: Received %d mark prices\n", len(markPrices))
 		if len(markPrices) > 0 {
 			fmt.Printf("  First mark price: %s = %s\n", markPrices[0].Symbol, markPrices[0].MarkPrice.String())
@@ -1,6 +1,8 @@
 package futures
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/shopspring/decimal"
@@ -19,9 +21,12 @@ func NewClient(config *common.ClientConfig) *Client {
 		config.BaseURL = "https://fapi.asterdex.com"
 	}
 
-	return &Client{
+	client := &Client{
 		Client: common.NewClient(config),
 	}
+	client.SetEndpointWeights(endpointWeights)
+	client.SetServerTimeEndpoint("/fapi/v3/time")
+	return client
 }
 
 // SetTestnet sets the client to use testnet
@@ -37,27 +42,50 @@ func (c *Client) SetTestnet(testnet bool) {
 
 // Ping tests connectivity to the REST API
 func (c *Client) Ping() error {
-	return c.Do("GET", "/fapi/v3/ping", nil, nil, false)
+	return c.PingCtx(context.Background())
+}
+
+// PingCtx is like Ping but carries ctx for cancellation and deadlines
+func (c *Client) PingCtx(ctx context.Context) error {
+	return c.DoCtx(ctx, "GET", "/fapi/v3/ping", nil, nil, false)
 }
 
 // GetServerTime gets the server time
 func (c *Client) GetServerTime() (int64, error) {
+	return c.GetServerTimeCtx(context.Background())
+}
+
+// GetServerTimeCtx is like GetServerTime but carries ctx for cancellation and deadlines
+func (c *Client) GetServerTimeCtx(ctx context.Context) (int64, error) {
 	var result struct {
 		ServerTime int64 `json:"serverTime"`
 	}
-	err := c.Do("GET", "/fapi/v3/time", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/time", nil, &result, false)
 	return result.ServerTime, err
 }
 
 // GetExchangeInfo gets exchange trading rules and symbol information
 func (c *Client) GetExchangeInfo() (*ExchangeInfo, error) {
+	return c.GetExchangeInfoCtx(context.Background())
+}
+
+// GetExchangeInfoCtx is like GetExchangeInfo but carries ctx for cancellation and deadlines
+func (c *Client) GetExchangeInfoCtx(ctx context.Context) (*ExchangeInfo, error) {
 	var result ExchangeInfo
-	err := c.Do("GET", "/fapi/v3/exchangeInfo", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/exchangeInfo", nil, &result, false)
+	if err == nil {
+		c.SetRateLimits(result.RateLimits)
+	}
 	return &result, err
 }
 
 // GetOrderBook gets the order book for a symbol
 func (c *Client) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	return c.GetOrderBookCtx(context.Background(), symbol, limit)
+}
+
+// GetOrderBookCtx is like GetOrderBook but carries ctx for cancellation and deadlines
+func (c *Client) GetOrderBookCtx(ctx context.Context, symbol string, limit int) (*OrderBook, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -70,12 +98,17 @@ func (c *Client) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
 	}
 
 	var result OrderBook
-	err := c.Do("GET", "/fapi/v3/depth", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/depth", params, &result, false)
 	return &result, err
 }
 
 // GetRecentTrades gets recent trades for a symbol
 func (c *Client) GetRecentTrades(symbol string, limit int) ([]Trade, error) {
+	return c.GetRecentTradesCtx(context.Background(), symbol, limit)
+}
+
+// GetRecentTradesCtx is like GetRecentTrades but carries ctx for cancellation and deadlines
+func (c *Client) GetRecentTradesCtx(ctx context.Context, symbol string, limit int) ([]Trade, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -88,75 +121,144 @@ func (c *Client) GetRecentTrades(symbol string, limit int) ([]Trade, error) {
 	}
 
 	var result []Trade
-	err := c.Do("GET", "/fapi/v3/trades", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/trades", params, &result, false)
 	return result, err
 }
 
 // GetHistoricalTrades gets historical trades for a symbol
 func (c *Client) GetHistoricalTrades(symbol string, limit int, fromID int64) ([]Trade, error) {
+	return c.GetHistoricalTradesCtx(context.Background(), symbol, limit, fromID)
+}
+
+// GetHistoricalTradesCtx is like GetHistoricalTrades but carries ctx for cancellation and deadlines
+func (c *Client) GetHistoricalTradesCtx(ctx context.Context, symbol string, limit int, fromID int64) ([]Trade, error) {
+	return c.QueryHistoricalTradesCtx(ctx, &HistoricalTradesRequest{
+		Symbol: symbol,
+		Limit:  common.Optional(limit),
+		FromID: common.Optional(fromID),
+	})
+}
+
+// QueryHistoricalTrades is like GetHistoricalTrades but takes its parameters as a request
+func (c *Client) QueryHistoricalTrades(req *HistoricalTradesRequest) ([]Trade, error) {
+	return c.QueryHistoricalTradesCtx(context.Background(), req)
+}
+
+// QueryHistoricalTradesCtx is like QueryHistoricalTrades but carries ctx for cancellation and deadlines
+func (c *Client) QueryHistoricalTradesCtx(ctx context.Context, req *HistoricalTradesRequest) ([]Trade, error) {
 	params := map[string]any{
-		"symbol": symbol,
+		"symbol": req.Symbol,
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
+	}
+	if req.FromID != nil {
+		params["fromId"] = *req.FromID
 	}
-	if fromID > 0 {
-		params["fromId"] = fromID
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result []Trade
-	err := c.Do("GET", "/fapi/v3/historicalTrades", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/historicalTrades", params, &result, true)
 	return result, err
 }
 
 // GetAggTrades gets compressed/aggregate trades for a symbol
 func (c *Client) GetAggTrades(symbol string, fromID, startTime, endTime int64, limit int) ([]AggTrade, error) {
+	return c.GetAggTradesCtx(context.Background(), symbol, fromID, startTime, endTime, limit)
+}
+
+// GetAggTradesCtx is like GetAggTrades but carries ctx for cancellation and deadlines
+func (c *Client) GetAggTradesCtx(ctx context.Context, symbol string, fromID, startTime, endTime int64, limit int) ([]AggTrade, error) {
+	return c.QueryAggTradesCtx(ctx, &AggTradesRequest{
+		Symbol:    symbol,
+		FromID:    common.Optional(fromID),
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryAggTrades is like GetAggTrades but takes its parameters as a request
+func (c *Client) QueryAggTrades(req *AggTradesRequest) ([]AggTrade, error) {
+	return c.QueryAggTradesCtx(context.Background(), req)
+}
+
+// QueryAggTradesCtx is like QueryAggTrades but carries ctx for cancellation and deadlines
+func (c *Client) QueryAggTradesCtx(ctx context.Context, req *AggTradesRequest) ([]AggTrade, error) {
 	params := map[string]any{
-		"symbol": symbol,
+		"symbol": req.Symbol,
 	}
-	if fromID > 0 {
-		params["fromId"] = fromID
+	if req.FromID != nil {
+		params["fromId"] = *req.FromID
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
 	}
 
-	var result []AggTrade
-	err := c.Do("GET", "/fapi/v3/aggTrades", params, &result, false)
-	return result, err
+	// Rows use the short field names of the stream payload
+	var result json.RawMessage
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/aggTrades", params, &result, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.ParseEvents(result, false, parseAggTrade)
 }
 
 // GetKlines gets kline/candlestick data for a symbol
 func (c *Client) GetKlines(symbol string, interval KlineInterval, startTime, endTime int64, limit int) ([]Kline, error) {
+	return c.GetKlinesCtx(context.Background(), symbol, interval, startTime, endTime, limit)
+}
+
+// GetKlinesCtx is like GetKlines but carries ctx for cancellation and deadlines
+func (c *Client) GetKlinesCtx(ctx context.Context, symbol string, interval KlineInterval, startTime, endTime int64, limit int) ([]Kline, error) {
+	return c.QueryKlinesCtx(ctx, &KlinesRequest{
+		Symbol:    symbol,
+		Interval:  interval,
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryKlines is like GetKlines but takes its parameters as a request
+func (c *Client) QueryKlines(req *KlinesRequest) ([]Kline, error) {
+	return c.QueryKlinesCtx(context.Background(), req)
+}
+
+// QueryKlinesCtx is like QueryKlines but carries ctx for cancellation and deadlines
+func (c *Client) QueryKlinesCtx(ctx context.Context, req *KlinesRequest) ([]Kline, error) {
 	params := map[string]any{
-		"symbol":   symbol,
-		"interval": interval,
+		"symbol":   req.Symbol,
+		"interval": req.Interval,
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
 	}
 
 	var result [][]any
-	err := c.Do("GET", "/fapi/v3/klines", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/klines", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
 
-	klines := make([]Kline, len(result))
-	for i, k := range result {
-		if len(k) < 12 {
+	klines := make([]Kline, 0, len(result))
+	for _, k := range result {
+		if len(k) < 11 {
 			continue
 		}
 
@@ -169,7 +271,7 @@ func (c *Client) GetKlines(symbol string, interval KlineInterval, startTime, end
 		takerBuyBaseAssetVolume, _ := decimal.NewFromString(k[9].(string))
 		takerBuyQuoteAssetVolume, _ := decimal.NewFromString(k[10].(string))
 
-		klines[i] = Kline{
+		klines = append(klines, Kline{
 			OpenTime:                 int64(k[0].(float64)),
 			Open:                     open,
 			High:                     high,
@@ -181,7 +283,7 @@ func (c *Client) GetKlines(symbol string, interval KlineInterval, startTime, end
 			NumberOfTrades:           int(k[8].(float64)),
 			TakerBuyBaseAssetVolume:  takerBuyBaseAssetVolume,
 			TakerBuyQuoteAssetVolume: takerBuyQuoteAssetVolume,
-		}
+		})
 	}
 
 	return klines, nil
@@ -189,29 +291,50 @@ func (c *Client) GetKlines(symbol string, interval KlineInterval, startTime, end
 
 // GetIndexPriceKlines gets index price kline/candlestick data
 func (c *Client) GetIndexPriceKlines(pair string, interval KlineInterval, startTime, endTime int64, limit int) ([]Kline, error) {
+	return c.GetIndexPriceKlinesCtx(context.Background(), pair, interval, startTime, endTime, limit)
+}
+
+// GetIndexPriceKlinesCtx is like GetIndexPriceKlines but carries ctx for cancellation and deadlines
+func (c *Client) GetIndexPriceKlinesCtx(ctx context.Context, pair string, interval KlineInterval, startTime, endTime int64, limit int) ([]Kline, error) {
+	return c.QueryIndexPriceKlinesCtx(ctx, &IndexPriceKlinesRequest{
+		Pair:      pair,
+		Interval:  interval,
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryIndexPriceKlines is like GetIndexPriceKlines but takes its parameters as a request
+func (c *Client) QueryIndexPriceKlines(req *IndexPriceKlinesRequest) ([]Kline, error) {
+	return c.QueryIndexPriceKlinesCtx(context.Background(), req)
+}
+
+// QueryIndexPriceKlinesCtx is like QueryIndexPriceKlines but carries ctx for cancellation and deadlines
+func (c *Client) QueryIndexPriceKlinesCtx(ctx context.Context, req *IndexPriceKlinesRequest) ([]Kline, error) {
 	params := map[string]any{
-		"pair":     pair,
-		"interval": interval,
+		"pair":     req.Pair,
+		"interval": req.Interval,
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
 	}
 
 	var result [][]any
-	err := c.Do("GET", "/fapi/v3/indexPriceKlines", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/indexPriceKlines", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
 
-	klines := make([]Kline, len(result))
-	for i, k := range result {
-		if len(k) < 12 {
+	klines := make([]Kline, 0, len(result))
+	for _, k := range result {
+		if len(k) < 11 {
 			continue
 		}
 
@@ -224,7 +347,7 @@ func (c *Client) GetIndexPriceKlines(pair string, interval KlineInterval, startT
 		takerBuyBaseAssetVolume, _ := decimal.NewFromString(k[9].(string))
 		takerBuyQuoteAssetVolume, _ := decimal.NewFromString(k[10].(string))
 
-		klines[i] = Kline{
+		klines = append(klines, Kline{
 			OpenTime:                 int64(k[0].(float64)),
 			Open:                     open,
 			High:                     high,
@@ -236,7 +359,7 @@ func (c *Client) GetIndexPriceKlines(pair string, interval KlineInterval, startT
 			NumberOfTrades:           int(k[8].(float64)),
 			TakerBuyBaseAssetVolume:  takerBuyBaseAssetVolume,
 			TakerBuyQuoteAssetVolume: takerBuyQuoteAssetVolume,
-		}
+		})
 	}
 
 	return klines, nil
@@ -244,29 +367,50 @@ func (c *Client) GetIndexPriceKlines(pair string, interval KlineInterval, startT
 
 // GetMarkPriceKlines gets mark price kline/candlestick data
 func (c *Client) GetMarkPriceKlines(symbol string, interval KlineInterval, startTime, endTime int64, limit int) ([]Kline, error) {
+	return c.GetMarkPriceKlinesCtx(context.Background(), symbol, interval, startTime, endTime, limit)
+}
+
+// GetMarkPriceKlinesCtx is like GetMarkPriceKlines but carries ctx for cancellation and deadlines
+func (c *Client) GetMarkPriceKlinesCtx(ctx context.Context, symbol string, interval KlineInterval, startTime, endTime int64, limit int) ([]Kline, error) {
+	return c.QueryMarkPriceKlinesCtx(ctx, &MarkPriceKlinesRequest{
+		Symbol:    symbol,
+		Interval:  interval,
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryMarkPriceKlines is like GetMarkPriceKlines but takes its parameters as a request
+func (c *Client) QueryMarkPriceKlines(req *MarkPriceKlinesRequest) ([]Kline, error) {
+	return c.QueryMarkPriceKlinesCtx(context.Background(), req)
+}
+
+// QueryMarkPriceKlinesCtx is like QueryMarkPriceKlines but carries ctx for cancellation and deadlines
+func (c *Client) QueryMarkPriceKlinesCtx(ctx context.Context, req *MarkPriceKlinesRequest) ([]Kline, error) {
 	params := map[string]any{
-		"symbol":   symbol,
-		"interval": interval,
+		"symbol":   req.Symbol,
+		"interval": req.Interval,
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
 	}
 
 	var result [][]any
-	err := c.Do("GET", "/fapi/v3/markPriceKlines", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/markPriceKlines", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
 
-	klines := make([]Kline, len(result))
-	for i, k := range result {
-		if len(k) < 12 {
+	klines := make([]Kline, 0, len(result))
+	for _, k := range result {
+		if len(k) < 11 {
 			continue
 		}
 
@@ -279,7 +423,7 @@ func (c *Client) GetMarkPriceKlines(symbol string, interval KlineInterval, start
 		takerBuyBaseAssetVolume, _ := decimal.NewFromString(k[9].(string))
 		takerBuyQuoteAssetVolume, _ := decimal.NewFromString(k[10].(string))
 
-		klines[i] = Kline{
+		klines = append(klines, Kline{
 			OpenTime:                 int64(k[0].(float64)),
 			Open:                     open,
 			High:                     high,
@@ -291,7 +435,7 @@ func (c *Client) GetMarkPriceKlines(symbol string, interval KlineInterval, start
 			NumberOfTrades:           int(k[8].(float64)),
 			TakerBuyBaseAssetVolume:  takerBuyBaseAssetVolume,
 			TakerBuyQuoteAssetVolume: takerBuyQuoteAssetVolume,
-		}
+		})
 	}
 
 	return klines, nil
@@ -299,6 +443,11 @@ func (c *Client) GetMarkPriceKlines(symbol string, interval KlineInterval, start
 
 // GetMarkPrice gets mark price
 func (c *Client) GetMarkPrice(symbol string) (*MarkPrice, error) {
+	return c.GetMarkPriceCtx(context.Background(), symbol)
+}
+
+// GetMarkPriceCtx is like GetMarkPrice but carries ctx for cancellation and deadlines
+func (c *Client) GetMarkPriceCtx(ctx context.Context, symbol string) (*MarkPrice, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -308,7 +457,7 @@ func (c *Client) GetMarkPrice(symbol string) (*MarkPrice, error) {
 	}
 
 	var result MarkPrice
-	err := c.Do("GET", "/fapi/v3/premiumIndex", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/premiumIndex", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
@@ -318,46 +467,81 @@ func (c *Client) GetMarkPrice(symbol string) (*MarkPrice, error) {
 
 // GetAllMarkPrices gets all mark prices
 func (c *Client) GetAllMarkPrices() ([]MarkPrice, error) {
+	return c.GetAllMarkPricesCtx(context.Background())
+}
+
+// GetAllMarkPricesCtx is like GetAllMarkPrices but carries ctx for cancellation and deadlines
+func (c *Client) GetAllMarkPricesCtx(ctx context.Context) ([]MarkPrice, error) {
 	var result []MarkPrice
-	err := c.Do("GET", "/fapi/v3/premiumIndex", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/premiumIndex", nil, &result, false)
 	return result, err
 }
 
 // GetFundingRateHistory gets funding rate history
 func (c *Client) GetFundingRateHistory(symbol string, startTime, endTime int64, limit int) ([]FundingRate, error) {
+	return c.GetFundingRateHistoryCtx(context.Background(), symbol, startTime, endTime, limit)
+}
+
+// GetFundingRateHistoryCtx is like GetFundingRateHistory but carries ctx for cancellation and deadlines
+func (c *Client) GetFundingRateHistoryCtx(ctx context.Context, symbol string, startTime, endTime int64, limit int) ([]FundingRate, error) {
+	return c.QueryFundingRateHistoryCtx(ctx, &FundingRateHistoryRequest{
+		Symbol:    symbol,
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryFundingRateHistory is like GetFundingRateHistory but takes its parameters as a request
+func (c *Client) QueryFundingRateHistory(req *FundingRateHistoryRequest) ([]FundingRate, error) {
+	return c.QueryFundingRateHistoryCtx(context.Background(), req)
+}
+
+// QueryFundingRateHistoryCtx is like QueryFundingRateHistory but carries ctx for cancellation and deadlines
+func (c *Client) QueryFundingRateHistoryCtx(ctx context.Context, req *FundingRateHistoryRequest) ([]FundingRate, error) {
 	params := map[string]any{}
-	if symbol != "" {
-		params["symbol"] = symbol
+	if req.Symbol != "" {
+		params["symbol"] = req.Symbol
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
 	}
 
 	var result []FundingRate
-	err := c.Do("GET", "/fapi/v3/fundingRate", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/fundingRate", params, &result, false)
 	return result, err
 }
 
 // GetFundingRateConfig gets funding rate configuration
 func (c *Client) GetFundingRateConfig(symbol string) (*FundingRateConfig, error) {
+	return c.GetFundingRateConfigCtx(context.Background(), symbol)
+}
+
+// GetFundingRateConfigCtx is like GetFundingRateConfig but carries ctx for cancellation and deadlines
+func (c *Client) GetFundingRateConfigCtx(ctx context.Context, symbol string) (*FundingRateConfig, error) {
 	params := map[string]any{}
 	if symbol != "" {
 		params["symbol"] = symbol
 	}
 
 	var result FundingRateConfig
-	err := c.Do("GET", "/fapi/v3/fundingRateConfig", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/fundingRateConfig", params, &result, false)
 	return &result, err
 }
 
 // GetTicker24hr gets 24hr ticker price change statistics
 func (c *Client) GetTicker24hr(symbol string) (*Ticker24hr, error) {
+	return c.GetTicker24hrCtx(context.Background(), symbol)
+}
+
+// GetTicker24hrCtx is like GetTicker24hr but carries ctx for cancellation and deadlines
+func (c *Client) GetTicker24hrCtx(ctx context.Context, symbol string) (*Ticker24hr, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -367,7 +551,7 @@ func (c *Client) GetTicker24hr(symbol string) (*Ticker24hr, error) {
 	}
 
 	var result Ticker24hr
-	err := c.Do("GET", "/fapi/v3/ticker/24hr", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/ticker/24hr", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
@@ -377,13 +561,23 @@ func (c *Client) GetTicker24hr(symbol string) (*Ticker24hr, error) {
 
 // GetAllTickers24hr gets 24hr ticker price change statistics for all symbols
 func (c *Client) GetAllTickers24hr() ([]Ticker24hr, error) {
+	return c.GetAllTickers24hrCtx(context.Background())
+}
+
+// GetAllTickers24hrCtx is like GetAllTickers24hr but carries ctx for cancellation and deadlines
+func (c *Client) GetAllTickers24hrCtx(ctx context.Context) ([]Ticker24hr, error) {
 	var result []Ticker24hr
-	err := c.Do("GET", "/fapi/v3/ticker/24hr", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/ticker/24hr", nil, &result, false)
 	return result, err
 }
 
 // GetPrice gets the latest price for a symbol
 func (c *Client) GetPrice(symbol string) (*PriceTicker, error) {
+	return c.GetPriceCtx(context.Background(), symbol)
+}
+
+// GetPriceCtx is like GetPrice but carries ctx for cancellation and deadlines
+func (c *Client) GetPriceCtx(ctx context.Context, symbol string) (*PriceTicker, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -393,7 +587,7 @@ func (c *Client) GetPrice(symbol string) (*PriceTicker, error) {
 	}
 
 	var result PriceTicker
-	err := c.Do("GET", "/fapi/v3/ticker/price", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/ticker/price", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
@@ -403,13 +597,23 @@ func (c *Client) GetPrice(symbol string) (*PriceTicker, error) {
 
 // GetAllPrices gets the latest price for all symbols
 func (c *Client) GetAllPrices() ([]PriceTicker, error) {
+	return c.GetAllPricesCtx(context.Background())
+}
+
+// GetAllPricesCtx is like GetAllPrices but carries ctx for cancellation and deadlines
+func (c *Client) GetAllPricesCtx(ctx context.Context) ([]PriceTicker, error) {
 	var result []PriceTicker
-	err := c.Do("GET", "/fapi/v3/ticker/price", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/ticker/price", nil, &result, false)
 	return result, err
 }
 
 // GetBookTicker gets the best bid/ask for a symbol
 func (c *Client) GetBookTicker(symbol string) (*BookTicker, error) {
+	return c.GetBookTickerCtx(context.Background(), symbol)
+}
+
+// GetBookTickerCtx is like GetBookTicker but carries ctx for cancellation and deadlines
+func (c *Client) GetBookTickerCtx(ctx context.Context, symbol string) (*BookTicker, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -419,7 +623,7 @@ func (c *Client) GetBookTicker(symbol string) (*BookTicker, error) {
 	}
 
 	var result BookTicker
-	err := c.Do("GET", "/fapi/v3/ticker/bookTicker", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/ticker/bookTicker", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
@@ -429,8 +633,13 @@ func (c *Client) GetBookTicker(symbol string) (*BookTicker, error) {
 
 // GetAllBookTickers gets the best bid/ask for all symbols
 func (c *Client) GetAllBookTickers() ([]BookTicker, error) {
+	return c.GetAllBookTickersCtx(context.Background())
+}
+
+// GetAllBookTickersCtx is like GetAllBookTickers but carries ctx for cancellation and deadlines
+func (c *Client) GetAllBookTickersCtx(ctx context.Context) ([]BookTicker, error) {
 	var result []BookTicker
-	err := c.Do("GET", "/fapi/v3/ticker/bookTicker", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/ticker/bookTicker", nil, &result, false)
 	return result, err
 }
 
@@ -438,40 +647,74 @@ func (c *Client) GetAllBookTickers() ([]BookTicker, error) {
 
 // ChangePositionMode changes position mode
 func (c *Client) ChangePositionMode(req *ChangePositionModeRequest) error {
+	return c.ChangePositionModeCtx(context.Background(), req)
+}
+
+// ChangePositionModeCtx is like ChangePositionMode but carries ctx for cancellation and deadlines
+func (c *Client) ChangePositionModeCtx(ctx context.Context, req *ChangePositionModeRequest) error {
 	params := map[string]any{
 		"dualSidePosition": req.DualSidePosition,
 	}
-	return c.Do("POST", "/fapi/v3/positionSide/dual", params, nil, true)
+	return c.DoCtx(ctx, "POST", "/fapi/v3/positionSide/dual", params, nil, true)
 }
 
 // GetCurrentPositionMode gets current position mode
 func (c *Client) GetCurrentPositionMode() (bool, error) {
+	return c.GetCurrentPositionModeCtx(context.Background())
+}
+
+// GetCurrentPositionModeCtx is like GetCurrentPositionMode but carries ctx for cancellation and deadlines
+func (c *Client) GetCurrentPositionModeCtx(ctx context.Context) (bool, error) {
 	var result struct {
 		DualSidePosition bool `json:"dualSidePosition"`
 	}
-	err := c.Do("GET", "/fapi/v3/positionSide/dual", nil, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/positionSide/dual", nil, &result, true)
 	return result.DualSidePosition, err
 }
 
 // ChangeMultiAssetsMode changes multi-assets mode
 func (c *Client) ChangeMultiAssetsMode(req *ChangeMultiAssetsModeRequest) error {
+	return c.ChangeMultiAssetsModeCtx(context.Background(), req)
+}
+
+// ChangeMultiAssetsModeCtx is like ChangeMultiAssetsMode but carries ctx for cancellation and deadlines
+func (c *Client) ChangeMultiAssetsModeCtx(ctx context.Context, req *ChangeMultiAssetsModeRequest) error {
 	params := map[string]any{
 		"multiAssetsMargin": req.MultiAssetsMargin,
 	}
-	return c.Do("POST", "/fapi/v3/multiAssetsMargin", params, nil, true)
+	return c.DoCtx(ctx, "POST", "/fapi/v3/multiAssetsMargin", params, nil, true)
 }
 
 // GetCurrentMultiAssetsMode gets current multi-assets mode
 func (c *Client) GetCurrentMultiAssetsMode() (bool, error) {
+	return c.GetCurrentMultiAssetsModeCtx(context.Background())
+}
+
+// GetCurrentMultiAssetsModeCtx is like GetCurrentMultiAssetsMode but carries ctx for cancellation and deadlines
+func (c *Client) GetCurrentMultiAssetsModeCtx(ctx context.Context) (bool, error) {
 	var result struct {
 		MultiAssetsMargin bool `json:"multiAssetsMargin"`
 	}
-	err := c.Do("GET", "/fapi/v3/multiAssetsMargin", nil, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/multiAssetsMargin", nil, &result, true)
 	return result.MultiAssetsMargin, err
 }
 
 // NewOrder places a new order
 func (c *Client) NewOrder(req *NewOrderRequest) (*Order, error) {
+	return c.NewOrderCtx(context.Background(), req)
+}
+
+// NewOrderCtx is like NewOrder but carries ctx for cancellation and deadlines
+func (c *Client) NewOrderCtx(ctx context.Context, req *NewOrderRequest) (*Order, error) {
+	params := newOrderParams(req)
+
+	var result Order
+	err := c.DoCtx(ctx, "POST", "/fapi/v3/order", params, &result, true)
+	return &result, err
+}
+
+// newOrderParams returns the request parameters of an order
+func newOrderParams(req *NewOrderRequest) map[string]any {
 	params := map[string]any{
 		"symbol": req.Symbol,
 		"side":   req.Side,
@@ -515,24 +758,45 @@ func (c *Client) NewOrder(req *NewOrderRequest) (*Order, error) {
 		params["positionSide"] = req.PositionSide
 	}
 
-	var result Order
-	err := c.Do("POST", "/fapi/v3/order", params, &result, true)
-	return &result, err
+	return params
 }
 
 // PlaceMultipleOrders places multiple orders
 func (c *Client) PlaceMultipleOrders(orders []NewOrderRequest) ([]Order, error) {
+	return c.PlaceMultipleOrdersCtx(context.Background(), orders)
+}
+
+// PlaceMultipleOrdersCtx is like PlaceMultipleOrders but carries ctx for cancellation and deadlines
+func (c *Client) PlaceMultipleOrdersCtx(ctx context.Context, orders []NewOrderRequest) ([]Order, error) {
+	// Each order is sent as a JSON object of string values
+	batch := make([]map[string]string, len(orders))
+	for i := range orders {
+		values, err := common.EncodeParams(newOrderParams(&orders[i]))
+		if err != nil {
+			return nil, err
+		}
+		batch[i] = make(map[string]string, len(values))
+		for k := range values {
+			batch[i][k] = values.Get(k)
+		}
+	}
+
 	params := map[string]any{
-		"batchOrders": orders,
+		"batchOrders": batch,
 	}
 
 	var result []Order
-	err := c.Do("POST", "/fapi/v3/batchOrders", params, &result, true)
+	err := c.DoCtx(ctx, "POST", "/fapi/v3/batchOrders", params, &result, true)
 	return result, err
 }
 
 // Transfer transfers between futures and spot
 func (c *Client) Transfer(req *TransferRequest) (*TransferResponse, error) {
+	return c.TransferCtx(context.Background(), req)
+}
+
+// TransferCtx is like Transfer but carries ctx for cancellation and deadlines
+func (c *Client) TransferCtx(ctx context.Context, req *TransferRequest) (*TransferResponse, error) {
 	params := map[string]any{
 		"asset":  req.Asset,
 		"amount": req.Amount,
@@ -540,30 +804,57 @@ func (c *Client) Transfer(req *TransferRequest) (*TransferResponse, error) {
 	}
 
 	var result TransferResponse
-	err := c.Do("POST", "/fapi/v3/transfer", params, &result, true)
+	err := c.DoCtx(ctx, "POST", "/fapi/v3/transfer", params, &result, true)
 	return &result, err
 }
 
 // GetOrder gets order information
 func (c *Client) GetOrder(symbol string, orderID int64, origClientOrderID string) (*Order, error) {
+	return c.GetOrderCtx(context.Background(), symbol, orderID, origClientOrderID)
+}
+
+// GetOrderCtx is like GetOrder but carries ctx for cancellation and deadlines
+func (c *Client) GetOrderCtx(ctx context.Context, symbol string, orderID int64, origClientOrderID string) (*Order, error) {
+	return c.QueryOrderCtx(ctx, &QueryOrderRequest{
+		Symbol:            symbol,
+		OrderID:           common.Optional(orderID),
+		OrigClientOrderID: origClientOrderID,
+	})
+}
+
+// QueryOrder is like GetOrder but takes its parameters as a request
+func (c *Client) QueryOrder(req *QueryOrderRequest) (*Order, error) {
+	return c.QueryOrderCtx(context.Background(), req)
+}
+
+// QueryOrderCtx is like QueryOrder but carries ctx for cancellation and deadlines
+func (c *Client) QueryOrderCtx(ctx context.Context, req *QueryOrderRequest) (*Order, error) {
 	params := map[string]any{
-		"symbol": symbol,
+		"symbol": req.Symbol,
 	}
 
-	if orderID > 0 {
-		params["orderId"] = orderID
+	if req.OrderID != nil {
+		params["orderId"] = *req.OrderID
 	}
-	if origClientOrderID != "" {
-		params["origClientOrderId"] = origClientOrderID
+	if req.OrigClientOrderID != "" {
+		params["origClientOrderId"] = req.OrigClientOrderID
+	}
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result Order
-	err := c.Do("GET", "/fapi/v3/order", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/order", params, &result, true)
 	return &result, err
 }
 
 // CancelOrder cancels an order
 func (c *Client) CancelOrder(symbol string, orderID int64, origClientOrderID string) (*Order, error) {
+	return c.CancelOrderCtx(context.Background(), symbol, orderID, origClientOrderID)
+}
+
+// CancelOrderCtx is like CancelOrder but carries ctx for cancellation and deadlines
+func (c *Client) CancelOrderCtx(ctx context.Context, symbol string, orderID int64, origClientOrderID string) (*Order, error) {
 	params := map[string]any{
 		"symbol": symbol,
 	}
@@ -576,20 +867,30 @@ func (c *Client) CancelOrder(symbol string, orderID int64, origClientOrderID str
 	}
 
 	var result Order
-	err := c.Do("DELETE", "/fapi/v3/order", params, &result, true)
+	err := c.DoCtx(ctx, "DELETE", "/fapi/v3/order", params, &result, true)
 	return &result, err
 }
 
 // CancelAllOpenOrders cancels all open orders
 func (c *Client) CancelAllOpenOrders(symbol string) error {
+	return c.CancelAllOpenOrdersCtx(context.Background(), symbol)
+}
+
+// CancelAllOpenOrdersCtx is like CancelAllOpenOrders but carries ctx for cancellation and deadlines
+func (c *Client) CancelAllOpenOrdersCtx(ctx context.Context, symbol string) error {
 	params := map[string]any{
 		"symbol": symbol,
 	}
-	return c.Do("DELETE", "/fapi/v3/allOpenOrders", params, nil, true)
+	return c.DoCtx(ctx, "DELETE", "/fapi/v3/allOpenOrders", params, nil, true)
 }
 
 // CancelMultipleOrders cancels multiple orders
 func (c *Client) CancelMultipleOrders(symbol string, orderIDList []int64, origClientOrderIDList []string) ([]Order, error) {
+	return c.CancelMultipleOrdersCtx(context.Background(), symbol, orderIDList, origClientOrderIDList)
+}
+
+// CancelMultipleOrdersCtx is like CancelMultipleOrders but carries ctx for cancellation and deadlines
+func (c *Client) CancelMultipleOrdersCtx(ctx context.Context, symbol string, orderIDList []int64, origClientOrderIDList []string) ([]Order, error) {
 	params := map[string]any{
 		"symbol": symbol,
 	}
@@ -602,92 +903,151 @@ func (c *Client) CancelMultipleOrders(symbol string, orderIDList []int64, origCl
 	}
 
 	var result []Order
-	err := c.Do("DELETE", "/fapi/v3/batchOrders", params, &result, true)
+	err := c.DoCtx(ctx, "DELETE", "/fapi/v3/batchOrders", params, &result, true)
 	return result, err
 }
 
 // AutoCancelAllOpenOrders cancels all open orders with countdown
 func (c *Client) AutoCancelAllOpenOrders(symbol string, countdownTime int64) error {
+	return c.AutoCancelAllOpenOrdersCtx(context.Background(), symbol, countdownTime)
+}
+
+// AutoCancelAllOpenOrdersCtx is like AutoCancelAllOpenOrders but carries ctx for cancellation and deadlines
+func (c *Client) AutoCancelAllOpenOrdersCtx(ctx context.Context, symbol string, countdownTime int64) error {
 	params := map[string]any{
 		"symbol":        symbol,
 		"countdownTime": countdownTime,
 	}
-	return c.Do("POST", "/fapi/v3/countdownCancelAll", params, nil, true)
+	return c.DoCtx(ctx, "POST", "/fapi/v3/countdownCancelAll", params, nil, true)
 }
 
 // GetOpenOrders gets all open orders
 func (c *Client) GetOpenOrders(symbol string) ([]Order, error) {
+	return c.GetOpenOrdersCtx(context.Background(), symbol)
+}
+
+// GetOpenOrdersCtx is like GetOpenOrders but carries ctx for cancellation and deadlines
+func (c *Client) GetOpenOrdersCtx(ctx context.Context, symbol string) ([]Order, error) {
 	params := map[string]any{}
 	if symbol != "" {
 		params["symbol"] = symbol
 	}
 
 	var result []Order
-	err := c.Do("GET", "/fapi/v3/openOrders", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/openOrders", params, &result, true)
 	return result, err
 }
 
 // GetAllOrders gets all orders
 func (c *Client) GetAllOrders(symbol string, orderID, startTime, endTime int64, limit int) ([]Order, error) {
+	return c.GetAllOrdersCtx(context.Background(), symbol, orderID, startTime, endTime, limit)
+}
+
+// GetAllOrdersCtx is like GetAllOrders but carries ctx for cancellation and deadlines
+func (c *Client) GetAllOrdersCtx(ctx context.Context, symbol string, orderID, startTime, endTime int64, limit int) ([]Order, error) {
+	return c.QueryAllOrdersCtx(ctx, &AllOrdersRequest{
+		Symbol:    symbol,
+		OrderID:   common.Optional(orderID),
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryAllOrders is like GetAllOrders but takes its parameters as a request
+func (c *Client) QueryAllOrders(req *AllOrdersRequest) ([]Order, error) {
+	return c.QueryAllOrdersCtx(context.Background(), req)
+}
+
+// QueryAllOrdersCtx is like QueryAllOrders but carries ctx for cancellation and deadlines
+func (c *Client) QueryAllOrdersCtx(ctx context.Context, req *AllOrdersRequest) ([]Order, error) {
 	params := map[string]any{
-		"symbol": symbol,
+		"symbol": req.Symbol,
 	}
 
-	if orderID > 0 {
-		params["orderId"] = orderID
+	if req.OrderID != nil {
+		params["orderId"] = *req.OrderID
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
+	}
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result []Order
-	err := c.Do("GET", "/fapi/v3/allOrders", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/allOrders", params, &result, true)
 	return result, err
 }
 
 // GetAccount gets account information
 func (c *Client) GetAccount() (*Account, error) {
+	return c.GetAccountCtx(context.Background())
+}
+
+// GetAccountCtx is like GetAccount but carries ctx for cancellation and deadlines
+func (c *Client) GetAccountCtx(ctx context.Context) (*Account, error) {
 	var result Account
-	err := c.Do("GET", "/fapi/v3/account", nil, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/account", nil, &result, true)
 	return &result, err
 }
 
 // GetBalance gets futures account balance
 func (c *Client) GetBalance() ([]Asset, error) {
+	return c.GetBalanceCtx(context.Background())
+}
+
+// GetBalanceCtx is like GetBalance but carries ctx for cancellation and deadlines
+func (c *Client) GetBalanceCtx(ctx context.Context) ([]Asset, error) {
 	var result []Asset
-	err := c.Do("GET", "/fapi/v3/balance", nil, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/balance", nil, &result, true)
 	return result, err
 }
 
 // ChangeLeverage changes initial leverage
 func (c *Client) ChangeLeverage(req *ChangeLeverageRequest) (*Order, error) {
+	return c.ChangeLeverageCtx(context.Background(), req)
+}
+
+// ChangeLeverageCtx is like ChangeLeverage but carries ctx for cancellation and deadlines
+func (c *Client) ChangeLeverageCtx(ctx context.Context, req *ChangeLeverageRequest) (*Order, error) {
 	params := map[string]any{
 		"symbol":   req.Symbol,
 		"leverage": req.Leverage,
 	}
 
 	var result Order
-	err := c.Do("POST", "/fapi/v3/leverage", params, &result, true)
+	err := c.DoCtx(ctx, "POST", "/fapi/v3/leverage", params, &result, true)
 	return &result, err
 }
 
 // ChangeMarginType changes margin type
 func (c *Client) ChangeMarginType(req *ChangeMarginTypeRequest) error {
+	return c.ChangeMarginTypeCtx(context.Background(), req)
+}
+
+// ChangeMarginTypeCtx is like ChangeMarginType but carries ctx for cancellation and deadlines
+func (c *Client) ChangeMarginTypeCtx(ctx context.Context, req *ChangeMarginTypeRequest) error {
 	params := map[string]any{
 		"symbol":     req.Symbol,
 		"marginType": req.MarginType,
 	}
-	return c.Do("POST", "/fapi/v3/marginType", params, nil, true)
+	return c.DoCtx(ctx, "POST", "/fapi/v3/marginType", params, nil, true)
 }
 
 // ModifyIsolatedPositionMargin modifies isolated position margin
 func (c *Client) ModifyIsolatedPositionMargin(req *ModifyIsolatedPositionMarginRequest) (*Order, error) {
+	return c.ModifyIsolatedPositionMarginCtx(context.Background(), req)
+}
+
+// ModifyIsolatedPositionMarginCtx is like ModifyIsolatedPositionMargin but carries ctx for cancellation and deadlines
+func (c *Client) ModifyIsolatedPositionMarginCtx(ctx context.Context, req *ModifyIsolatedPositionMarginRequest) (*Order, error) {
 	params := map[string]any{
 		"symbol": req.Symbol,
 		"amount": req.Amount,
@@ -695,150 +1055,266 @@ func (c *Client) ModifyIsolatedPositionMargin(req *ModifyIsolatedPositionMarginR
 	}
 
 	var result Order
-	err := c.Do("POST", "/fapi/v3/positionMargin", params, &result, true)
+	err := c.DoCtx(ctx, "POST", "/fapi/v3/positionMargin", params, &result, true)
 	return &result, err
 }
 
 // GetPositionMarginChangeHistory gets position margin change history
 func (c *Client) GetPositionMarginChangeHistory(symbol string, startTime, endTime int64, limit int) ([]PositionMarginChangeHistory, error) {
+	return c.GetPositionMarginChangeHistoryCtx(context.Background(), symbol, startTime, endTime, limit)
+}
+
+// GetPositionMarginChangeHistoryCtx is like GetPositionMarginChangeHistory but carries ctx for cancellation and deadlines
+func (c *Client) GetPositionMarginChangeHistoryCtx(ctx context.Context, symbol string, startTime, endTime int64, limit int) ([]PositionMarginChangeHistory, error) {
+	return c.QueryPositionMarginChangeHistoryCtx(ctx, &PositionMarginChangeHistoryRequest{
+		Symbol:    symbol,
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryPositionMarginChangeHistory is like GetPositionMarginChangeHistory but takes its parameters as a request
+func (c *Client) QueryPositionMarginChangeHistory(req *PositionMarginChangeHistoryRequest) ([]PositionMarginChangeHistory, error) {
+	return c.QueryPositionMarginChangeHistoryCtx(context.Background(), req)
+}
+
+// QueryPositionMarginChangeHistoryCtx is like QueryPositionMarginChangeHistory but carries ctx for cancellation and deadlines
+func (c *Client) QueryPositionMarginChangeHistoryCtx(ctx context.Context, req *PositionMarginChangeHistoryRequest) ([]PositionMarginChangeHistory, error) {
 	params := map[string]any{}
-	if symbol != "" {
-		params["symbol"] = symbol
+	if req.Symbol != "" {
+		params["symbol"] = req.Symbol
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
+	}
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result []PositionMarginChangeHistory
-	err := c.Do("GET", "/fapi/v3/positionMargin/history", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/positionMargin/history", params, &result, true)
 	return result, err
 }
 
 // GetPositionInfo gets position information
 func (c *Client) GetPositionInfo(symbol string) ([]Position, error) {
+	return c.GetPositionInfoCtx(context.Background(), symbol)
+}
+
+// GetPositionInfoCtx is like GetPositionInfo but carries ctx for cancellation and deadlines
+func (c *Client) GetPositionInfoCtx(ctx context.Context, symbol string) ([]Position, error) {
 	params := map[string]any{}
 	if symbol != "" {
 		params["symbol"] = symbol
 	}
 
 	var result []Position
-	err := c.Do("GET", "/fapi/v3/positionRisk", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/positionRisk", params, &result, true)
 	return result, err
 }
 
 // GetUserTrades gets user trade history
 func (c *Client) GetUserTrades(symbol string, orderID, startTime, endTime, fromID int64, limit int) ([]UserTrade, error) {
+	return c.GetUserTradesCtx(context.Background(), symbol, orderID, startTime, endTime, fromID, limit)
+}
+
+// GetUserTradesCtx is like GetUserTrades but carries ctx for cancellation and deadlines
+func (c *Client) GetUserTradesCtx(ctx context.Context, symbol string, orderID, startTime, endTime, fromID int64, limit int) ([]UserTrade, error) {
+	return c.QueryUserTradesCtx(ctx, &UserTradesRequest{
+		Symbol:    symbol,
+		OrderID:   common.Optional(orderID),
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		FromID:    common.Optional(fromID),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryUserTrades is like GetUserTrades but takes its parameters as a request
+func (c *Client) QueryUserTrades(req *UserTradesRequest) ([]UserTrade, error) {
+	return c.QueryUserTradesCtx(context.Background(), req)
+}
+
+// QueryUserTradesCtx is like QueryUserTrades but carries ctx for cancellation and deadlines
+func (c *Client) QueryUserTradesCtx(ctx context.Context, req *UserTradesRequest) ([]UserTrade, error) {
 	params := map[string]any{}
-	if symbol != "" {
-		params["symbol"] = symbol
+	if req.Symbol != "" {
+		params["symbol"] = req.Symbol
 	}
-	if orderID > 0 {
-		params["orderId"] = orderID
+	if req.OrderID != nil {
+		params["orderId"] = *req.OrderID
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if fromID > 0 {
-		params["fromId"] = fromID
+	if req.FromID != nil {
+		params["fromId"] = *req.FromID
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
+	}
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result []UserTrade
-	err := c.Do("GET", "/fapi/v3/userTrades", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/userTrades", params, &result, true)
 	return result, err
 }
 
 // GetIncomeHistory gets income history
 func (c *Client) GetIncomeHistory(symbol string, incomeType string, startTime, endTime int64, limit int) ([]Income, error) {
+	return c.GetIncomeHistoryCtx(context.Background(), symbol, incomeType, startTime, endTime, limit)
+}
+
+// GetIncomeHistoryCtx is like GetIncomeHistory but carries ctx for cancellation and deadlines
+func (c *Client) GetIncomeHistoryCtx(ctx context.Context, symbol string, incomeType string, startTime, endTime int64, limit int) ([]Income, error) {
+	return c.QueryIncomeHistoryCtx(ctx, &IncomeHistoryRequest{
+		Symbol:     symbol,
+		IncomeType: incomeType,
+		StartTime:  common.OptionalTime(startTime),
+		EndTime:    common.OptionalTime(endTime),
+		Limit:      common.Optional(limit),
+	})
+}
+
+// QueryIncomeHistory is like GetIncomeHistory but takes its parameters as a request
+func (c *Client) QueryIncomeHistory(req *IncomeHistoryRequest) ([]Income, error) {
+	return c.QueryIncomeHistoryCtx(context.Background(), req)
+}
+
+// QueryIncomeHistoryCtx is like QueryIncomeHistory but carries ctx for cancellation and deadlines
+func (c *Client) QueryIncomeHistoryCtx(ctx context.Context, req *IncomeHistoryRequest) ([]Income, error) {
 	params := map[string]any{}
-	if symbol != "" {
-		params["symbol"] = symbol
+	if req.Symbol != "" {
+		params["symbol"] = req.Symbol
 	}
-	if incomeType != "" {
-		params["incomeType"] = incomeType
+	if req.IncomeType != "" {
+		params["incomeType"] = req.IncomeType
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
+	}
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result []Income
-	err := c.Do("GET", "/fapi/v3/income", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/income", params, &result, true)
 	return result, err
 }
 
 // GetNotionalBracket gets notional and leverage brackets
 func (c *Client) GetNotionalBracket(symbol string) ([]NotionalBracket, error) {
+	return c.GetNotionalBracketCtx(context.Background(), symbol)
+}
+
+// GetNotionalBracketCtx is like GetNotionalBracket but carries ctx for cancellation and deadlines
+func (c *Client) GetNotionalBracketCtx(ctx context.Context, symbol string) ([]NotionalBracket, error) {
 	params := map[string]any{}
 	if symbol != "" {
 		params["symbol"] = symbol
 	}
 
 	var result []NotionalBracket
-	err := c.Do("GET", "/fapi/v3/leverageBracket", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/leverageBracket", params, &result, true)
 	return result, err
 }
 
 // GetADLQuantile gets position ADL quantile estimation
 func (c *Client) GetADLQuantile(symbol string) ([]ADLQuantile, error) {
+	return c.GetADLQuantileCtx(context.Background(), symbol)
+}
+
+// GetADLQuantileCtx is like GetADLQuantile but carries ctx for cancellation and deadlines
+func (c *Client) GetADLQuantileCtx(ctx context.Context, symbol string) ([]ADLQuantile, error) {
 	params := map[string]any{}
 	if symbol != "" {
 		params["symbol"] = symbol
 	}
 
 	var result []ADLQuantile
-	err := c.Do("GET", "/fapi/v3/adlQuantile", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/adlQuantile", params, &result, true)
 	return result, err
 }
 
 // GetForceOrders gets user's force orders
 func (c *Client) GetForceOrders(symbol string, autoCloseType string, startTime, endTime int64, limit int) ([]ForceOrder, error) {
+	return c.GetForceOrdersCtx(context.Background(), symbol, autoCloseType, startTime, endTime, limit)
+}
+
+// GetForceOrdersCtx is like GetForceOrders but carries ctx for cancellation and deadlines
+func (c *Client) GetForceOrdersCtx(ctx context.Context, symbol string, autoCloseType string, startTime, endTime int64, limit int) ([]ForceOrder, error) {
+	return c.QueryForceOrdersCtx(ctx, &ForceOrdersRequest{
+		Symbol:        symbol,
+		AutoCloseType: autoCloseType,
+		StartTime:     common.OptionalTime(startTime),
+		EndTime:       common.OptionalTime(endTime),
+		Limit:         common.Optional(limit),
+	})
+}
+
+// QueryForceOrders is like GetForceOrders but takes its parameters as a request
+func (c *Client) QueryForceOrders(req *ForceOrdersRequest) ([]ForceOrder, error) {
+	return c.QueryForceOrdersCtx(context.Background(), req)
+}
+
+// QueryForceOrdersCtx is like QueryForceOrders but carries ctx for cancellation and deadlines
+func (c *Client) QueryForceOrdersCtx(ctx context.Context, req *ForceOrdersRequest) ([]ForceOrder, error) {
 	params := map[string]any{}
-	if symbol != "" {
-		params["symbol"] = symbol
+	if req.Symbol != "" {
+		params["symbol"] = req.Symbol
 	}
-	if autoCloseType != "" {
-		params["autoCloseType"] = autoCloseType
+	if req.AutoCloseType != "" {
+		params["autoCloseType"] = req.AutoCloseType
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
+	}
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result []ForceOrder
-	err := c.Do("GET", "/fapi/v3/forceOrders", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/forceOrders", params, &result, true)
 	return result, err
 }
 
 // GetCommissionRate gets user commission rate
 func (c *Client) GetCommissionRate(symbol string) (*CommissionRate, error) {
+	return c.GetCommissionRateCtx(context.Background(), symbol)
+}
+
+// GetCommissionRateCtx is like GetCommissionRate but carries ctx for cancellation and deadlines
+func (c *Client) GetCommissionRateCtx(ctx context.Context, symbol string) (*CommissionRate, error) {
 	params := map[string]any{
 		"symbol": symbol,
 	}
 
 	var result CommissionRate
-	err := c.Do("GET", "/fapi/v3/commissionRate", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/fapi/v3/commissionRate", params, &result, true)
 	return &result, err
 }
 
@@ -846,23 +1322,38 @@ func (c *Client) GetCommissionRate(symbol string) (*CommissionRate, error) {
 
 // CreateListenKey creates a listen key for user data stream
 func (c *Client) CreateListenKey() (*ListenKeyResponse, error) {
+	return c.CreateListenKeyCtx(context.Background())
+}
+
+// CreateListenKeyCtx is like CreateListenKey but carries ctx for cancellation and deadlines
+func (c *Client) CreateListenKeyCtx(ctx context.Context) (*ListenKeyResponse, error) {
 	var result ListenKeyResponse
-	err := c.Do("POST", "/fapi/v3/listenKey", nil, &result, true)
+	err := c.DoCtx(ctx, "POST", "/fapi/v3/listenKey", nil, &result, true)
 	return &result, err
 }
 
 // KeepAliveListenKey keeps the listen key alive
 func (c *Client) KeepAliveListenKey(listenKey string) error {
+	return c.KeepAliveListenKeyCtx(context.Background(), listenKey)
+}
+
+// KeepAliveListenKeyCtx is like KeepAliveListenKey but carries ctx for cancellation and deadlines
+func (c *Client) KeepAliveListenKeyCtx(ctx context.Context, listenKey string) error {
 	params := map[string]any{
 		"listenKey": listenKey,
 	}
-	return c.Do("PUT", "/fapi/v3/listenKey", params, nil, true)
+	return c.DoCtx(ctx, "PUT", "/fapi/v3/listenKey", params, nil, true)
 }
 
 // CloseListenKey closes the listen key
 func (c *Client) CloseListenKey(listenKey string) error {
+	return c.CloseListenKeyCtx(context.Background(), listenKey)
+}
+
+// CloseListenKeyCtx is like CloseListenKey but carries ctx for cancellation and deadlines
+func (c *Client) CloseListenKeyCtx(ctx context.Context, listenKey string) error {
 	params := map[string]any{
 		"listenKey": listenKey,
 	}
-	return c.Do("DELETE", "/fapi/v3/listenKey", params, nil, true)
+	return c.DoCtx(ctx, "DELETE", "/fapi/v3/listenKey", params, nil, true)
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/yiplee/aster-go/common"
@@ -14,12 +16,26 @@ import (
 type MockHTTPClient struct {
 	Response *http.Response
 	Error    error
+	Request  *http.Request // last request sent
 }
 
 func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	m.Request = req
 	return m.Response, m.Error
 }
 
+// sentForm returns the parameters of the last request sent through m
+func sentForm(t *testing.T, m *MockHTTPClient) url.Values {
+	t.Helper()
+	if m.Request == nil {
+		t.Fatal("Expected a request to be sent")
+	}
+	if err := m.Request.ParseForm(); err != nil {
+		t.Fatalf("Failed to parse request form: %v", err)
+	}
+	return m.Request.Form
+}
+
 func TestNewClient(t *testing.T) {
 	client := NewClient(nil)
 
@@ -266,6 +282,69 @@ func TestGetKlines(t *testing.T) {
 	}
 }
 
+func TestGetKlinesSkipsShortRows(t *testing.T) {
+	// A short row is skipped rather than returned as a zero kline
+	responseBody := `[
+		[1499040000000, "0.01634790"],
+		[1499040000000, "0.01634790", "0.80000000", "0.01575800", "0.01577100", "148976.11427815", 1499644799999, "2434.19055334", 308, "1756.87402397", "28.46694368"]
+	]`
+
+	tests := []struct {
+		name string
+		get  func(c *Client) ([]Kline, error)
+	}{
+		{"klines", func(c *Client) ([]Kline, error) { return c.GetKlines("BTCUSDT", Interval1h, 0, 0, 100) }},
+		{"index price", func(c *Client) ([]Kline, error) { return c.GetIndexPriceKlines("BTCUSDT", Interval1h, 0, 0, 100) }},
+		{"mark price", func(c *Client) ([]Kline, error) { return c.GetMarkPriceKlines("BTCUSDT", Interval1h, 0, 0, 100) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(nil)
+			client.SetHTTPClient(&MockHTTPClient{
+				Response: &http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(bytes.NewBufferString(responseBody)),
+					Header:     make(http.Header),
+				},
+			})
+
+			klines, err := tt.get(client)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(klines) != 1 {
+				t.Fatalf("Expected 1 kline, got %d", len(klines))
+			}
+			if klines[0].OpenTime != 1499040000000 || klines[0].NumberOfTrades != 308 {
+				t.Errorf("Unexpected kline %+v", klines[0])
+			}
+		})
+	}
+}
+
+func TestGetAggTradesMalformed(t *testing.T) {
+	client := NewClient(nil)
+
+	// A row that fails to parse fails the request rather than being dropped
+	responseBody := `[
+		{"a": 1, "p": "40000", "q": "0.1", "f": 1, "l": 1, "T": 1640995200000, "m": false},
+		{"a": 2, "p": "not a price", "q": "0.1", "f": 2, "l": 2, "T": 1640995200001, "m": false}
+	]`
+	mockClient := &MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(responseBody)),
+			Header:     make(http.Header),
+		},
+	}
+	client.SetHTTPClient(mockClient)
+
+	trades, err := client.GetAggTrades("BTCUSDT", 0, 0, 0, 100)
+	if err == nil {
+		t.Fatalf("Expected an error for the malformed row, got %d trades", len(trades))
+	}
+}
+
 func TestGetMarkPrice(t *testing.T) {
 	client := NewClient(nil)
 
@@ -584,6 +663,70 @@ func TestTransfer(t *testing.T) {
 	if transfer.TranID != 21841 {
 		t.Errorf("Expected tran ID 21841, got %d", transfer.TranID)
 	}
+
+	form := sentForm(t, mockClient)
+	if form.Get("amount") != "100" || form.Get("asset") != "USDT" || form.Get("type") != "1" {
+		t.Errorf("Unexpected transfer params: %v", form)
+	}
+}
+
+func TestPlaceMultipleOrders(t *testing.T) {
+	client := NewClient(nil)
+	client.SetAPIKey("test-api-key", "test-secret-key")
+
+	mockClient := &MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(`[{"orderId": 1}, {"orderId": 2}]`)),
+			Header:     make(http.Header),
+		},
+	}
+	client.SetHTTPClient(mockClient)
+
+	orders := []NewOrderRequest{
+		{Symbol: "BTCUSDT", Side: OrderSideBuy, Type: OrderTypeMarket, Quantity: decimal.RequireFromString("0.01")},
+		{Symbol: "BTCUSDT", Side: OrderSideSell, Type: OrderTypeLimit, Quantity: decimal.RequireFromString("0.01"), Price: decimal.RequireFromString("50000"), TimeInForce: TimeInForceGTC},
+	}
+
+	result, err := client.PlaceMultipleOrders(orders)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result) != 2 {
+		t.Errorf("Expected 2 orders, got %d", len(result))
+	}
+
+	expected := `[{"quantity":"0.01","side":"BUY","symbol":"BTCUSDT","type":"MARKET"},` +
+		`{"price":"50000","quantity":"0.01","side":"SELL","symbol":"BTCUSDT","timeInForce":"GTC","type":"LIMIT"}]`
+	if got := sentForm(t, mockClient).Get("batchOrders"); got != expected {
+		t.Errorf("Expected batchOrders %s, got %s", expected, got)
+	}
+}
+
+func TestCancelMultipleOrders(t *testing.T) {
+	client := NewClient(nil)
+	client.SetAPIKey("test-api-key", "test-secret-key")
+
+	mockClient := &MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(`[]`)),
+			Header:     make(http.Header),
+		},
+	}
+	client.SetHTTPClient(mockClient)
+
+	if _, err := client.CancelMultipleOrders("BTCUSDT", []int64{1234, 5678}, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	query := mockClient.Request.URL.Query()
+	if got := query.Get("orderIdList"); got != "[1234,5678]" {
+		t.Errorf("Expected orderIdList [1234,5678], got %s", got)
+	}
+	if query.Has("origClientOrderIdList") {
+		t.Error("Expected origClientOrderIdList to be left out")
+	}
 }
 
 func TestCreateListenKey(t *testing.T) {
@@ -615,3 +758,173 @@ func TestCreateListenKey(t *testing.T) {
 		t.Errorf("Expected listen key '%s', got '%s'", expectedKey, listenKey.ListenKey)
 	}
 }
+
+func TestQueryRequests(t *testing.T) {
+	start := time.UnixMilli(1640995200000)
+	tests := []struct {
+		name   string
+		call   func(c *Client) error
+		body   string // response, an empty array by default
+		path   string
+		want   map[string]string
+		absent []string
+	}{
+		{
+			name: "historical trades",
+			call: func(c *Client) error {
+				_, err := c.QueryHistoricalTrades(&HistoricalTradesRequest{Symbol: "BTCUSDT", FromID: common.Ptr(int64(42))})
+				return err
+			},
+			path:   "/fapi/v3/historicalTrades",
+			want:   map[string]string{"symbol": "BTCUSDT", "fromId": "42"},
+			absent: []string{"limit"},
+		},
+		{
+			name: "aggregate trades",
+			call: func(c *Client) error {
+				_, err := c.QueryAggTrades(&AggTradesRequest{Symbol: "BTCUSDT", StartTime: &start, EndTime: common.Ptr(start.Add(time.Hour))})
+				return err
+			},
+			path:   "/fapi/v3/aggTrades",
+			want:   map[string]string{"symbol": "BTCUSDT", "startTime": "1640995200000", "endTime": "1640998800000"},
+			absent: []string{"fromId", "limit"},
+		},
+		{
+			name: "index price klines",
+			call: func(c *Client) error {
+				_, err := c.QueryIndexPriceKlines(&IndexPriceKlinesRequest{Pair: "BTCUSDT", Interval: Interval1h, Limit: common.Ptr(10)})
+				return err
+			},
+			path:   "/fapi/v3/indexPriceKlines",
+			want:   map[string]string{"pair": "BTCUSDT", "interval": "1h", "limit": "10"},
+			absent: []string{"startTime", "endTime"},
+		},
+		{
+			name: "mark price klines",
+			call: func(c *Client) error {
+				_, err := c.QueryMarkPriceKlines(&MarkPriceKlinesRequest{Symbol: "BTCUSDT", Interval: Interval1h, StartTime: &start})
+				return err
+			},
+			path:   "/fapi/v3/markPriceKlines",
+			want:   map[string]string{"symbol": "BTCUSDT", "interval": "1h", "startTime": "1640995200000"},
+			absent: []string{"endTime", "limit"},
+		},
+		{
+			name: "funding rate history",
+			call: func(c *Client) error {
+				_, err := c.QueryFundingRateHistory(&FundingRateHistoryRequest{StartTime: &start, Limit: common.Ptr(100)})
+				return err
+			},
+			path:   "/fapi/v3/fundingRate",
+			want:   map[string]string{"startTime": "1640995200000", "limit": "100"},
+			absent: []string{"symbol", "endTime"},
+		},
+		{
+			name: "order",
+			call: func(c *Client) error {
+				_, err := c.QueryOrder(&QueryOrderRequest{Symbol: "BTCUSDT", OrigClientOrderID: "abc"})
+				return err
+			},
+			body:   `{}`,
+			path:   "/fapi/v3/order",
+			want:   map[string]string{"symbol": "BTCUSDT", "origClientOrderId": "abc"},
+			absent: []string{"orderId"},
+		},
+		{
+			name: "all orders",
+			call: func(c *Client) error {
+				_, err := c.QueryAllOrders(&AllOrdersRequest{Symbol: "BTCUSDT", OrderID: common.Ptr(int64(7)), RecvWindow: 10000})
+				return err
+			},
+			path:   "/fapi/v3/allOrders",
+			want:   map[string]string{"symbol": "BTCUSDT", "orderId": "7", "recvWindow": "10000"},
+			absent: []string{"startTime", "endTime", "limit"},
+		},
+		{
+			name: "user trades",
+			call: func(c *Client) error {
+				_, err := c.QueryUserTrades(&UserTradesRequest{Symbol: "BTCUSDT", StartTime: &start, EndTime: common.Ptr(start.Add(time.Hour)), RecvWindow: 10000})
+				return err
+			},
+			path:   "/fapi/v3/userTrades",
+			want:   map[string]string{"symbol": "BTCUSDT", "startTime": "1640995200000", "endTime": "1640998800000", "recvWindow": "10000"},
+			absent: []string{"orderId", "fromId", "limit"},
+		},
+		{
+			name: "user trades from zero",
+			call: func(c *Client) error {
+				_, err := c.QueryUserTrades(&UserTradesRequest{Symbol: "BTCUSDT", FromID: common.Ptr(int64(0))})
+				return err
+			},
+			path:   "/fapi/v3/userTrades",
+			want:   map[string]string{"symbol": "BTCUSDT", "fromId": "0"},
+			absent: []string{"orderId", "startTime", "endTime", "limit"},
+		},
+		{
+			name: "position margin history",
+			call: func(c *Client) error {
+				_, err := c.QueryPositionMarginChangeHistory(&PositionMarginChangeHistoryRequest{Symbol: "BTCUSDT", Limit: common.Ptr(5)})
+				return err
+			},
+			path:   "/fapi/v3/positionMargin/history",
+			want:   map[string]string{"symbol": "BTCUSDT", "limit": "5"},
+			absent: []string{"startTime", "endTime"},
+		},
+		{
+			name: "income history",
+			call: func(c *Client) error {
+				_, err := c.QueryIncomeHistory(&IncomeHistoryRequest{IncomeType: "FUNDING_FEE", StartTime: &start})
+				return err
+			},
+			path:   "/fapi/v3/income",
+			want:   map[string]string{"incomeType": "FUNDING_FEE", "startTime": "1640995200000"},
+			absent: []string{"symbol", "endTime", "limit"},
+		},
+		{
+			name: "force orders",
+			call: func(c *Client) error {
+				_, err := c.QueryForceOrders(&ForceOrdersRequest{Symbol: "BTCUSDT", AutoCloseType: "LIQUIDATION"})
+				return err
+			},
+			path:   "/fapi/v3/forceOrders",
+			want:   map[string]string{"symbol": "BTCUSDT", "autoCloseType": "LIQUIDATION"},
+			absent: []string{"startTime", "endTime", "limit"},
+		},
+	}
+
+	for _, tt := range tests {
+		body := tt.body
+		if body == "" {
+			body = `[]`
+		}
+		client := NewClient(nil)
+		client.SetAPIKey("test-api-key", "test-secret-key")
+		mockClient := &MockHTTPClient{
+			Response: &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+				Header:     make(http.Header),
+			},
+		}
+		client.SetHTTPClient(mockClient)
+
+		if err := tt.call(client); err != nil {
+			t.Errorf("%s: Expected no error, got %v", tt.name, err)
+			continue
+		}
+		if mockClient.Request.URL.Path != tt.path {
+			t.Errorf("%s: Expected path %s, got %s", tt.name, tt.path, mockClient.Request.URL.Path)
+		}
+		form := sentForm(t, mockClient)
+		for key, value := range tt.want {
+			if got := form.Get(key); got != value {
+				t.Errorf("%s: Expected %s=%s, got %q", tt.name, key, value, got)
+			}
+		}
+		for _, key := range tt.absent {
+			if form.Has(key) {
+				t.Errorf("%s: Expected unset %s to be left out", tt.name, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package futures
+
+import (
+	"context"
+	"iter"
+	"strconv"
+	"time"
+
+	"github.com/yiplee/aster-go/common"
+)
+
+// Page sizes and time windows allowed by the history endpoints
+const (
+	maxTradesLimit      = 500
+	maxAggTradesLimit   = 1000
+	maxKlinesLimit      = 1500
+	maxOrdersLimit      = 1000
+	maxUserTradesLimit  = 1000
+	maxIncomeLimit      = 1000
+	maxFundingLimit     = 1000
+	maxForceOrdersLimit = 100
+
+	aggTradesWindow   = time.Hour
+	allOrdersWindow   = 7 * 24 * time.Hour
+	userTradesWindow  = 7 * 24 * time.Hour
+	incomeWindow      = 7 * 24 * time.Hour
+	forceOrdersWindow = 7 * 24 * time.Hour
+)
+
+// IterHistoricalTrades iterates over the trades of a symbol from fromID on.
+// fromID is required: 1 starts from the first trade.
+func (c *Client) IterHistoricalTrades(ctx context.Context, symbol string, fromID int64) iter.Seq2[Trade, error] {
+	return common.IDPaginator[Trade]{
+		Limit: maxTradesLimit,
+		Fetch: func(ctx context.Context, fromID int64, limit int) ([]Trade, error) {
+			return c.GetHistoricalTradesCtx(ctx, symbol, limit, fromID)
+		},
+		ID: func(t Trade) int64 { return t.ID },
+	}.All(ctx, fromID)
+}
+
+// IterAggTrades iterates over the aggregate trades of a symbol between
+// startTime and endTime, or from fromID on when startTime is zero. One of
+// startTime and fromID is required.
+func (c *Client) IterAggTrades(ctx context.Context, symbol string, fromID, startTime, endTime int64) iter.Seq2[AggTrade, error] {
+	if startTime == 0 {
+		return common.IDPaginator[AggTrade]{
+			Limit: maxAggTradesLimit,
+			Fetch: func(ctx context.Context, fromID int64, limit int) ([]AggTrade, error) {
+				return c.GetAggTradesCtx(ctx, symbol, fromID, 0, 0, limit)
+			},
+			ID: func(t AggTrade) int64 { return t.AggregateTradeID },
+		}.All(ctx, fromID)
+	}
+
+	return common.TimePaginator[AggTrade]{
+		Limit:  maxAggTradesLimit,
+		Window: aggTradesWindow,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]AggTrade, error) {
+			return c.GetAggTradesCtx(ctx, symbol, 0, startTime, endTime, limit)
+		},
+		Time: func(t AggTrade) int64 { return t.Timestamp },
+		Key:  func(t AggTrade) string { return strconv.FormatInt(t.AggregateTradeID, 10) },
+	}.All(ctx, startTime, endTime)
+}
+
+// IterKlines iterates over the klines of a symbol between startTime and
+// endTime
+func (c *Client) IterKlines(ctx context.Context, symbol string, interval KlineInterval, startTime, endTime int64) iter.Seq2[Kline, error] {
+	return common.TimePaginator[Kline]{
+		Limit: maxKlinesLimit,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]Kline, error) {
+			return c.GetKlinesCtx(ctx, symbol, interval, startTime, endTime, limit)
+		},
+		Time: func(k Kline) int64 { return k.OpenTime },
+		Key:  func(k Kline) string { return strconv.FormatInt(k.OpenTime, 10) },
+	}.All(ctx, startTime, endTime)
+}
+
+// IterAllOrders iterates over the orders of a symbol between startTime and
+// endTime, or from orderID on when startTime is zero. One of startTime and
+// orderID is required.
+func (c *Client) IterAllOrders(ctx context.Context, symbol string, orderID, startTime, endTime int64) iter.Seq2[Order, error] {
+	if startTime == 0 {
+		return common.IDPaginator[Order]{
+			Limit: maxOrdersLimit,
+			Fetch: func(ctx context.Context, orderID int64, limit int) ([]Order, error) {
+				return c.GetAllOrdersCtx(ctx, symbol, orderID, 0, 0, limit)
+			},
+			ID: func(o Order) int64 { return o.OrderID },
+		}.All(ctx, orderID)
+	}
+
+	return common.TimePaginator[Order]{
+		Limit:  maxOrdersLimit,
+		Window: allOrdersWindow,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]Order, error) {
+			return c.GetAllOrdersCtx(ctx, symbol, 0, startTime, endTime, limit)
+		},
+		Time: func(o Order) int64 { return o.Time },
+		Key:  func(o Order) string { return strconv.FormatInt(o.OrderID, 10) },
+	}.All(ctx, startTime, endTime)
+}
+
+// IterUserTrades iterates over the account trades of a symbol between
+// startTime and endTime, or from fromID on when startTime is zero. One of
+// startTime and fromID is required.
+func (c *Client) IterUserTrades(ctx context.Context, symbol string, fromID, startTime, endTime int64) iter.Seq2[UserTrade, error] {
+	if startTime == 0 {
+		return common.IDPaginator[UserTrade]{
+			Limit: maxUserTradesLimit,
+			Fetch: func(ctx context.Context, fromID int64, limit int) ([]UserTrade, error) {
+				return c.GetUserTradesCtx(ctx, symbol, 0, 0, 0, fromID, limit)
+			},
+			ID: func(t UserTrade) int64 { return t.ID },
+		}.All(ctx, fromID)
+	}
+
+	return common.TimePaginator[UserTrade]{
+		Limit:  maxUserTradesLimit,
+		Window: userTradesWindow,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]UserTrade, error) {
+			return c.GetUserTradesCtx(ctx, symbol, 0, startTime, endTime, 0, limit)
+		},
+		Time: func(t UserTrade) int64 { return t.Time },
+		Key:  func(t UserTrade) string { return strconv.FormatInt(t.ID, 10) },
+	}.All(ctx, startTime, endTime)
+}
+
+// IterIncomeHistory iterates over the income history between startTime and
+// endTime. symbol and incomeType are optional filters.
+func (c *Client) IterIncomeHistory(ctx context.Context, symbol, incomeType string, startTime, endTime int64) iter.Seq2[Income, error] {
+	return common.TimePaginator[Income]{
+		Limit:  maxIncomeLimit,
+		Window: incomeWindow,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]Income, error) {
+			return c.GetIncomeHistoryCtx(ctx, symbol, incomeType, startTime, endTime, limit)
+		},
+		Time: func(i Income) int64 { return i.Time },
+		Key: func(i Income) string {
+			return strconv.FormatInt(i.TranID, 10) + "/" + i.IncomeType + "/" + i.Symbol + "/" + i.Asset
+		},
+	}.All(ctx, startTime, endTime)
+}
+
+// IterFundingRateHistory iterates over the funding rates between startTime
+// and endTime. symbol is an optional filter.
+func (c *Client) IterFundingRateHistory(ctx context.Context, symbol string, startTime, endTime int64) iter.Seq2[FundingRate, error] {
+	return common.TimePaginator[FundingRate]{
+		Limit: maxFundingLimit,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]FundingRate, error) {
+			return c.GetFundingRateHistoryCtx(ctx, symbol, startTime, endTime, limit)
+		},
+		Time: func(f FundingRate) int64 { return f.FundingTime },
+		Key:  func(f FundingRate) string { return f.Symbol },
+	}.All(ctx, startTime, endTime)
+}
+
+// IterForceOrders iterates over the force orders between startTime and
+// endTime. symbol and autoCloseType are optional filters.
+func (c *Client) IterForceOrders(ctx context.Context, symbol, autoCloseType string, startTime, endTime int64) iter.Seq2[ForceOrder, error] {
+	return common.TimePaginator[ForceOrder]{
+		Limit:  maxForceOrdersLimit,
+		Window: forceOrdersWindow,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]ForceOrder, error) {
+			return c.GetForceOrdersCtx(ctx, symbol, autoCloseType, startTime, endTime, limit)
+		},
+		Time: func(o ForceOrder) int64 { return o.Time },
+		Key:  func(o ForceOrder) string { return strconv.FormatInt(o.OrderID, 10) },
+	}.All(ctx, startTime, endTime)
+}
@@ -0,0 +1,52 @@
+package futures
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/yiplee/aster-go/common"
+)
+
+func TestIterAggTrades(t *testing.T) {
+	var fromIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromID := r.URL.Query().Get("fromId")
+		fromIDs = append(fromIDs, fromID)
+
+		start, _ := strconv.Atoi(fromID)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var rows []map[string]any
+		for id := max(start, 1); id <= 1500 && len(rows) < limit; id++ {
+			rows = append(rows, map[string]any{"a": id, "p": "40000", "q": "0.1", "f": id, "l": id, "T": 1640995200000 + id, "m": false})
+		}
+		json.NewEncoder(w).Encode(rows)
+	}))
+	defer server.Close()
+
+	config := common.DefaultConfig()
+	config.BaseURL = server.URL
+	client := NewClient(config)
+
+	var last int64
+	count := 0
+	for trade, err := range client.IterAggTrades(t.Context(), "BTCUSDT", 1, 0, 0) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if trade.AggregateTradeID != last+1 {
+			t.Fatalf("Expected trade %d, got %d", last+1, trade.AggregateTradeID)
+		}
+		last = trade.AggregateTradeID
+		count++
+	}
+
+	if count != 1500 {
+		t.Errorf("Expected 1500 trades, got %d", count)
+	}
+	if len(fromIDs) != 2 || fromIDs[1] != "1001" {
+		t.Errorf("Unexpected cursors: %v", fromIDs)
+	}
+}
@@ -1,7 +1,10 @@
 package futures
 
 import (
+	"time"
+
 	"github.com/shopspring/decimal"
+	"github.com/yiplee/aster-go/common"
 )
 
 // OrderSide represents the order side
@@ -95,15 +98,10 @@ const (
 
 // ExchangeInfo represents the exchange information
 type ExchangeInfo struct {
-	Timezone   string `json:"timezone"`
-	ServerTime int64  `json:"serverTime"`
-	RateLimits []struct {
-		RateLimitType string `json:"rateLimitType"`
-		Interval      string `json:"interval"`
-		IntervalNum   int    `json:"intervalNum"`
-		Limit         int    `json:"limit"`
-	} `json:"rateLimits"`
-	ExchangeFilters []any `json:"exchangeFilters"`
+	Timezone        string             `json:"timezone"`
+	ServerTime      int64              `json:"serverTime"`
+	RateLimits      []common.RateLimit `json:"rateLimits"`
+	ExchangeFilters []any              `json:"exchangeFilters"`
 	Assets          []struct {
 		Asset string `json:"asset"`
 	} `json:"assets"`
@@ -505,3 +503,113 @@ type NewOrderRequest struct {
 type ListenKeyResponse struct {
 	ListenKey string `json:"listenKey"`
 }
+
+// HistoricalTradesRequest represents a historical trades request
+type HistoricalTradesRequest struct {
+	Symbol     string
+	Limit      *int   // optional, page size
+	FromID     *int64 // optional, first id to return
+	RecvWindow int64  // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
+
+// AggTradesRequest represents an aggregate trades request
+type AggTradesRequest struct {
+	Symbol    string
+	FromID    *int64     // optional, first id to return
+	StartTime *time.Time // optional
+	EndTime   *time.Time // optional
+	Limit     *int       // optional, page size
+}
+
+// KlinesRequest represents a klines request
+type KlinesRequest struct {
+	Symbol    string
+	Interval  KlineInterval
+	StartTime *time.Time // optional
+	EndTime   *time.Time // optional
+	Limit     *int       // optional, page size
+}
+
+// IndexPriceKlinesRequest represents an index price klines request
+type IndexPriceKlinesRequest struct {
+	Pair      string
+	Interval  KlineInterval
+	StartTime *time.Time // optional
+	EndTime   *time.Time // optional
+	Limit     *int       // optional, page size
+}
+
+// MarkPriceKlinesRequest represents a mark price klines request
+type MarkPriceKlinesRequest struct {
+	Symbol    string
+	Interval  KlineInterval
+	StartTime *time.Time // optional
+	EndTime   *time.Time // optional
+	Limit     *int       // optional, page size
+}
+
+// FundingRateHistoryRequest represents a funding rate history request
+type FundingRateHistoryRequest struct {
+	Symbol    string     // optional
+	StartTime *time.Time // optional
+	EndTime   *time.Time // optional
+	Limit     *int       // optional, page size
+}
+
+// QueryOrderRequest represents a query order request
+type QueryOrderRequest struct {
+	Symbol            string
+	OrderID           *int64 // optional
+	OrigClientOrderID string // optional
+	RecvWindow        int64  // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
+
+// AllOrdersRequest represents an all orders request
+type AllOrdersRequest struct {
+	Symbol     string
+	OrderID    *int64     // optional
+	StartTime  *time.Time // optional
+	EndTime    *time.Time // optional
+	Limit      *int       // optional, page size
+	RecvWindow int64      // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
+
+// UserTradesRequest represents a user trades request
+type UserTradesRequest struct {
+	Symbol     string     // optional
+	OrderID    *int64     // optional
+	StartTime  *time.Time // optional
+	EndTime    *time.Time // optional
+	FromID     *int64     // optional, first id to return
+	Limit      *int       // optional, page size
+	RecvWindow int64      // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
+
+// PositionMarginChangeHistoryRequest represents a position margin change history request
+type PositionMarginChangeHistoryRequest struct {
+	Symbol     string     // optional
+	StartTime  *time.Time // optional
+	EndTime    *time.Time // optional
+	Limit      *int       // optional, page size
+	RecvWindow int64      // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
+
+// IncomeHistoryRequest represents an income history request
+type IncomeHistoryRequest struct {
+	Symbol     string     // optional
+	IncomeType string     // optional
+	StartTime  *time.Time // optional
+	EndTime    *time.Time // optional
+	Limit      *int       // optional, page size
+	RecvWindow int64      // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
+
+// ForceOrdersRequest represents a force orders request
+type ForceOrdersRequest struct {
+	Symbol        string     // optional
+	AutoCloseType string     // optional
+	StartTime     *time.Time // optional
+	EndTime       *time.Time // optional
+	Limit         *int       // optional, page size
+	RecvWindow    int64      // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
@@ -1,7 +1,9 @@
 package futures
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -12,170 +14,156 @@ import (
 // WebSocketClient represents the futures WebSocket client
 type WebSocketClient struct {
 	*common.WebSocketClient
+	marketStreams
 	baseURL string
 }
 
 // NewWebSocketClient creates a new futures WebSocket client
 func NewWebSocketClient(testnet bool) *WebSocketClient {
-	baseURL := "wss://fstream.asterdex.com"
-	if testnet {
-		baseURL = "wss://testnet-fstream.asterdex.com"
-	}
+	baseURL := websocketURL(testnet)
+	ws := newConnection(baseURL)
 
 	return &WebSocketClient{
-		WebSocketClient: common.NewWebSocketClient(baseURL),
+		WebSocketClient: ws,
+		marketStreams:   marketStreams{sub: ws},
 		baseURL:         baseURL,
 	}
 }
 
+// WebSocketPool spreads futures streams across several WebSocket connections
+type WebSocketPool struct {
+	*common.WebSocketPool
+	marketStreams
+}
+
+// NewWebSocketPool creates a futures WebSocket pool with at most maxStreams
+// streams per connection, 200 if maxStreams is 0
+func NewWebSocketPool(testnet bool, maxStreams int) *WebSocketPool {
+	baseURL := websocketURL(testnet)
+	pool := common.NewWebSocketPool(func() *common.WebSocketClient {
+		return newConnection(baseURL)
+	}, maxStreams)
+
+	return &WebSocketPool{
+		WebSocketPool: pool,
+		marketStreams: marketStreams{sub: pool},
+	}
+}
+
+// websocketURL returns the WebSocket base URL of mainnet or testnet
+func websocketURL(testnet bool) string {
+	if testnet {
+		return "wss://testnet-fstream.asterdex.com"
+	}
+	return "wss://fstream.asterdex.com"
+}
+
+// newConnection creates a WebSocket client for futures streams
+func newConnection(baseURL string) *common.WebSocketClient {
+	ws := common.NewWebSocketClient(baseURL)
+	// Futures streams accept 10 incoming messages per second
+	ws.SetMessageRate(10)
+	return ws
+}
+
+// marketStreams provides the market stream subscriptions of a client or a
+// pool
+type marketStreams struct {
+	sub common.StreamSubscriber
+}
+
 // Subscribe to individual symbol ticker streams
-func (c *WebSocketClient) SubscribeTicker(symbol string, handler func(*Ticker24hr)) {
+func (s marketStreams) SubscribeTicker(ctx context.Context, symbol string) (*common.Subscription[*Ticker24hr], error) {
 	stream := fmt.Sprintf("%s@ticker", strings.ToLower(symbol))
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if ticker := parseTicker24hr(data); ticker != nil {
-			handler(ticker)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseTicker24hr)
 }
 
 // Subscribe to all symbols ticker stream
-func (c *WebSocketClient) SubscribeAllTickers(handler func([]Ticker24hr)) {
+func (s marketStreams) SubscribeAllTickers(ctx context.Context) (*common.Subscription[[]Ticker24hr], error) {
 	stream := "!ticker@arr"
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if tickers := parseAllTickers(data); tickers != nil {
-			handler(tickers)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseAllTickers)
 }
 
 // Subscribe to individual symbol mini ticker streams
-func (c *WebSocketClient) SubscribeMiniTicker(symbol string, handler func(*MiniTicker)) {
+func (s marketStreams) SubscribeMiniTicker(ctx context.Context, symbol string) (*common.Subscription[*MiniTicker], error) {
 	stream := fmt.Sprintf("%s@miniTicker", strings.ToLower(symbol))
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if ticker := parseMiniTicker(data); ticker != nil {
-			handler(ticker)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseMiniTicker)
 }
 
 // Subscribe to all symbols mini ticker stream
-func (c *WebSocketClient) SubscribeAllMiniTickers(handler func([]MiniTicker)) {
+func (s marketStreams) SubscribeAllMiniTickers(ctx context.Context) (*common.Subscription[[]MiniTicker], error) {
 	stream := "!miniTicker@arr"
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if tickers := parseAllMiniTickers(data); tickers != nil {
-			handler(tickers)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseAllMiniTickers)
 }
 
 // Subscribe to individual symbol book ticker streams
-func (c *WebSocketClient) SubscribeBookTicker(symbol string, handler func(*BookTicker)) {
+func (s marketStreams) SubscribeBookTicker(ctx context.Context, symbol string) (*common.Subscription[*BookTicker], error) {
 	stream := fmt.Sprintf("%s@bookTicker", strings.ToLower(symbol))
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if bookTicker := parseBookTicker(data); bookTicker != nil {
-			handler(bookTicker)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseBookTicker)
 }
 
 // Subscribe to all symbols book ticker stream
-func (c *WebSocketClient) SubscribeAllBookTickers(handler func([]BookTicker)) {
+func (s marketStreams) SubscribeAllBookTickers(ctx context.Context) (*common.Subscription[[]BookTicker], error) {
 	stream := "!bookTicker@arr"
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if bookTickers := parseAllBookTickers(data); bookTickers != nil {
-			handler(bookTickers)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseAllBookTickers)
 }
 
 // Subscribe to individual symbol trade streams
-func (c *WebSocketClient) SubscribeTrade(symbol string, handler func(*Trade)) {
+func (s marketStreams) SubscribeTrade(ctx context.Context, symbol string) (*common.Subscription[*Trade], error) {
 	stream := fmt.Sprintf("%s@trade", strings.ToLower(symbol))
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if trade := parseTrade(data); trade != nil {
-			handler(trade)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseTrade)
 }
 
 // Subscribe to individual symbol aggregated trade streams
-func (c *WebSocketClient) SubscribeAggTrade(symbol string, handler func(*AggTrade)) {
+func (s marketStreams) SubscribeAggTrade(ctx context.Context, symbol string) (*common.Subscription[*AggTrade], error) {
 	stream := fmt.Sprintf("%s@aggTrade", strings.ToLower(symbol))
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if aggTrade := parseAggTrade(data); aggTrade != nil {
-			handler(aggTrade)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseAggTrade)
 }
 
 // Subscribe to individual symbol kline streams
-func (c *WebSocketClient) SubscribeKline(symbol string, interval KlineInterval, handler func(*Kline)) {
+func (s marketStreams) SubscribeKline(ctx context.Context, symbol string, interval KlineInterval) (*common.Subscription[*Kline], error) {
 	stream := fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if kline := parseKline(data); kline != nil {
-			handler(kline)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseKline)
 }
 
 // Subscribe to individual symbol depth streams
-func (c *WebSocketClient) SubscribeDepth(symbol string, levels int, handler func(*OrderBook)) {
+func (s marketStreams) SubscribeDepth(ctx context.Context, symbol string, levels int) (*common.Subscription[*OrderBook], error) {
 	var stream string
 	if levels > 0 {
 		stream = fmt.Sprintf("%s@depth%d", strings.ToLower(symbol), levels)
 	} else {
 		stream = fmt.Sprintf("%s@depth", strings.ToLower(symbol))
 	}
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if depth := parseDepth(data); depth != nil {
-			handler(depth)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseDepth)
 }
 
 // Subscribe to individual symbol depth streams with 100ms updates
-func (c *WebSocketClient) SubscribeDepthWithUpdates(symbol string, levels int, handler func(*OrderBook)) {
+func (s marketStreams) SubscribeDepthWithUpdates(ctx context.Context, symbol string, levels int) (*common.Subscription[*OrderBook], error) {
 	var stream string
 	if levels > 0 {
 		stream = fmt.Sprintf("%s@depth%d@100ms", strings.ToLower(symbol), levels)
 	} else {
 		stream = fmt.Sprintf("%s@depth@100ms", strings.ToLower(symbol))
 	}
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if depth := parseDepth(data); depth != nil {
-			handler(depth)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseDepth)
 }
 
 // Subscribe to mark price streams
-func (c *WebSocketClient) SubscribeMarkPrice(symbol string, handler func(*MarkPrice)) {
+func (s marketStreams) SubscribeMarkPrice(ctx context.Context, symbol string) (*common.Subscription[*MarkPrice], error) {
 	stream := fmt.Sprintf("%s@markPrice", strings.ToLower(symbol))
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if markPrice := parseMarkPrice(data); markPrice != nil {
-			handler(markPrice)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseMarkPrice)
 }
 
 // Subscribe to all symbols mark price stream
-func (c *WebSocketClient) SubscribeAllMarkPrices(handler func([]MarkPrice)) {
+func (s marketStreams) SubscribeAllMarkPrices(ctx context.Context) (*common.Subscription[[]MarkPrice], error) {
 	stream := "!markPrice@arr"
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if markPrices := parseAllMarkPrices(data); markPrices != nil {
-			handler(markPrices)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseAllMarkPrices)
 }
 
 // Subscribe to funding rate streams
-func (c *WebSocketClient) SubscribeFundingRate(symbol string, handler func(*FundingRate)) {
+func (s marketStreams) SubscribeFundingRate(ctx context.Context, symbol string) (*common.Subscription[*FundingRate], error) {
 	stream := fmt.Sprintf("%s@markPrice", strings.ToLower(symbol))
-	c.Subscribe(stream, func(data json.RawMessage) {
-		if fundingRate := parseFundingRate(data); fundingRate != nil {
-			handler(fundingRate)
-		}
-	})
+	return common.NewSubscription(ctx, s.sub, stream, parseFundingRate)
 }
 
 // MiniTicker represents a mini ticker
@@ -189,343 +177,214 @@ type MiniTicker struct {
 	CloseTime int64           `json:"C"`
 }
 
-// Parse functions for WebSocket data
+// Parse functions for WebSocket data. Each returns the parsed event along
+// with a common.FieldsError when its fields mismatch in strict mode.
 
-func parseTicker24hr(data json.RawMessage) *Ticker24hr {
-	var rawData map[string]any
-	if err := json.Unmarshal(data, &rawData); err != nil {
-		return nil
+func parseTicker24hr(data json.RawMessage, strict bool) (*Ticker24hr, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
 	}
-
-	ticker := &Ticker24hr{}
+	f.Optional("x", "b", "B", "a", "A", "baseAsset", "quoteAsset")
 
 	// Parse WebSocket format (single letter fields)
-	if symbol, ok := rawData["s"].(string); ok {
-		ticker.Symbol = symbol
-	}
-	if priceChange, ok := rawData["P"].(string); ok {
-		ticker.PriceChange, _ = decimal.NewFromString(priceChange)
-	}
-	if priceChangePercent, ok := rawData["p"].(string); ok {
-		ticker.PriceChangePercent, _ = decimal.NewFromString(priceChangePercent)
-	}
-	if weightedAvgPrice, ok := rawData["w"].(string); ok {
-		ticker.WeightedAvgPrice, _ = decimal.NewFromString(weightedAvgPrice)
-	}
-	if prevClosePrice, ok := rawData["x"].(string); ok {
-		ticker.PrevClosePrice, _ = decimal.NewFromString(prevClosePrice)
-	}
-	if lastPrice, ok := rawData["c"].(string); ok {
-		ticker.LastPrice, _ = decimal.NewFromString(lastPrice)
-	}
-	if lastQty, ok := rawData["Q"].(string); ok {
-		ticker.LastQty, _ = decimal.NewFromString(lastQty)
-	}
-	if bidPrice, ok := rawData["b"].(string); ok {
-		ticker.BidPrice, _ = decimal.NewFromString(bidPrice)
-	}
-	if bidQty, ok := rawData["B"].(string); ok {
-		ticker.BidQty, _ = decimal.NewFromString(bidQty)
-	}
-	if askPrice, ok := rawData["a"].(string); ok {
-		ticker.AskPrice, _ = decimal.NewFromString(askPrice)
-	}
-	if askQty, ok := rawData["A"].(string); ok {
-		ticker.AskQty, _ = decimal.NewFromString(askQty)
-	}
-	if openPrice, ok := rawData["o"].(string); ok {
-		ticker.OpenPrice, _ = decimal.NewFromString(openPrice)
-	}
-	if highPrice, ok := rawData["h"].(string); ok {
-		ticker.HighPrice, _ = decimal.NewFromString(highPrice)
-	}
-	if lowPrice, ok := rawData["l"].(string); ok {
-		ticker.LowPrice, _ = decimal.NewFromString(lowPrice)
-	}
-	if volume, ok := rawData["v"].(string); ok {
-		ticker.Volume, _ = decimal.NewFromString(volume)
-	}
-	if quoteVolume, ok := rawData["q"].(string); ok {
-		ticker.QuoteVolume, _ = decimal.NewFromString(quoteVolume)
-	}
-	if openTime, ok := rawData["O"].(float64); ok {
-		ticker.OpenTime = int64(openTime)
-	}
-	if closeTime, ok := rawData["C"].(float64); ok {
-		ticker.CloseTime = int64(closeTime)
-	}
-	if firstID, ok := rawData["F"].(float64); ok {
-		ticker.FirstID = int64(firstID)
-	}
-	if lastID, ok := rawData["L"].(float64); ok {
-		ticker.LastID = int64(lastID)
-	}
-	if count, ok := rawData["n"].(float64); ok {
-		ticker.Count = int64(count)
-	}
-	if baseAsset, ok := rawData["baseAsset"].(string); ok {
-		ticker.BaseAsset = baseAsset
-	}
-	if quoteAsset, ok := rawData["quoteAsset"].(string); ok {
-		ticker.QuoteAsset = quoteAsset
-	}
-
-	return ticker
+	ticker := &Ticker24hr{
+		Symbol:             f.String("s"),
+		PriceChange:        f.Decimal("P"),
+		PriceChangePercent: f.Decimal("p"),
+		WeightedAvgPrice:   f.Decimal("w"),
+		PrevClosePrice:     f.Decimal("x"),
+		LastPrice:          f.Decimal("c"),
+		LastQty:            f.Decimal("Q"),
+		BidPrice:           f.Decimal("b"),
+		BidQty:             f.Decimal("B"),
+		AskPrice:           f.Decimal("a"),
+		AskQty:             f.Decimal("A"),
+		OpenPrice:          f.Decimal("o"),
+		HighPrice:          f.Decimal("h"),
+		LowPrice:           f.Decimal("l"),
+		Volume:             f.Decimal("v"),
+		QuoteVolume:        f.Decimal("q"),
+		OpenTime:           f.Int("O"),
+		CloseTime:          f.Int("C"),
+		FirstID:            f.Int("F"),
+		LastID:             f.Int("L"),
+		Count:              f.Int("n"),
+		BaseAsset:          f.String("baseAsset"),
+		QuoteAsset:         f.String("quoteAsset"),
+	}
+	return ticker, f.Err(strict)
 }
 
-func parseAllTickers(data json.RawMessage) []Ticker24hr {
-	var tickers []Ticker24hr
-	if err := json.Unmarshal(data, &tickers); err != nil {
-		return nil
-	}
-
-	return tickers
+func parseAllTickers(data json.RawMessage, strict bool) ([]Ticker24hr, error) {
+	return common.ParseEvents(data, strict, parseTicker24hr)
 }
 
-func parseMiniTicker(data json.RawMessage) *MiniTicker {
+func parseMiniTicker(data json.RawMessage, strict bool) (*MiniTicker, error) {
 	var ticker MiniTicker
-	if err := json.Unmarshal(data, &ticker); err != nil {
-		return nil
+	err := common.DecodeEvent(data, &ticker, strict)
+	if err != nil && !errors.As(err, &common.FieldsError{}) {
+		return nil, err
 	}
-
-	return &ticker
+	return &ticker, err
 }
 
-func parseAllMiniTickers(data json.RawMessage) []MiniTicker {
-	var tickers []MiniTicker
-	if err := json.Unmarshal(data, &tickers); err != nil {
-		return nil
-	}
-
-	return tickers
+func parseAllMiniTickers(data json.RawMessage, strict bool) ([]MiniTicker, error) {
+	return common.ParseEvents(data, strict, parseMiniTicker)
 }
 
-func parseBookTicker(data json.RawMessage) *BookTicker {
-	var rawData map[string]any
-	if err := json.Unmarshal(data, &rawData); err != nil {
-		return nil
+func parseBookTicker(data json.RawMessage, strict bool) (*BookTicker, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
 	}
-
-	bookTicker := &BookTicker{}
+	f.Optional("u", "T")
 
 	// Parse WebSocket format (single letter fields)
-	if symbol, ok := rawData["s"].(string); ok {
-		bookTicker.Symbol = symbol
-	}
-	if bidPrice, ok := rawData["b"].(string); ok {
-		bookTicker.BidPrice = bidPrice
-	}
-	if bidQty, ok := rawData["B"].(string); ok {
-		bookTicker.BidQty = bidQty
-	}
-	if askPrice, ok := rawData["a"].(string); ok {
-		bookTicker.AskPrice = askPrice
-	}
-	if askQty, ok := rawData["A"].(string); ok {
-		bookTicker.AskQty = askQty
-	}
-	if time, ok := rawData["T"].(float64); ok {
-		bookTicker.Time = int64(time)
-	}
-
-	return bookTicker
+	bookTicker := &BookTicker{
+		Symbol:   f.String("s"),
+		BidPrice: f.String("b"),
+		BidQty:   f.String("B"),
+		AskPrice: f.String("a"),
+		AskQty:   f.String("A"),
+		Time:     f.Int("T"),
+	}
+	return bookTicker, f.Err(strict)
 }
 
-func parseAllBookTickers(data json.RawMessage) []BookTicker {
-	var bookTickers []BookTicker
-	if err := json.Unmarshal(data, &bookTickers); err != nil {
-		return nil
-	}
-
-	return bookTickers
+func parseAllBookTickers(data json.RawMessage, strict bool) ([]BookTicker, error) {
+	return common.ParseEvents(data, strict, parseBookTicker)
 }
 
-func parseTrade(data json.RawMessage) *Trade {
-	var rawData map[string]any
-	if err := json.Unmarshal(data, &rawData); err != nil {
-		return nil
+func parseTrade(data json.RawMessage, strict bool) (*Trade, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
 	}
-
-	trade := &Trade{}
+	f.Optional("s", "b", "a", "M")
 
 	// Parse WebSocket format (single letter fields)
-	if id, ok := rawData["t"].(float64); ok {
-		trade.ID = int64(id)
-	}
-	if price, ok := rawData["p"].(string); ok {
-		trade.Price, _ = decimal.NewFromString(price)
-	}
-	if qty, ok := rawData["q"].(string); ok {
-		trade.Qty, _ = decimal.NewFromString(qty)
-	}
-	if baseQty, ok := rawData["b"].(string); ok {
-		trade.BaseQty, _ = decimal.NewFromString(baseQty)
-	}
-	if time, ok := rawData["T"].(float64); ok {
-		trade.Time = int64(time)
-	}
-	if isBuyerMaker, ok := rawData["m"].(bool); ok {
-		trade.IsBuyerMaker = isBuyerMaker
-	}
-
-	return trade
+	trade := &Trade{
+		ID:           f.Int("t"),
+		Price:        f.Decimal("p"),
+		Qty:          f.Decimal("q"),
+		BaseQty:      f.Decimal("b"),
+		Time:         f.Int("T"),
+		IsBuyerMaker: f.Bool("m"),
+	}
+	return trade, f.Err(strict)
 }
 
-func parseAggTrade(data json.RawMessage) *AggTrade {
-	var rawData map[string]any
-	if err := json.Unmarshal(data, &rawData); err != nil {
-		return nil
+func parseAggTrade(data json.RawMessage, strict bool) (*AggTrade, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
 	}
-
-	aggTrade := &AggTrade{}
+	f.Optional("s", "M")
 
 	// Parse WebSocket format (single letter fields)
-	if aggregateTradeID, ok := rawData["a"].(float64); ok {
-		aggTrade.AggregateTradeID = int64(aggregateTradeID)
-	}
-	if price, ok := rawData["p"].(string); ok {
-		aggTrade.Price, _ = decimal.NewFromString(price)
-	}
-	if quantity, ok := rawData["q"].(string); ok {
-		aggTrade.Quantity, _ = decimal.NewFromString(quantity)
-	}
-	if firstTradeID, ok := rawData["f"].(float64); ok {
-		aggTrade.FirstTradeID = int64(firstTradeID)
-	}
-	if lastTradeID, ok := rawData["l"].(float64); ok {
-		aggTrade.LastTradeID = int64(lastTradeID)
-	}
-	if timestamp, ok := rawData["T"].(float64); ok {
-		aggTrade.Timestamp = int64(timestamp)
-	}
-	if isBuyerMaker, ok := rawData["m"].(bool); ok {
-		aggTrade.IsBuyerMaker = isBuyerMaker
-	}
-
-	return aggTrade
+	aggTrade := &AggTrade{
+		AggregateTradeID: f.Int("a"),
+		Price:            f.Decimal("p"),
+		Quantity:         f.Decimal("q"),
+		FirstTradeID:     f.Int("f"),
+		LastTradeID:      f.Int("l"),
+		Timestamp:        f.Int("T"),
+		IsBuyerMaker:     f.Bool("m"),
+	}
+	return aggTrade, f.Err(strict)
 }
 
-func parseKline(data json.RawMessage) *Kline {
-	var rawData map[string]any
-	if err := json.Unmarshal(data, &rawData); err != nil {
-		return nil
-	}
-
-	kline := &Kline{}
-
-	// Parse WebSocket format (single letter fields)
-	if openTime, ok := rawData["t"].(float64); ok {
-		kline.OpenTime = int64(openTime)
-	}
-	if open, ok := rawData["o"].(string); ok {
-		kline.Open, _ = decimal.NewFromString(open)
-	}
-	if high, ok := rawData["h"].(string); ok {
-		kline.High, _ = decimal.NewFromString(high)
-	}
-	if low, ok := rawData["l"].(string); ok {
-		kline.Low, _ = decimal.NewFromString(low)
-	}
-	if close, ok := rawData["c"].(string); ok {
-		kline.Close, _ = decimal.NewFromString(close)
-	}
-	if volume, ok := rawData["v"].(string); ok {
-		kline.Volume, _ = decimal.NewFromString(volume)
-	}
-	if closeTime, ok := rawData["T"].(float64); ok {
-		kline.CloseTime = int64(closeTime)
-	}
-	if quoteAssetVolume, ok := rawData["q"].(string); ok {
-		kline.QuoteAssetVolume, _ = decimal.NewFromString(quoteAssetVolume)
-	}
-	if numberOfTrades, ok := rawData["n"].(float64); ok {
-		kline.NumberOfTrades = int(numberOfTrades)
-	}
-	if takerBuyBaseAssetVolume, ok := rawData["V"].(string); ok {
-		kline.TakerBuyBaseAssetVolume, _ = decimal.NewFromString(takerBuyBaseAssetVolume)
-	}
-	if takerBuyQuoteAssetVolume, ok := rawData["Q"].(string); ok {
-		kline.TakerBuyQuoteAssetVolume, _ = decimal.NewFromString(takerBuyQuoteAssetVolume)
-	}
-
-	return kline
+func parseKline(data json.RawMessage, strict bool) (*Kline, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Kline events carry the candle under "k", which is read flat otherwise
+	k := f
+	if f.Has("k") {
+		f.Optional("s")
+		k = f.Object("k")
+		k.Optional("s", "i", "f", "L", "x", "B")
+	}
+
+	kline := &Kline{
+		OpenTime:                 k.Int("t"),
+		Open:                     k.Decimal("o"),
+		High:                     k.Decimal("h"),
+		Low:                      k.Decimal("l"),
+		Close:                    k.Decimal("c"),
+		Volume:                   k.Decimal("v"),
+		CloseTime:                k.Int("T"),
+		QuoteAssetVolume:         k.Decimal("q"),
+		NumberOfTrades:           int(k.Int("n")),
+		TakerBuyBaseAssetVolume:  k.Decimal("V"),
+		TakerBuyQuoteAssetVolume: k.Decimal("Q"),
+	}
+	return kline, f.Err(strict)
 }
 
-func parseDepth(data json.RawMessage) *OrderBook {
-	var rawData map[string]any
-	if err := json.Unmarshal(data, &rawData); err != nil {
-		return nil
+func parseDepth(data json.RawMessage, strict bool) (*OrderBook, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
 	}
+	f.Optional("E", "T", "s", "U", "pu")
 
-	depth := &OrderBook{}
-
-	// Parse WebSocket format (mixed field names)
-	if lastUpdateID, ok := rawData["lastUpdateId"].(float64); ok {
-		depth.LastUpdateID = int64(lastUpdateID)
-	}
-	if messageTime, ok := rawData["E"].(float64); ok {
-		depth.MessageTime = int64(messageTime)
-	}
-	if transactionTime, ok := rawData["T"].(float64); ok {
-		depth.TransactionTime = int64(transactionTime)
-	}
-	if bids, ok := rawData["bids"].([]any); ok {
-		for _, bid := range bids {
-			if bidSlice, ok := bid.([]any); ok && len(bidSlice) >= 2 {
-				bidStr := []string{}
-				for _, item := range bidSlice {
-					if str, ok := item.(string); ok {
-						bidStr = append(bidStr, str)
-					}
-				}
-				if len(bidStr) >= 2 {
-					depth.Bids = append(depth.Bids, bidStr)
-				}
-			}
-		}
+	depth := &OrderBook{
+		MessageTime:     f.Int("E"),
+		TransactionTime: f.Int("T"),
 	}
-	if asks, ok := rawData["asks"].([]any); ok {
-		for _, ask := range asks {
-			if askSlice, ok := ask.([]any); ok && len(askSlice) >= 2 {
-				askStr := []string{}
-				for _, item := range askSlice {
-					if str, ok := item.(string); ok {
-						askStr = append(askStr, str)
-					}
-				}
-				if len(askStr) >= 2 {
-					depth.Asks = append(depth.Asks, askStr)
-				}
-			}
-		}
+	if f.Has("u") {
+		// Diff depth events
+		depth.LastUpdateID = f.Int("u")
+		f.Decode("b", &depth.Bids)
+		f.Decode("a", &depth.Asks)
+	} else {
+		depth.LastUpdateID = f.Int("lastUpdateId")
+		f.Decode("bids", &depth.Bids)
+		f.Decode("asks", &depth.Asks)
 	}
-
-	return depth
+	return depth, f.Err(strict)
 }
 
-func parseMarkPrice(data json.RawMessage) *MarkPrice {
-	var markPrice MarkPrice
-	if err := json.Unmarshal(data, &markPrice); err != nil {
-		return nil
+func parseMarkPrice(data json.RawMessage, strict bool) (*MarkPrice, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
 	}
+	f.Optional("i", "P")
 
-	return &markPrice
+	// Parse WebSocket format (single letter fields)
+	markPrice := &MarkPrice{
+		Symbol:               f.String("s"),
+		MarkPrice:            f.Decimal("p"),
+		IndexPrice:           f.Decimal("i"),
+		EstimatedSettlePrice: f.Decimal("P"),
+		LastFundingRate:      f.Decimal("r"),
+		NextFundingTime:      f.Int("T"),
+		Time:                 f.Int("E"),
+	}
+	return markPrice, f.Err(strict)
 }
 
-func parseAllMarkPrices(data json.RawMessage) []MarkPrice {
-	var markPrices []MarkPrice
-	if err := json.Unmarshal(data, &markPrices); err != nil {
-		return nil
-	}
-
-	return markPrices
+func parseAllMarkPrices(data json.RawMessage, strict bool) ([]MarkPrice, error) {
+	return common.ParseEvents(data, strict, parseMarkPrice)
 }
 
-func parseFundingRate(data json.RawMessage) *FundingRate {
-	var fundingRate FundingRate
-	if err := json.Unmarshal(data, &fundingRate); err != nil {
-		return nil
+func parseFundingRate(data json.RawMessage, strict bool) (*FundingRate, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
 	}
+	// Funding rates are read from mark price events
+	f.Optional("p", "i", "P")
 
-	return &fundingRate
+	fundingRate := &FundingRate{
+		Symbol:      f.String("s"),
+		FundingRate: f.Decimal("r"),
+		FundingTime: f.Int("T"),
+	}
+	return fundingRate, f.Err(strict)
 }
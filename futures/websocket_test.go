@@ -1,12 +1,49 @@
 package futures
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/shopspring/decimal"
+	"github.com/yiplee/aster-go/common"
 )
 
+func TestWebSocketClientState(t *testing.T) {
+	client := NewWebSocketClient(false)
+	client.SetOnConnect(func() {})
+	client.SetOnDisconnect(func(err error) {})
+	client.SetOnReconnect(func(attempt int) {})
+	client.SetOnError(func(err error) {})
+
+	if state := client.State(); state != common.StateDisconnected {
+		t.Errorf("Expected disconnected state initially, got %s", state)
+	}
+	if client.StateChanges() == nil {
+		t.Error("Expected a state change channel")
+	}
+}
+
+func TestWebSocketPool(t *testing.T) {
+	pool := NewWebSocketPool(false, 1)
+
+	// Streams subscribed before connecting are spread without a round trip
+	if _, err := pool.SubscribeTicker(context.Background(), "BTCUSDT"); err != nil {
+		t.Errorf("SubscribeTicker failed: %v", err)
+	}
+	if _, err := pool.SubscribeTrade(context.Background(), "BTCUSDT"); err != nil {
+		t.Errorf("SubscribeTrade failed: %v", err)
+	}
+
+	if n := len(pool.Clients()); n != 2 {
+		t.Errorf("Expected 2 connections, got %d", n)
+	}
+	if streams := pool.Subscriptions(); len(streams) != 2 || streams[0] != "btcusdt@ticker" || streams[1] != "btcusdt@trade" {
+		t.Errorf("Unexpected subscriptions: %v", streams)
+	}
+}
+
 func TestParseTicker24hr(t *testing.T) {
 	// Test with valid data (using WebSocket single letter field names)
 	validData := map[string]any{
@@ -18,7 +55,10 @@ func TestParseTicker24hr(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(validData)
-	ticker := parseTicker24hr(jsonData)
+	ticker, err := parseTicker24hr(jsonData, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 	if ticker == nil {
 		t.Error("Expected ticker to not be nil")
 	}
@@ -45,7 +85,10 @@ func TestParseBookTicker(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(validData)
-	bookTicker := parseBookTicker(jsonData)
+	bookTicker, err := parseBookTicker(jsonData, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 	if bookTicker == nil {
 		t.Error("Expected book ticker to not be nil")
 	}
@@ -71,7 +114,10 @@ func TestParseTrade(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(validData)
-	trade := parseTrade(jsonData)
+	trade, err := parseTrade(jsonData, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 	if trade == nil {
 		t.Error("Expected trade to not be nil")
 	}
@@ -99,7 +145,10 @@ func TestParseAggTrade(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(validData)
-	aggTrade := parseAggTrade(jsonData)
+	aggTrade, err := parseAggTrade(jsonData, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 	if aggTrade == nil {
 		t.Error("Expected agg trade to not be nil")
 	}
@@ -131,7 +180,10 @@ func TestParseKline(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(validData)
-	kline := parseKline(jsonData)
+	kline, err := parseKline(jsonData, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 	if kline == nil {
 		t.Error("Expected kline to not be nil")
 	}
@@ -145,3 +197,44 @@ func TestParseKline(t *testing.T) {
 		t.Errorf("Expected open %s, got %s", expectedOpen.String(), kline.Open.String())
 	}
 }
+
+func TestParseKlineEvent(t *testing.T) {
+	// Kline events nest the candle under "k"
+	data := json.RawMessage(`{"e":"kline","E":1,"s":"BTCUSDT","k":{"t":1640995200000,"T":1640995260000,"s":"BTCUSDT","i":"1m","o":"40000.00","h":"41000.00","l":"39000.00","c":"40500.00","v":"1000.00","n":100,"x":false,"q":"40000000.00","V":"500.00","Q":"20000000.00","B":"0"}}`)
+	kline, err := parseKline(data, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if kline.OpenTime != 1640995200000 || kline.NumberOfTrades != 100 {
+		t.Errorf("Unexpected kline: %+v", kline)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	// Fields of the wrong type fail to parse
+	if _, err := parseTrade(json.RawMessage(`{"t":"abc","p":"1"}`), false); err == nil {
+		t.Error("Expected an error for a malformed trade")
+	}
+
+	// In strict mode, missing and unknown fields are reported along with
+	// the parsed event
+	trade, err := parseTrade(json.RawMessage(`{"t":1,"p":"1","q":"2","T":3,"m":true,"z":0}`), true)
+	var fieldsErr common.FieldsError
+	if !errors.As(err, &fieldsErr) {
+		t.Fatalf("Expected a FieldsError, got %v", err)
+	}
+	if fieldsErr.Error() != "unknown fields z" {
+		t.Errorf("Unexpected error: %v", fieldsErr)
+	}
+	if trade == nil || trade.ID != 1 {
+		t.Errorf("Expected trade 1, got %+v", trade)
+	}
+
+	tickers, err := parseAllTickers(json.RawMessage(`[{"s":"BTCUSDT","c":"1"},{"s":"ETHUSDT","c":"2"}]`), true)
+	if !errors.As(err, &fieldsErr) || len(fieldsErr.Missing) == 0 {
+		t.Errorf("Expected missing fields, got %v", err)
+	}
+	if len(tickers) != 2 || tickers[1].Symbol != "ETHUSDT" {
+		t.Errorf("Unexpected tickers: %+v", tickers)
+	}
+}
@@ -0,0 +1,96 @@
+package futures
+
+import "github.com/yiplee/aster-go/common"
+
+// endpointWeights lists the rate limit cost of every futures REST endpoint
+var endpointWeights = map[string]common.EndpointWeight{
+	"GET /fapi/v3/ping":                   {Weight: 1},
+	"GET /fapi/v3/time":                   {Weight: 1},
+	"GET /fapi/v3/exchangeInfo":           {Weight: 1},
+	"GET /fapi/v3/depth":                  {WeightFunc: depthWeight},
+	"GET /fapi/v3/trades":                 {Weight: 1},
+	"GET /fapi/v3/historicalTrades":       {Weight: 20},
+	"GET /fapi/v3/aggTrades":              {Weight: 20},
+	"GET /fapi/v3/klines":                 {WeightFunc: klinesWeight},
+	"GET /fapi/v3/indexPriceKlines":       {WeightFunc: klinesWeight},
+	"GET /fapi/v3/markPriceKlines":        {WeightFunc: klinesWeight},
+	"GET /fapi/v3/premiumIndex":           {Weight: 1},
+	"GET /fapi/v3/fundingRate":            {Weight: 1},
+	"GET /fapi/v3/fundingRateConfig":      {Weight: 1},
+	"GET /fapi/v3/ticker/24hr":            {WeightFunc: symbolWeight(1, 40)},
+	"GET /fapi/v3/ticker/price":           {WeightFunc: symbolWeight(1, 2)},
+	"GET /fapi/v3/ticker/bookTicker":      {WeightFunc: symbolWeight(1, 2)},
+	"POST /fapi/v3/positionSide/dual":     {Weight: 1},
+	"GET /fapi/v3/positionSide/dual":      {Weight: 30},
+	"POST /fapi/v3/multiAssetsMargin":     {Weight: 1},
+	"GET /fapi/v3/multiAssetsMargin":      {Weight: 30},
+	"POST /fapi/v3/order":                 {Weight: 1, Orders: 1},
+	"POST /fapi/v3/batchOrders":           {Weight: 5, Orders: 5},
+	"POST /fapi/v3/transfer":              {Weight: 5},
+	"GET /fapi/v3/order":                  {Weight: 1},
+	"DELETE /fapi/v3/order":               {Weight: 1},
+	"DELETE /fapi/v3/allOpenOrders":       {Weight: 1},
+	"DELETE /fapi/v3/batchOrders":         {Weight: 1},
+	"POST /fapi/v3/countdownCancelAll":    {Weight: 10},
+	"GET /fapi/v3/openOrders":             {WeightFunc: symbolWeight(1, 40)},
+	"GET /fapi/v3/allOrders":              {Weight: 5},
+	"GET /fapi/v3/account":                {Weight: 5},
+	"GET /fapi/v3/balance":                {Weight: 5},
+	"POST /fapi/v3/leverage":              {Weight: 1},
+	"POST /fapi/v3/marginType":            {Weight: 1},
+	"POST /fapi/v3/positionMargin":        {Weight: 1},
+	"GET /fapi/v3/positionMargin/history": {Weight: 1},
+	"GET /fapi/v3/positionRisk":           {Weight: 5},
+	"GET /fapi/v3/userTrades":             {Weight: 5},
+	"GET /fapi/v3/income":                 {Weight: 30},
+	"GET /fapi/v3/leverageBracket":        {Weight: 1},
+	"GET /fapi/v3/adlQuantile":            {Weight: 5},
+	"GET /fapi/v3/forceOrders":            {WeightFunc: symbolWeight(20, 50)},
+	"GET /fapi/v3/commissionRate":         {Weight: 20},
+	"POST /fapi/v3/listenKey":             {Weight: 1},
+	"PUT /fapi/v3/listenKey":              {Weight: 1},
+	"DELETE /fapi/v3/listenKey":           {Weight: 1},
+}
+
+// depthWeight returns the weight of an order book request for its limit
+func depthWeight(params map[string]any) int {
+	limit, _ := params["limit"].(int)
+	switch {
+	case limit == 0 || limit > 50 && limit <= 100:
+		return 5
+	case limit <= 50:
+		return 2
+	case limit <= 500:
+		return 10
+	default:
+		return 20
+	}
+}
+
+// klinesWeight returns the weight of a klines request for its limit
+func klinesWeight(params map[string]any) int {
+	limit, _ := params["limit"].(int)
+	switch {
+	case limit == 0:
+		return 2
+	case limit < 100:
+		return 1
+	case limit < 500:
+		return 2
+	case limit <= 1000:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// symbolWeight returns a weight function for endpoints that are cheaper
+// when restricted to a single symbol
+func symbolWeight(withSymbol, withoutSymbol int) func(map[string]any) int {
+	return func(params map[string]any) int {
+		if symbol, _ := params["symbol"].(string); symbol != "" {
+			return withSymbol
+		}
+		return withoutSymbol
+	}
+}
@@ -3,6 +3,10 @@ module github.com/yiplee/aster-go
 go 1.24.2
 
 require (
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.1
 	github.com/gorilla/websocket v1.5.3
 	github.com/shopspring/decimal v1.4.0
+	golang.org/x/crypto v0.40.0
 )
+
+require golang.org/x/sys v0.34.0 // indirect
@@ -1,6 +1,7 @@
 package spot
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/shopspring/decimal"
@@ -19,9 +20,12 @@ func NewClient(config *common.ClientConfig) *Client {
 		config.BaseURL = "https://sapi.asterdex.com"
 	}
 
-	return &Client{
+	client := &Client{
 		Client: common.NewClient(config),
 	}
+	client.SetEndpointWeights(endpointWeights)
+	client.SetServerTimeEndpoint("/api/v1/time")
+	return client
 }
 
 // SetTestnet sets the client to use testnet
@@ -37,27 +41,50 @@ func (c *Client) SetTestnet(testnet bool) {
 
 // Ping tests connectivity to the REST API
 func (c *Client) Ping() error {
-	return c.Do("GET", "/api/v1/ping", nil, nil, false)
+	return c.PingCtx(context.Background())
+}
+
+// PingCtx is like Ping but carries ctx for cancellation and deadlines
+func (c *Client) PingCtx(ctx context.Context) error {
+	return c.DoCtx(ctx, "GET", "/api/v1/ping", nil, nil, false)
 }
 
 // GetServerTime gets the server time
 func (c *Client) GetServerTime() (int64, error) {
+	return c.GetServerTimeCtx(context.Background())
+}
+
+// GetServerTimeCtx is like GetServerTime but carries ctx for cancellation and deadlines
+func (c *Client) GetServerTimeCtx(ctx context.Context) (int64, error) {
 	var result struct {
 		ServerTime int64 `json:"serverTime"`
 	}
-	err := c.Do("GET", "/api/v1/time", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/time", nil, &result, false)
 	return result.ServerTime, err
 }
 
 // GetExchangeInfo gets exchange trading rules and symbol information
 func (c *Client) GetExchangeInfo() (*ExchangeInfo, error) {
+	return c.GetExchangeInfoCtx(context.Background())
+}
+
+// GetExchangeInfoCtx is like GetExchangeInfo but carries ctx for cancellation and deadlines
+func (c *Client) GetExchangeInfoCtx(ctx context.Context) (*ExchangeInfo, error) {
 	var result ExchangeInfo
-	err := c.Do("GET", "/api/v1/exchangeInfo", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/exchangeInfo", nil, &result, false)
+	if err == nil {
+		c.SetRateLimits(result.RateLimits)
+	}
 	return &result, err
 }
 
 // GetOrderBook gets the order book for a symbol
 func (c *Client) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	return c.GetOrderBookCtx(context.Background(), symbol, limit)
+}
+
+// GetOrderBookCtx is like GetOrderBook but carries ctx for cancellation and deadlines
+func (c *Client) GetOrderBookCtx(ctx context.Context, symbol string, limit int) (*OrderBook, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -70,12 +97,17 @@ func (c *Client) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
 	}
 
 	var result OrderBook
-	err := c.Do("GET", "/api/v1/depth", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/depth", params, &result, false)
 	return &result, err
 }
 
 // GetRecentTrades gets recent trades for a symbol
 func (c *Client) GetRecentTrades(symbol string, limit int) ([]Trade, error) {
+	return c.GetRecentTradesCtx(context.Background(), symbol, limit)
+}
+
+// GetRecentTradesCtx is like GetRecentTrades but carries ctx for cancellation and deadlines
+func (c *Client) GetRecentTradesCtx(ctx context.Context, symbol string, limit int) ([]Trade, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -88,75 +120,139 @@ func (c *Client) GetRecentTrades(symbol string, limit int) ([]Trade, error) {
 	}
 
 	var result []Trade
-	err := c.Do("GET", "/api/v1/trades", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/trades", params, &result, false)
 	return result, err
 }
 
 // GetHistoricalTrades gets historical trades for a symbol
 func (c *Client) GetHistoricalTrades(symbol string, limit int, fromID int64) ([]Trade, error) {
+	return c.GetHistoricalTradesCtx(context.Background(), symbol, limit, fromID)
+}
+
+// GetHistoricalTradesCtx is like GetHistoricalTrades but carries ctx for cancellation and deadlines
+func (c *Client) GetHistoricalTradesCtx(ctx context.Context, symbol string, limit int, fromID int64) ([]Trade, error) {
+	return c.QueryHistoricalTradesCtx(ctx, &HistoricalTradesRequest{
+		Symbol: symbol,
+		Limit:  common.Optional(limit),
+		FromID: common.Optional(fromID),
+	})
+}
+
+// QueryHistoricalTrades is like GetHistoricalTrades but takes its parameters as a request
+func (c *Client) QueryHistoricalTrades(req *HistoricalTradesRequest) ([]Trade, error) {
+	return c.QueryHistoricalTradesCtx(context.Background(), req)
+}
+
+// QueryHistoricalTradesCtx is like QueryHistoricalTrades but carries ctx for cancellation and deadlines
+func (c *Client) QueryHistoricalTradesCtx(ctx context.Context, req *HistoricalTradesRequest) ([]Trade, error) {
 	params := map[string]any{
-		"symbol": symbol,
+		"symbol": req.Symbol,
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
 	}
-	if fromID > 0 {
-		params["fromId"] = fromID
+	if req.FromID != nil {
+		params["fromId"] = *req.FromID
+	}
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result []Trade
-	err := c.Do("GET", "/api/v1/historicalTrades", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/api/v1/historicalTrades", params, &result, true)
 	return result, err
 }
 
 // GetAggTrades gets compressed/aggregate trades for a symbol
 func (c *Client) GetAggTrades(symbol string, fromID, startTime, endTime int64, limit int) ([]AggTrade, error) {
+	return c.GetAggTradesCtx(context.Background(), symbol, fromID, startTime, endTime, limit)
+}
+
+// GetAggTradesCtx is like GetAggTrades but carries ctx for cancellation and deadlines
+func (c *Client) GetAggTradesCtx(ctx context.Context, symbol string, fromID, startTime, endTime int64, limit int) ([]AggTrade, error) {
+	return c.QueryAggTradesCtx(ctx, &AggTradesRequest{
+		Symbol:    symbol,
+		FromID:    common.Optional(fromID),
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryAggTrades is like GetAggTrades but takes its parameters as a request
+func (c *Client) QueryAggTrades(req *AggTradesRequest) ([]AggTrade, error) {
+	return c.QueryAggTradesCtx(context.Background(), req)
+}
+
+// QueryAggTradesCtx is like QueryAggTrades but carries ctx for cancellation and deadlines
+func (c *Client) QueryAggTradesCtx(ctx context.Context, req *AggTradesRequest) ([]AggTrade, error) {
 	params := map[string]any{
-		"symbol": symbol,
+		"symbol": req.Symbol,
 	}
-	if fromID > 0 {
-		params["fromId"] = fromID
+	if req.FromID != nil {
+		params["fromId"] = *req.FromID
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
 	}
 
 	var result []AggTrade
-	err := c.Do("GET", "/api/v1/aggTrades", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/aggTrades", params, &result, false)
 	return result, err
 }
 
 // GetKlines gets kline/candlestick data for a symbol
 func (c *Client) GetKlines(symbol string, interval KlineInterval, startTime, endTime int64, limit int) ([]Kline, error) {
+	return c.GetKlinesCtx(context.Background(), symbol, interval, startTime, endTime, limit)
+}
+
+// GetKlinesCtx is like GetKlines but carries ctx for cancellation and deadlines
+func (c *Client) GetKlinesCtx(ctx context.Context, symbol string, interval KlineInterval, startTime, endTime int64, limit int) ([]Kline, error) {
+	return c.QueryKlinesCtx(ctx, &KlinesRequest{
+		Symbol:    symbol,
+		Interval:  interval,
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryKlines is like GetKlines but takes its parameters as a request
+func (c *Client) QueryKlines(req *KlinesRequest) ([]Kline, error) {
+	return c.QueryKlinesCtx(context.Background(), req)
+}
+
+// QueryKlinesCtx is like QueryKlines but carries ctx for cancellation and deadlines
+func (c *Client) QueryKlinesCtx(ctx context.Context, req *KlinesRequest) ([]Kline, error) {
 	params := map[string]any{
-		"symbol":   symbol,
-		"interval": interval,
+		"symbol":   req.Symbol,
+		"interval": req.Interval,
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
 	}
 
 	var result [][]any
-	err := c.Do("GET", "/api/v1/klines", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/klines", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
 
-	klines := make([]Kline, len(result))
-	for i, k := range result {
-		if len(k) < 12 {
+	klines := make([]Kline, 0, len(result))
+	for _, k := range result {
+		if len(k) < 11 {
 			continue
 		}
 
@@ -193,7 +289,7 @@ func (c *Client) GetKlines(symbol string, interval KlineInterval, startTime, end
 			takerBuyQuoteAssetVolume = decimal.Zero
 		}
 
-		klines[i] = Kline{
+		klines = append(klines, Kline{
 			OpenTime:                 int64(k[0].(float64)),
 			Open:                     open,
 			High:                     high,
@@ -205,7 +301,7 @@ func (c *Client) GetKlines(symbol string, interval KlineInterval, startTime, end
 			NumberOfTrades:           int(k[8].(float64)),
 			TakerBuyBaseAssetVolume:  takerBuyBaseAssetVolume,
 			TakerBuyQuoteAssetVolume: takerBuyQuoteAssetVolume,
-		}
+		})
 	}
 
 	return klines, nil
@@ -213,6 +309,11 @@ func (c *Client) GetKlines(symbol string, interval KlineInterval, startTime, end
 
 // GetTicker24hr gets 24hr ticker price change statistics
 func (c *Client) GetTicker24hr(symbol string) (*Ticker24hr, error) {
+	return c.GetTicker24hrCtx(context.Background(), symbol)
+}
+
+// GetTicker24hrCtx is like GetTicker24hr but carries ctx for cancellation and deadlines
+func (c *Client) GetTicker24hrCtx(ctx context.Context, symbol string) (*Ticker24hr, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -222,7 +323,7 @@ func (c *Client) GetTicker24hr(symbol string) (*Ticker24hr, error) {
 	}
 
 	var result Ticker24hr
-	err := c.Do("GET", "/api/v1/ticker/24hr", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/ticker/24hr", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
@@ -232,13 +333,23 @@ func (c *Client) GetTicker24hr(symbol string) (*Ticker24hr, error) {
 
 // GetAllTickers24hr gets 24hr ticker price change statistics for all symbols
 func (c *Client) GetAllTickers24hr() ([]Ticker24hr, error) {
+	return c.GetAllTickers24hrCtx(context.Background())
+}
+
+// GetAllTickers24hrCtx is like GetAllTickers24hr but carries ctx for cancellation and deadlines
+func (c *Client) GetAllTickers24hrCtx(ctx context.Context) ([]Ticker24hr, error) {
 	var result []Ticker24hr
-	err := c.Do("GET", "/api/v1/ticker/24hr", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/ticker/24hr", nil, &result, false)
 	return result, err
 }
 
 // GetPrice gets the latest price for a symbol
 func (c *Client) GetPrice(symbol string) (*PriceTicker, error) {
+	return c.GetPriceCtx(context.Background(), symbol)
+}
+
+// GetPriceCtx is like GetPrice but carries ctx for cancellation and deadlines
+func (c *Client) GetPriceCtx(ctx context.Context, symbol string) (*PriceTicker, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -248,7 +359,7 @@ func (c *Client) GetPrice(symbol string) (*PriceTicker, error) {
 	}
 
 	var result PriceTicker
-	err := c.Do("GET", "/api/v1/ticker/price", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/ticker/price", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
@@ -258,13 +369,23 @@ func (c *Client) GetPrice(symbol string) (*PriceTicker, error) {
 
 // GetAllPrices gets the latest price for all symbols
 func (c *Client) GetAllPrices() ([]PriceTicker, error) {
+	return c.GetAllPricesCtx(context.Background())
+}
+
+// GetAllPricesCtx is like GetAllPrices but carries ctx for cancellation and deadlines
+func (c *Client) GetAllPricesCtx(ctx context.Context) ([]PriceTicker, error) {
 	var result []PriceTicker
-	err := c.Do("GET", "/api/v1/ticker/price", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/ticker/price", nil, &result, false)
 	return result, err
 }
 
 // GetBookTicker gets the best bid/ask for a symbol
 func (c *Client) GetBookTicker(symbol string) (*BookTicker, error) {
+	return c.GetBookTickerCtx(context.Background(), symbol)
+}
+
+// GetBookTickerCtx is like GetBookTicker but carries ctx for cancellation and deadlines
+func (c *Client) GetBookTickerCtx(ctx context.Context, symbol string) (*BookTicker, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
@@ -274,7 +395,7 @@ func (c *Client) GetBookTicker(symbol string) (*BookTicker, error) {
 	}
 
 	var result BookTicker
-	err := c.Do("GET", "/api/v1/ticker/bookTicker", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/ticker/bookTicker", params, &result, false)
 	if err != nil {
 		return nil, err
 	}
@@ -284,19 +405,29 @@ func (c *Client) GetBookTicker(symbol string) (*BookTicker, error) {
 
 // GetAllBookTickers gets the best bid/ask for all symbols
 func (c *Client) GetAllBookTickers() ([]BookTicker, error) {
+	return c.GetAllBookTickersCtx(context.Background())
+}
+
+// GetAllBookTickersCtx is like GetAllBookTickers but carries ctx for cancellation and deadlines
+func (c *Client) GetAllBookTickersCtx(ctx context.Context) ([]BookTicker, error) {
 	var result []BookTicker
-	err := c.Do("GET", "/api/v1/ticker/bookTicker", nil, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/ticker/bookTicker", nil, &result, false)
 	return result, err
 }
 
 // GetCommissionRate gets the commission rate for a symbol
 func (c *Client) GetCommissionRate(symbol string) (*CommissionRate, error) {
+	return c.GetCommissionRateCtx(context.Background(), symbol)
+}
+
+// GetCommissionRateCtx is like GetCommissionRate but carries ctx for cancellation and deadlines
+func (c *Client) GetCommissionRateCtx(ctx context.Context, symbol string) (*CommissionRate, error) {
 	params := map[string]any{
 		"symbol": symbol,
 	}
 
 	var result CommissionRate
-	err := c.Do("GET", "/api/v1/commissionRate", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/api/v1/commissionRate", params, &result, true)
 	return &result, err
 }
 
@@ -304,6 +435,11 @@ func (c *Client) GetCommissionRate(symbol string) (*CommissionRate, error) {
 
 // NewOrder places a new order
 func (c *Client) NewOrder(req *NewOrderRequest) (*Order, error) {
+	return c.NewOrderCtx(context.Background(), req)
+}
+
+// NewOrderCtx is like NewOrder but carries ctx for cancellation and deadlines
+func (c *Client) NewOrderCtx(ctx context.Context, req *NewOrderRequest) (*Order, error) {
 	params := map[string]any{
 		"symbol": req.Symbol,
 		"side":   req.Side,
@@ -333,12 +469,17 @@ func (c *Client) NewOrder(req *NewOrderRequest) (*Order, error) {
 	}
 
 	var result Order
-	err := c.Do("POST", "/api/v1/order", params, &result, true)
+	err := c.DoCtx(ctx, "POST", "/api/v1/order", params, &result, true)
 	return &result, err
 }
 
 // CancelOrder cancels an order
 func (c *Client) CancelOrder(symbol string, orderID int64, origClientOrderID string) (*Order, error) {
+	return c.CancelOrderCtx(context.Background(), symbol, orderID, origClientOrderID)
+}
+
+// CancelOrderCtx is like CancelOrder but carries ctx for cancellation and deadlines
+func (c *Client) CancelOrderCtx(ctx context.Context, symbol string, orderID int64, origClientOrderID string) (*Order, error) {
 	params := map[string]any{
 		"symbol": symbol,
 	}
@@ -351,61 +492,112 @@ func (c *Client) CancelOrder(symbol string, orderID int64, origClientOrderID str
 	}
 
 	var result Order
-	err := c.Do("DELETE", "/api/v1/order", params, &result, true)
+	err := c.DoCtx(ctx, "DELETE", "/api/v1/order", params, &result, true)
 	return &result, err
 }
 
 // GetOrder gets order information
 func (c *Client) GetOrder(symbol string, orderID int64, origClientOrderID string) (*Order, error) {
+	return c.GetOrderCtx(context.Background(), symbol, orderID, origClientOrderID)
+}
+
+// GetOrderCtx is like GetOrder but carries ctx for cancellation and deadlines
+func (c *Client) GetOrderCtx(ctx context.Context, symbol string, orderID int64, origClientOrderID string) (*Order, error) {
+	return c.QueryOrderCtx(ctx, &QueryOrderRequest{
+		Symbol:            symbol,
+		OrderID:           common.Optional(orderID),
+		OrigClientOrderID: origClientOrderID,
+	})
+}
+
+// QueryOrder is like GetOrder but takes its parameters as a request
+func (c *Client) QueryOrder(req *QueryOrderRequest) (*Order, error) {
+	return c.QueryOrderCtx(context.Background(), req)
+}
+
+// QueryOrderCtx is like QueryOrder but carries ctx for cancellation and deadlines
+func (c *Client) QueryOrderCtx(ctx context.Context, req *QueryOrderRequest) (*Order, error) {
 	params := map[string]any{
-		"symbol": symbol,
+		"symbol": req.Symbol,
 	}
 
-	if orderID > 0 {
-		params["orderId"] = orderID
+	if req.OrderID != nil {
+		params["orderId"] = *req.OrderID
 	}
-	if origClientOrderID != "" {
-		params["origClientOrderId"] = origClientOrderID
+	if req.OrigClientOrderID != "" {
+		params["origClientOrderId"] = req.OrigClientOrderID
+	}
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result Order
-	err := c.Do("GET", "/api/v1/order", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/api/v1/order", params, &result, true)
 	return &result, err
 }
 
 // GetOpenOrders gets all open orders
 func (c *Client) GetOpenOrders(symbol string) ([]Order, error) {
+	return c.GetOpenOrdersCtx(context.Background(), symbol)
+}
+
+// GetOpenOrdersCtx is like GetOpenOrders but carries ctx for cancellation and deadlines
+func (c *Client) GetOpenOrdersCtx(ctx context.Context, symbol string) ([]Order, error) {
 	params := map[string]any{}
 	if symbol != "" {
 		params["symbol"] = symbol
 	}
 
 	var result []Order
-	err := c.Do("GET", "/api/v1/openOrders", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/api/v1/openOrders", params, &result, true)
 	return result, err
 }
 
 // GetAllOrders gets all orders
 func (c *Client) GetAllOrders(symbol string, orderID, startTime, endTime int64, limit int) ([]Order, error) {
+	return c.GetAllOrdersCtx(context.Background(), symbol, orderID, startTime, endTime, limit)
+}
+
+// GetAllOrdersCtx is like GetAllOrders but carries ctx for cancellation and deadlines
+func (c *Client) GetAllOrdersCtx(ctx context.Context, symbol string, orderID, startTime, endTime int64, limit int) ([]Order, error) {
+	return c.QueryAllOrdersCtx(ctx, &AllOrdersRequest{
+		Symbol:    symbol,
+		OrderID:   common.Optional(orderID),
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryAllOrders is like GetAllOrders but takes its parameters as a request
+func (c *Client) QueryAllOrders(req *AllOrdersRequest) ([]Order, error) {
+	return c.QueryAllOrdersCtx(context.Background(), req)
+}
+
+// QueryAllOrdersCtx is like QueryAllOrders but carries ctx for cancellation and deadlines
+func (c *Client) QueryAllOrdersCtx(ctx context.Context, req *AllOrdersRequest) ([]Order, error) {
 	params := map[string]any{
-		"symbol": symbol,
+		"symbol": req.Symbol,
 	}
 
-	if orderID > 0 {
-		params["orderId"] = orderID
+	if req.OrderID != nil {
+		params["orderId"] = *req.OrderID
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
+	}
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result []Order
-	err := c.Do("GET", "/api/v1/allOrders", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/api/v1/allOrders", params, &result, true)
 	return result, err
 }
 
@@ -413,40 +605,75 @@ func (c *Client) GetAllOrders(symbol string, orderID, startTime, endTime int64,
 
 // GetAccount gets account information
 func (c *Client) GetAccount() (*Account, error) {
+	return c.GetAccountCtx(context.Background())
+}
+
+// GetAccountCtx is like GetAccount but carries ctx for cancellation and deadlines
+func (c *Client) GetAccountCtx(ctx context.Context) (*Account, error) {
 	var result Account
-	err := c.Do("GET", "/api/v1/account", nil, &result, true)
+	err := c.DoCtx(ctx, "GET", "/api/v1/account", nil, &result, true)
 	return &result, err
 }
 
 // GetUserTrades gets user trade history
 func (c *Client) GetUserTrades(symbol string, orderID, startTime, endTime, fromID int64, limit int) ([]UserTrade, error) {
+	return c.GetUserTradesCtx(context.Background(), symbol, orderID, startTime, endTime, fromID, limit)
+}
+
+// GetUserTradesCtx is like GetUserTrades but carries ctx for cancellation and deadlines
+func (c *Client) GetUserTradesCtx(ctx context.Context, symbol string, orderID, startTime, endTime, fromID int64, limit int) ([]UserTrade, error) {
+	return c.QueryUserTradesCtx(ctx, &UserTradesRequest{
+		Symbol:    symbol,
+		OrderID:   common.Optional(orderID),
+		StartTime: common.OptionalTime(startTime),
+		EndTime:   common.OptionalTime(endTime),
+		FromID:    common.Optional(fromID),
+		Limit:     common.Optional(limit),
+	})
+}
+
+// QueryUserTrades is like GetUserTrades but takes its parameters as a request
+func (c *Client) QueryUserTrades(req *UserTradesRequest) ([]UserTrade, error) {
+	return c.QueryUserTradesCtx(context.Background(), req)
+}
+
+// QueryUserTradesCtx is like QueryUserTrades but carries ctx for cancellation and deadlines
+func (c *Client) QueryUserTradesCtx(ctx context.Context, req *UserTradesRequest) ([]UserTrade, error) {
 	params := map[string]any{}
-	if symbol != "" {
-		params["symbol"] = symbol
+	if req.Symbol != "" {
+		params["symbol"] = req.Symbol
 	}
-	if orderID > 0 {
-		params["orderId"] = orderID
+	if req.OrderID != nil {
+		params["orderId"] = *req.OrderID
 	}
-	if startTime > 0 {
-		params["startTime"] = startTime
+	if req.StartTime != nil {
+		params["startTime"] = *req.StartTime
 	}
-	if endTime > 0 {
-		params["endTime"] = endTime
+	if req.EndTime != nil {
+		params["endTime"] = *req.EndTime
 	}
-	if fromID > 0 {
-		params["fromId"] = fromID
+	if req.FromID != nil {
+		params["fromId"] = *req.FromID
 	}
-	if limit > 0 {
-		params["limit"] = limit
+	if req.Limit != nil {
+		params["limit"] = *req.Limit
+	}
+	if req.RecvWindow > 0 {
+		params["recvWindow"] = req.RecvWindow
 	}
 
 	var result []UserTrade
-	err := c.Do("GET", "/api/v1/userTrades", params, &result, true)
+	err := c.DoCtx(ctx, "GET", "/api/v1/userTrades", params, &result, true)
 	return result, err
 }
 
 // Transfer between spot and futures
 func (c *Client) Transfer(req *TransferRequest) (*TransferResponse, error) {
+	return c.TransferCtx(context.Background(), req)
+}
+
+// TransferCtx is like Transfer but carries ctx for cancellation and deadlines
+func (c *Client) TransferCtx(ctx context.Context, req *TransferRequest) (*TransferResponse, error) {
 	params := map[string]any{
 		"amount":       req.Amount.String(),
 		"asset":        req.Asset,
@@ -455,24 +682,34 @@ func (c *Client) Transfer(req *TransferRequest) (*TransferResponse, error) {
 	}
 
 	var result TransferResponse
-	err := c.Do("POST", "/api/v1/asset/wallet/transfer", params, &result, true)
+	err := c.DoCtx(ctx, "POST", "/api/v1/asset/wallet/transfer", params, &result, true)
 	return &result, err
 }
 
 // GetWithdrawFee gets withdraw fee estimation
 func (c *Client) GetWithdrawFee(req *WithdrawFeeRequest) (*WithdrawFeeResponse, error) {
+	return c.GetWithdrawFeeCtx(context.Background(), req)
+}
+
+// GetWithdrawFeeCtx is like GetWithdrawFee but carries ctx for cancellation and deadlines
+func (c *Client) GetWithdrawFeeCtx(ctx context.Context, req *WithdrawFeeRequest) (*WithdrawFeeResponse, error) {
 	params := map[string]any{
 		"chainId": req.ChainID,
 		"asset":   req.Asset,
 	}
 
 	var result WithdrawFeeResponse
-	err := c.Do("GET", "/api/v1/aster/withdraw/estimateFee", params, &result, false)
+	err := c.DoCtx(ctx, "GET", "/api/v1/aster/withdraw/estimateFee", params, &result, false)
 	return &result, err
 }
 
 // Withdraw withdraws assets
 func (c *Client) Withdraw(req *WithdrawRequest) (*WithdrawResponse, error) {
+	return c.WithdrawCtx(context.Background(), req)
+}
+
+// WithdrawCtx is like Withdraw but carries ctx for cancellation and deadlines
+func (c *Client) WithdrawCtx(ctx context.Context, req *WithdrawRequest) (*WithdrawResponse, error) {
 	params := map[string]any{
 		"chainId":       req.ChainID,
 		"asset":         req.Asset,
@@ -484,12 +721,17 @@ func (c *Client) Withdraw(req *WithdrawRequest) (*WithdrawResponse, error) {
 	}
 
 	var result WithdrawResponse
-	err := c.Do("POST", "/api/v1/aster/user-withdraw", params, &result, true)
+	err := c.DoCtx(ctx, "POST", "/api/v1/aster/user-withdraw", params, &result, true)
 	return &result, err
 }
 
 // GetNonce gets nonce for API key creation
 func (c *Client) GetNonce(address, userOperationType, network string) (int64, error) {
+	return c.GetNonceCtx(context.Background(), address, userOperationType, network)
+}
+
+// GetNonceCtx is like GetNonce but carries ctx for cancellation and deadlines
+func (c *Client) GetNonceCtx(ctx context.Context, address, userOperationType, network string) (int64, error) {
 	params := map[string]any{
 		"address":           address,
 		"userOperationType": userOperationType,
@@ -499,12 +741,17 @@ func (c *Client) GetNonce(address, userOperationType, network string) (int64, er
 	}
 
 	var result int64
-	err := c.Do("POST", "/api/v1/getNonce", params, &result, false)
+	err := c.DoCtx(ctx, "POST", "/api/v1/getNonce", params, &result, false)
 	return result, err
 }
 
 // CreateAPIKey creates a new API key
 func (c *Client) CreateAPIKey(req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	return c.CreateAPIKeyCtx(context.Background(), req)
+}
+
+// CreateAPIKeyCtx is like CreateAPIKey but carries ctx for cancellation and deadlines
+func (c *Client) CreateAPIKeyCtx(ctx context.Context, req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
 	params := map[string]any{
 		"address":           req.Address,
 		"userOperationType": req.UserOperationType,
@@ -519,7 +766,7 @@ func (c *Client) CreateAPIKey(req *CreateAPIKeyRequest) (*CreateAPIKeyResponse,
 	}
 
 	var result CreateAPIKeyResponse
-	err := c.Do("POST", "/api/v1/createApiKey", params, &result, false)
+	err := c.DoCtx(ctx, "POST", "/api/v1/createApiKey", params, &result, false)
 	return &result, err
 }
 
@@ -527,25 +774,40 @@ func (c *Client) CreateAPIKey(req *CreateAPIKeyRequest) (*CreateAPIKeyResponse,
 
 // CreateListenKey creates a listen key for user data stream
 func (c *Client) CreateListenKey() (*ListenKeyResponse, error) {
+	return c.CreateListenKeyCtx(context.Background())
+}
+
+// CreateListenKeyCtx is like CreateListenKey but carries ctx for cancellation and deadlines
+func (c *Client) CreateListenKeyCtx(ctx context.Context) (*ListenKeyResponse, error) {
 	var result ListenKeyResponse
-	err := c.Do("POST", "/api/v1/listenKey", nil, &result, true)
+	err := c.DoCtx(ctx, "POST", "/api/v1/listenKey", nil, &result, true)
 	return &result, err
 }
 
 // KeepAliveListenKey keeps the listen key alive
 func (c *Client) KeepAliveListenKey(listenKey string) error {
+	return c.KeepAliveListenKeyCtx(context.Background(), listenKey)
+}
+
+// KeepAliveListenKeyCtx is like KeepAliveListenKey but carries ctx for cancellation and deadlines
+func (c *Client) KeepAliveListenKeyCtx(ctx context.Context, listenKey string) error {
 	params := map[string]any{
 		"listenKey": listenKey,
 	}
-	return c.Do("PUT", "/api/v1/listenKey", params, nil, true)
+	return c.DoCtx(ctx, "PUT", "/api/v1/listenKey", params, nil, true)
 }
 
 // CloseListenKey closes the listen key
 func (c *Client) CloseListenKey(listenKey string) error {
+	return c.CloseListenKeyCtx(context.Background(), listenKey)
+}
+
+// CloseListenKeyCtx is like CloseListenKey but carries ctx for cancellation and deadlines
+func (c *Client) CloseListenKeyCtx(ctx context.Context, listenKey string) error {
 	params := map[string]any{
 		"listenKey": listenKey,
 	}
-	return c.Do("DELETE", "/api/v1/listenKey", params, nil, true)
+	return c.DoCtx(ctx, "DELETE", "/api/v1/listenKey", params, nil, true)
 }
 
 // NewOrderRequest represents a new order request
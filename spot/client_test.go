@@ -2,9 +2,11 @@ package spot
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/yiplee/aster-go/common"
@@ -14,9 +16,11 @@ import (
 type MockHTTPClient struct {
 	Response *http.Response
 	Error    error
+	Request  *http.Request // last request sent
 }
 
 func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	m.Request = req
 	return m.Response, m.Error
 }
 
@@ -145,6 +149,38 @@ func TestGetExchangeInfo(t *testing.T) {
 	}
 }
 
+func TestGetExchangeInfoSeedsRateLimits(t *testing.T) {
+	config := common.DefaultConfig()
+	config.RateLimitMode = common.RateLimitFailFast
+	client := NewClient(config)
+
+	responseBody := `{
+		"timezone": "UTC",
+		"rateLimits": [
+			{"rateLimitType": "REQUEST_WEIGHT", "interval": "MINUTE", "intervalNum": 1, "limit": 2}
+		],
+		"symbols": []
+	}`
+	client.SetHTTPClient(&MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(responseBody)),
+			Header:     make(http.Header),
+		},
+	})
+
+	if _, err := client.GetExchangeInfo(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// ping weighs 1, so the third one exceeds the seeded budget
+	client.Ping()
+	client.Ping()
+	if err := client.Ping(); !errors.Is(err, common.ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded, got %v", err)
+	}
+}
+
 func TestGetOrderBook(t *testing.T) {
 	client := NewClient(nil)
 
@@ -266,6 +302,34 @@ func TestGetKlines(t *testing.T) {
 	}
 }
 
+func TestGetKlinesSkipsShortRows(t *testing.T) {
+	client := NewClient(nil)
+
+	// A short row is skipped rather than returned as a zero kline
+	responseBody := `[
+		[1499040000000, "0.01634790"],
+		[1499040000000, "0.01634790", "0.80000000", "0.01575800", "0.01577100", "148976.11427815", 1499644799999, "2434.19055334", 308, "1756.87402397", "28.46694368"]
+	]`
+	client.SetHTTPClient(&MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(responseBody)),
+			Header:     make(http.Header),
+		},
+	})
+
+	klines, err := client.GetKlines("BTCUSDT", Interval1h, 0, 0, 100)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(klines) != 1 {
+		t.Fatalf("Expected 1 kline, got %d", len(klines))
+	}
+	if klines[0].OpenTime != 1499040000000 || klines[0].NumberOfTrades != 308 {
+		t.Errorf("Unexpected kline %+v", klines[0])
+	}
+}
+
 func TestGetTicker24hr(t *testing.T) {
 	client := NewClient(nil)
 
@@ -536,3 +600,90 @@ func TestCreateListenKey(t *testing.T) {
 		t.Errorf("Expected listen key '%s', got '%s'", expectedKey, listenKey.ListenKey)
 	}
 }
+
+func TestQueryUserTrades(t *testing.T) {
+	client := NewClient(nil)
+	client.SetAPIKey("test-api-key", "test-secret-key")
+
+	mockClient := &MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(`[]`)),
+			Header:     make(http.Header),
+		},
+	}
+	client.SetHTTPClient(mockClient)
+
+	start := time.UnixMilli(1640995200000)
+	_, err := client.QueryUserTrades(&UserTradesRequest{
+		Symbol:     "BTCUSDT",
+		StartTime:  &start,
+		EndTime:    common.Ptr(start.Add(time.Hour)),
+		RecvWindow: 10000,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	query := mockClient.Request.URL.Query()
+	if query.Get("startTime") != "1640995200000" || query.Get("endTime") != "1640998800000" {
+		t.Errorf("Expected time bounds in milliseconds, got %s", mockClient.Request.URL.RawQuery)
+	}
+	if query.Get("recvWindow") != "10000" {
+		t.Errorf("Expected recvWindow override, got %s", query.Get("recvWindow"))
+	}
+	for _, key := range []string{"orderId", "fromId", "limit"} {
+		if query.Has(key) {
+			t.Errorf("Expected unset %s to be left out", key)
+		}
+	}
+}
+
+func TestGetAllOrdersWrapsQueryAllOrders(t *testing.T) {
+	client := NewClient(nil)
+	client.SetAPIKey("test-api-key", "test-secret-key")
+
+	mockClient := &MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(`[]`)),
+			Header:     make(http.Header),
+		},
+	}
+	client.SetHTTPClient(mockClient)
+
+	if _, err := client.GetAllOrders("BTCUSDT", 0, 1640995200000, 0, 10); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	query := mockClient.Request.URL.Query()
+	if query.Get("startTime") != "1640995200000" || query.Has("endTime") || query.Has("orderId") || query.Get("limit") != "10" {
+		t.Errorf("Unexpected query: %s", mockClient.Request.URL.RawQuery)
+	}
+	if query.Get("recvWindow") != "5000" {
+		t.Errorf("Expected default recvWindow, got %s", query.Get("recvWindow"))
+	}
+}
+
+func TestQueryAllOrdersSendsZeroOrderID(t *testing.T) {
+	client := NewClient(nil)
+	client.SetAPIKey("test-api-key", "test-secret-key")
+
+	mockClient := &MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(`[]`)),
+			Header:     make(http.Header),
+		},
+	}
+	client.SetHTTPClient(mockClient)
+
+	if _, err := client.QueryAllOrders(&AllOrdersRequest{Symbol: "BTCUSDT", OrderID: common.Ptr(int64(0))}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	query := mockClient.Request.URL.Query()
+	if !query.Has("orderId") || query.Get("orderId") != "0" {
+		t.Errorf("Expected orderId=0 to be sent, got %s", mockClient.Request.URL.RawQuery)
+	}
+}
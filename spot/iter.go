@@ -0,0 +1,122 @@
+package spot
+
+import (
+	"context"
+	"iter"
+	"strconv"
+	"time"
+
+	"github.com/yiplee/aster-go/common"
+)
+
+// Page sizes and time windows allowed by the history endpoints
+const (
+	maxTradesLimit    = 1000
+	maxAggTradesLimit = 1000
+	maxKlinesLimit    = 1000
+	maxOrdersLimit    = 1000
+
+	aggTradesWindow  = time.Hour
+	allOrdersWindow  = 24 * time.Hour
+	userTradesWindow = 24 * time.Hour
+)
+
+// IterHistoricalTrades iterates over the trades of a symbol from fromID on.
+// fromID is required: 1 starts from the first trade.
+func (c *Client) IterHistoricalTrades(ctx context.Context, symbol string, fromID int64) iter.Seq2[Trade, error] {
+	return common.IDPaginator[Trade]{
+		Limit: maxTradesLimit,
+		Fetch: func(ctx context.Context, fromID int64, limit int) ([]Trade, error) {
+			return c.GetHistoricalTradesCtx(ctx, symbol, limit, fromID)
+		},
+		ID: func(t Trade) int64 { return t.ID },
+	}.All(ctx, fromID)
+}
+
+// IterAggTrades iterates over the aggregate trades of a symbol between
+// startTime and endTime, or from fromID on when startTime is zero. One of
+// startTime and fromID is required.
+func (c *Client) IterAggTrades(ctx context.Context, symbol string, fromID, startTime, endTime int64) iter.Seq2[AggTrade, error] {
+	if startTime == 0 {
+		return common.IDPaginator[AggTrade]{
+			Limit: maxAggTradesLimit,
+			Fetch: func(ctx context.Context, fromID int64, limit int) ([]AggTrade, error) {
+				return c.GetAggTradesCtx(ctx, symbol, fromID, 0, 0, limit)
+			},
+			ID: func(t AggTrade) int64 { return t.A },
+		}.All(ctx, fromID)
+	}
+
+	return common.TimePaginator[AggTrade]{
+		Limit:  maxAggTradesLimit,
+		Window: aggTradesWindow,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]AggTrade, error) {
+			return c.GetAggTradesCtx(ctx, symbol, 0, startTime, endTime, limit)
+		},
+		Time: func(t AggTrade) int64 { return t.T },
+		Key:  func(t AggTrade) string { return strconv.FormatInt(t.A, 10) },
+	}.All(ctx, startTime, endTime)
+}
+
+// IterKlines iterates over the klines of a symbol between startTime and
+// endTime
+func (c *Client) IterKlines(ctx context.Context, symbol string, interval KlineInterval, startTime, endTime int64) iter.Seq2[Kline, error] {
+	return common.TimePaginator[Kline]{
+		Limit: maxKlinesLimit,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]Kline, error) {
+			return c.GetKlinesCtx(ctx, symbol, interval, startTime, endTime, limit)
+		},
+		Time: func(k Kline) int64 { return k.OpenTime },
+		Key:  func(k Kline) string { return strconv.FormatInt(k.OpenTime, 10) },
+	}.All(ctx, startTime, endTime)
+}
+
+// IterAllOrders iterates over the orders of a symbol between startTime and
+// endTime, or from orderID on when startTime is zero. One of startTime and
+// orderID is required.
+func (c *Client) IterAllOrders(ctx context.Context, symbol string, orderID, startTime, endTime int64) iter.Seq2[Order, error] {
+	if startTime == 0 {
+		return common.IDPaginator[Order]{
+			Limit: maxOrdersLimit,
+			Fetch: func(ctx context.Context, orderID int64, limit int) ([]Order, error) {
+				return c.GetAllOrdersCtx(ctx, symbol, orderID, 0, 0, limit)
+			},
+			ID: func(o Order) int64 { return o.OrderID },
+		}.All(ctx, orderID)
+	}
+
+	return common.TimePaginator[Order]{
+		Limit:  maxOrdersLimit,
+		Window: allOrdersWindow,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]Order, error) {
+			return c.GetAllOrdersCtx(ctx, symbol, 0, startTime, endTime, limit)
+		},
+		Time: func(o Order) int64 { return o.Time },
+		Key:  func(o Order) string { return strconv.FormatInt(o.OrderID, 10) },
+	}.All(ctx, startTime, endTime)
+}
+
+// IterUserTrades iterates over the account trades of a symbol between
+// startTime and endTime, or from fromID on when startTime is zero. One of
+// startTime and fromID is required.
+func (c *Client) IterUserTrades(ctx context.Context, symbol string, fromID, startTime, endTime int64) iter.Seq2[UserTrade, error] {
+	if startTime == 0 {
+		return common.IDPaginator[UserTrade]{
+			Limit: maxTradesLimit,
+			Fetch: func(ctx context.Context, fromID int64, limit int) ([]UserTrade, error) {
+				return c.GetUserTradesCtx(ctx, symbol, 0, 0, 0, fromID, limit)
+			},
+			ID: func(t UserTrade) int64 { return t.ID },
+		}.All(ctx, fromID)
+	}
+
+	return common.TimePaginator[UserTrade]{
+		Limit:  maxTradesLimit,
+		Window: userTradesWindow,
+		Fetch: func(ctx context.Context, startTime, endTime int64, limit int) ([]UserTrade, error) {
+			return c.GetUserTradesCtx(ctx, symbol, 0, startTime, endTime, 0, limit)
+		},
+		Time: func(t UserTrade) int64 { return t.Time },
+		Key:  func(t UserTrade) string { return strconv.FormatInt(t.ID, 10) },
+	}.All(ctx, startTime, endTime)
+}
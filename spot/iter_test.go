@@ -0,0 +1,63 @@
+package spot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/yiplee/aster-go/common"
+)
+
+func TestIterAggTrades(t *testing.T) {
+	var fromIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromID := r.URL.Query().Get("fromId")
+		fromIDs = append(fromIDs, fromID)
+
+		start, _ := strconv.Atoi(fromID)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var rows []map[string]any
+		for id := max(start, 1); id <= 2500 && len(rows) < limit; id++ {
+			rows = append(rows, map[string]any{"a": id, "p": "40000", "q": "0.1", "f": id, "l": id, "T": 1640995200000 + id, "m": false})
+		}
+		json.NewEncoder(w).Encode(rows)
+	}))
+	defer server.Close()
+
+	config := common.DefaultConfig()
+	config.BaseURL = server.URL
+	client := NewClient(config)
+
+	var last int64
+	count := 0
+	for trade, err := range client.IterAggTrades(t.Context(), "BTCUSDT", 1, 0, 0) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if trade.A != last+1 {
+			t.Fatalf("Expected trade %d, got %d", last+1, trade.A)
+		}
+		last = trade.A
+		count++
+	}
+
+	if count != 2500 {
+		t.Errorf("Expected 2500 trades, got %d", count)
+	}
+	if len(fromIDs) != 3 || fromIDs[1] != "1001" || fromIDs[2] != "2001" {
+		t.Errorf("Unexpected cursors: %v", fromIDs)
+	}
+
+	// Without a start id the endpoint would return the latest trades only
+	fromIDs = nil
+	for _, err := range client.IterAggTrades(t.Context(), "BTCUSDT", 0, 0, 0) {
+		if err == nil {
+			t.Error("Expected an error without a start id")
+		}
+	}
+	if len(fromIDs) != 0 {
+		t.Errorf("Expected no request, got %v", fromIDs)
+	}
+}
@@ -1,7 +1,10 @@
 package spot
 
 import (
+	"time"
+
 	"github.com/shopspring/decimal"
+	"github.com/yiplee/aster-go/common"
 )
 
 // OrderSide represents the order side
@@ -36,6 +39,18 @@ const (
 	OrderStatusExpired         OrderStatus = "EXPIRED"
 )
 
+// ExecutionType represents the execution type of an order update
+type ExecutionType string
+
+const (
+	ExecutionTypeNew      ExecutionType = "NEW"
+	ExecutionTypeCanceled ExecutionType = "CANCELED"
+	ExecutionTypeReplaced ExecutionType = "REPLACED"
+	ExecutionTypeRejected ExecutionType = "REJECTED"
+	ExecutionTypeTrade    ExecutionType = "TRADE"
+	ExecutionTypeExpired  ExecutionType = "EXPIRED"
+)
+
 // TimeInForce represents the time in force
 type TimeInForce string
 
@@ -69,15 +84,10 @@ const (
 
 // ExchangeInfo represents the exchange information
 type ExchangeInfo struct {
-	Timezone   string `json:"timezone"`
-	ServerTime int64  `json:"serverTime"`
-	RateLimits []struct {
-		RateLimitType string `json:"rateLimitType"`
-		Interval      string `json:"interval"`
-		IntervalNum   int    `json:"intervalNum"`
-		Limit         int    `json:"limit"`
-	} `json:"rateLimits"`
-	ExchangeFilters []any `json:"exchangeFilters"`
+	Timezone        string             `json:"timezone"`
+	ServerTime      int64              `json:"serverTime"`
+	RateLimits      []common.RateLimit `json:"rateLimits"`
+	ExchangeFilters []any              `json:"exchangeFilters"`
 	Assets          []struct {
 		Asset string `json:"asset"`
 	} `json:"assets"`
@@ -342,3 +352,58 @@ type CreateAPIKeyResponse struct {
 type ListenKeyResponse struct {
 	ListenKey string `json:"listenKey"`
 }
+
+// HistoricalTradesRequest represents a historical trades request
+type HistoricalTradesRequest struct {
+	Symbol     string
+	Limit      *int   // optional, page size
+	FromID     *int64 // optional, first id to return
+	RecvWindow int64  // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
+
+// AggTradesRequest represents an aggregate trades request
+type AggTradesRequest struct {
+	Symbol    string
+	FromID    *int64     // optional, first id to return
+	StartTime *time.Time // optional
+	EndTime   *time.Time // optional
+	Limit     *int       // optional, page size
+}
+
+// KlinesRequest represents a klines request
+type KlinesRequest struct {
+	Symbol    string
+	Interval  KlineInterval
+	StartTime *time.Time // optional
+	EndTime   *time.Time // optional
+	Limit     *int       // optional, page size
+}
+
+// QueryOrderRequest represents a query order request
+type QueryOrderRequest struct {
+	Symbol            string
+	OrderID           *int64 // optional
+	OrigClientOrderID string // optional
+	RecvWindow        int64  // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
+
+// AllOrdersRequest represents an all orders request
+type AllOrdersRequest struct {
+	Symbol     string
+	OrderID    *int64     // optional
+	StartTime  *time.Time // optional
+	EndTime    *time.Time // optional
+	Limit      *int       // optional, page size
+	RecvWindow int64      // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
+
+// UserTradesRequest represents a user trades request
+type UserTradesRequest struct {
+	Symbol     string     // optional
+	OrderID    *int64     // optional
+	StartTime  *time.Time // optional
+	EndTime    *time.Time // optional
+	FromID     *int64     // optional, first id to return
+	Limit      *int       // optional, page size
+	RecvWindow int64      // optional, overrides ClientConfig.RecvWindow, in milliseconds
+}
@@ -0,0 +1,455 @@
+package spot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/yiplee/aster-go/common"
+)
+
+const (
+	// defaultKeepAlive is how often listen keys, valid for 60 minutes, are
+	// kept alive
+	defaultKeepAlive = 30 * time.Minute
+	// listenKeyRetry is the delay before retrying to create a listen key
+	listenKeyRetry = 10 * time.Second
+)
+
+// UserDataStream delivers the account and order updates of the spot user
+// data stream. It creates the listen key on Connect, keeps it alive, and
+// creates a new one when it expires or the connection is restored.
+type UserDataStream struct {
+	*common.WebSocketClient
+	client *Client
+	states <-chan common.StateChange
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	renewC chan struct{}
+
+	// keyMu serializes the changes of listen key
+	keyMu sync.Mutex
+
+	mu        sync.Mutex
+	started   bool
+	listenKey string
+	remove    func() error
+	keepAlive time.Duration
+	retry     time.Duration
+	hooks     userDataHooks
+}
+
+// userDataHooks are the event handlers of a user data stream
+type userDataHooks struct {
+	onExecutionReport func(report *ExecutionReport)
+	onAccountPosition func(position *AccountPosition)
+	onBalanceUpdate   func(update *BalanceUpdate)
+	onListenKeyError  func(err error)
+}
+
+// NewUserDataStream creates a user data stream for the account of client
+func NewUserDataStream(client *Client, testnet bool) *UserDataStream {
+	return newUserDataStream(client, websocketURL(testnet))
+}
+
+func newUserDataStream(client *Client, baseURL string) *UserDataStream {
+	ws := common.NewWebSocketClient(baseURL)
+	ctx, cancel := context.WithCancel(context.Background())
+	return &UserDataStream{
+		WebSocketClient: ws,
+		client:          client,
+		states:          ws.StateChanges(),
+		ctx:             ctx,
+		cancel:          cancel,
+		renewC:          make(chan struct{}, 1),
+		keepAlive:       defaultKeepAlive,
+		retry:           listenKeyRetry,
+	}
+}
+
+// SetOnExecutionReport sets the handler of order updates
+func (s *UserDataStream) SetOnExecutionReport(hook func(report *ExecutionReport)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.onExecutionReport = hook
+}
+
+// SetOnAccountPosition sets the handler of account balance changes
+func (s *UserDataStream) SetOnAccountPosition(hook func(position *AccountPosition)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.onAccountPosition = hook
+}
+
+// SetOnBalanceUpdate sets the handler of deposits, withdrawals and transfers
+func (s *UserDataStream) SetOnBalanceUpdate(hook func(update *BalanceUpdate)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.onBalanceUpdate = hook
+}
+
+// SetOnListenKeyError sets a hook called when the listen key can't be kept
+// alive or created. Creating it is retried until it succeeds; no event is
+// received meanwhile.
+func (s *UserDataStream) SetOnListenKeyError(hook func(err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.onListenKeyError = hook
+}
+
+// SetKeepAliveInterval sets how often the listen key is kept alive, 30
+// minutes by default. Listen keys expire 60 minutes after the last
+// keepalive.
+func (s *UserDataStream) SetKeepAliveInterval(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keepAlive = interval
+}
+
+// ListenKey returns the current listen key, empty before Connect
+func (s *UserDataStream) ListenKey() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listenKey
+}
+
+// Connect creates a listen key, connects to its stream and starts keeping
+// the key alive. When connecting fails, the listen key is closed and Connect
+// may be called again.
+func (s *UserDataStream) Connect() error {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return fmt.Errorf("user data stream already connected")
+	}
+	s.started = true
+	s.mu.Unlock()
+
+	err := s.renew()
+	if err == nil {
+		if err = s.WebSocketClient.Connect(); err != nil {
+			// The unused key would stay valid for an hour
+			s.closeListenKey()
+		}
+	}
+	if err != nil {
+		s.mu.Lock()
+		s.started = false
+		s.mu.Unlock()
+		return err
+	}
+	go s.run()
+	return nil
+}
+
+// Disconnect closes the connection and the listen key
+func (s *UserDataStream) Disconnect() error {
+	s.cancel()
+	err := s.WebSocketClient.Disconnect()
+	if closeErr := s.closeListenKey(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// closeListenKey unsubscribes the stream of the listen key and closes it
+func (s *UserDataStream) closeListenKey() error {
+	// Wait for a listen key being created
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+	s.mu.Lock()
+	listenKey, remove := s.listenKey, s.remove
+	s.listenKey, s.remove = "", nil
+	s.mu.Unlock()
+
+	if listenKey == "" {
+		return nil
+	}
+	remove()
+	if err := s.client.CloseListenKey(listenKey); err != nil {
+		return fmt.Errorf("failed to close listen key: %w", err)
+	}
+	return nil
+}
+
+// run keeps the listen key alive, and renews it when it expires or the
+// connection is restored, until Disconnect is called
+func (s *UserDataStream) run() {
+	s.mu.Lock()
+	timer := time.NewTimer(s.keepAlive)
+	s.mu.Unlock()
+	defer timer.Stop()
+
+	// renewing is set while the listen key is to be created again
+	reconnecting, renewing := false, false
+	for {
+		var err error
+		select {
+		case <-s.ctx.Done():
+			return
+		case change := <-s.states:
+			switch {
+			case change.State == common.StateReconnecting:
+				reconnecting = true
+				continue
+			case change.State != common.StateConnected || !reconnecting:
+				continue
+			}
+			// The listen key may have expired while disconnected
+			reconnecting = false
+			err = s.renew()
+		case <-s.renewC:
+			err = s.renew()
+		case <-timer.C:
+			if renewing {
+				err = s.renew()
+			} else if err = s.extend(); err != nil {
+				s.listenKeyError(err)
+				err = s.renew()
+			}
+		}
+
+		renewing = err != nil
+		if renewing {
+			s.listenKeyError(err)
+		}
+		s.mu.Lock()
+		delay := s.keepAlive
+		if renewing {
+			delay = s.retry
+		}
+		s.mu.Unlock()
+		timer.Reset(delay)
+	}
+}
+
+// extend keeps the current listen key alive
+func (s *UserDataStream) extend() error {
+	listenKey := s.ListenKey()
+	if err := s.client.KeepAliveListenKeyCtx(s.ctx, listenKey); err != nil {
+		return fmt.Errorf("failed to keep listen key alive: %w", err)
+	}
+	s.Logger().Debug("aster: listen key kept alive")
+	return nil
+}
+
+// renew creates a listen key, which is the current one while it is valid,
+// and moves the stream to it when it changed
+func (s *UserDataStream) renew() error {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+
+	resp, err := s.client.CreateListenKeyCtx(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create listen key: %w", err)
+	}
+	listenKey := resp.ListenKey
+	if listenKey == s.ListenKey() {
+		return nil
+	}
+
+	// Subscribe the new key before dropping the old one. A failed request
+	// leaves the key subscribed on reconnect, unless it was rejected.
+	remove, err := s.AddHandler(listenKey, s.handle(listenKey))
+	if remove == nil {
+		return fmt.Errorf("failed to subscribe listen key: %w", err)
+	}
+
+	s.mu.Lock()
+	removeOld := s.remove
+	s.listenKey, s.remove = listenKey, remove
+	s.mu.Unlock()
+
+	s.Logger().Info("aster: listen key created")
+	if removeOld != nil {
+		removeOld()
+	}
+	return nil
+}
+
+// listenKeyError logs err and passes it to the listen key error hook
+func (s *UserDataStream) listenKeyError(err error) {
+	s.Logger().Warn("aster: listen key failed", "error", err)
+	s.mu.Lock()
+	hook := s.hooks.onListenKeyError
+	s.mu.Unlock()
+	if hook != nil {
+		hook(err)
+	}
+}
+
+// handle returns the handler of the events of the stream of a listen key
+func (s *UserDataStream) handle(stream string) func(json.RawMessage) {
+	return func(data json.RawMessage) {
+		f, err := common.NewEventFields(data)
+		if err != nil {
+			s.ReportParseError(stream, data, err)
+			return
+		}
+
+		s.mu.Lock()
+		hooks := s.hooks
+		s.mu.Unlock()
+
+		switch eventType := f.String("e"); eventType {
+		case "executionReport":
+			deliver(s, stream, data, parseExecutionReport, hooks.onExecutionReport)
+		case "outboundAccountPosition":
+			deliver(s, stream, data, parseAccountPosition, hooks.onAccountPosition)
+		case "balanceUpdate":
+			deliver(s, stream, data, parseBalanceUpdate, hooks.onBalanceUpdate)
+		case "listenKeyExpired":
+			s.Logger().Info("aster: listen key expired")
+			select {
+			case s.renewC <- struct{}{}:
+			default:
+			}
+		default:
+			s.Logger().Debug("aster: user data event ignored", "type", eventType)
+		}
+	}
+}
+
+// deliver parses an event and passes it to hook. Events that fail to parse
+// are reported, and dropped unless only their fields mismatch in strict mode.
+func deliver[T any](s *UserDataStream, stream string, data json.RawMessage, parse func(data json.RawMessage, strict bool) (*T, error), hook func(*T)) {
+	v, err := parse(data, s.StrictParsing())
+	if err != nil {
+		s.ReportParseError(stream, data, err)
+		if !errors.As(err, &common.FieldsError{}) {
+			return
+		}
+	}
+	if hook != nil {
+		hook(v)
+	}
+}
+
+// ExecutionReport is an order update, sent when an order is placed, filled,
+// canceled, rejected or expires
+type ExecutionReport struct {
+	EventTime           int64
+	Symbol              string
+	ClientOrderID       string
+	Side                OrderSide
+	Type                OrderType
+	TimeInForce         TimeInForce
+	Quantity            decimal.Decimal
+	Price               decimal.Decimal
+	StopPrice           decimal.Decimal
+	IcebergQty          decimal.Decimal
+	OrigClientOrderID   string // of the canceled order, for cancels
+	ExecutionType       ExecutionType
+	Status              OrderStatus
+	RejectReason        string
+	OrderID             int64
+	LastFilledQty       decimal.Decimal
+	CumulativeFilledQty decimal.Decimal
+	LastFilledPrice     decimal.Decimal
+	Commission          decimal.Decimal
+	CommissionAsset     string
+	TransactionTime     int64
+	TradeID             int64
+	IsWorking           bool
+	IsMaker             bool
+	CreationTime        int64
+	CumulativeQuoteQty  decimal.Decimal
+	LastQuoteQty        decimal.Decimal
+	QuoteOrderQty       decimal.Decimal
+}
+
+// AccountPosition is an account update, sent with the balances that changed
+type AccountPosition struct {
+	EventTime      int64
+	LastUpdateTime int64
+	Balances       []Balance
+}
+
+// BalanceUpdate is a balance change from a deposit, a withdrawal or a
+// transfer
+type BalanceUpdate struct {
+	EventTime int64
+	Asset     string
+	Delta     decimal.Decimal
+	ClearTime int64
+}
+
+func parseExecutionReport(data json.RawMessage, strict bool) (*ExecutionReport, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
+	}
+	f.Optional("g", "I", "M", "W", "V", "O", "Z", "Y", "Q")
+
+	report := &ExecutionReport{
+		EventTime:           f.Int("E"),
+		Symbol:              f.String("s"),
+		ClientOrderID:       f.String("c"),
+		Side:                OrderSide(f.String("S")),
+		Type:                OrderType(f.String("o")),
+		TimeInForce:         TimeInForce(f.String("f")),
+		Quantity:            f.Decimal("q"),
+		Price:               f.Decimal("p"),
+		StopPrice:           f.Decimal("P"),
+		IcebergQty:          f.Decimal("F"),
+		OrigClientOrderID:   f.String("C"),
+		ExecutionType:       ExecutionType(f.String("x")),
+		Status:              OrderStatus(f.String("X")),
+		RejectReason:        f.String("r"),
+		OrderID:             f.Int("i"),
+		LastFilledQty:       f.Decimal("l"),
+		CumulativeFilledQty: f.Decimal("z"),
+		LastFilledPrice:     f.Decimal("L"),
+		Commission:          f.Decimal("n"),
+		CommissionAsset:     f.String("N"),
+		TransactionTime:     f.Int("T"),
+		TradeID:             f.Int("t"),
+		IsWorking:           f.Bool("w"),
+		IsMaker:             f.Bool("m"),
+		CreationTime:        f.Int("O"),
+		CumulativeQuoteQty:  f.Decimal("Z"),
+		LastQuoteQty:        f.Decimal("Y"),
+		QuoteOrderQty:       f.Decimal("Q"),
+	}
+	return report, f.Err(strict)
+}
+
+func parseAccountPosition(data json.RawMessage, strict bool) (*AccountPosition, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	position := &AccountPosition{
+		EventTime:      f.Int("E"),
+		LastUpdateTime: f.Int("u"),
+	}
+	for _, b := range f.Objects("B") {
+		position.Balances = append(position.Balances, Balance{
+			Asset:  b.String("a"),
+			Free:   b.Decimal("f"),
+			Locked: b.Decimal("l"),
+		})
+	}
+	return position, f.Err(strict)
+}
+
+func parseBalanceUpdate(data json.RawMessage, strict bool) (*BalanceUpdate, error) {
+	f, err := common.NewEventFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &BalanceUpdate{
+		EventTime: f.Int("E"),
+		Asset:     f.String("a"),
+		Delta:     f.Decimal("d"),
+		ClearTime: f.Int("T"),
+	}
+	return update, f.Err(strict)
+}
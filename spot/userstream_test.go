@@ -0,0 +1,238 @@
+package spot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yiplee/aster-go/common"
+)
+
+// fakeUserDataServer serves the listen key endpoint, returning a new key on
+// every call, and the stream of the keys
+type fakeUserDataServer struct {
+	*httptest.Server
+	keys      atomic.Int32
+	created   chan string
+	keptAlive chan string
+	closed    chan string
+	conns     chan *websocket.Conn
+	streams   chan string // streams in the URL of the connections
+	messages  chan string // method and stream of the requests
+	writeMu   sync.Mutex
+}
+
+func newFakeUserDataServer(t *testing.T) *fakeUserDataServer {
+	s := &fakeUserDataServer{
+		created:   make(chan string, 16),
+		keptAlive: make(chan string, 16),
+		closed:    make(chan string, 16),
+		conns:     make(chan *websocket.Conn, 16),
+		streams:   make(chan string, 16),
+		messages:  make(chan string, 16),
+	}
+
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/listenKey", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.Method {
+		case http.MethodPost:
+			key := fmt.Sprintf("key%d", s.keys.Add(1))
+			s.created <- key
+			json.NewEncoder(w).Encode(map[string]string{"listenKey": key})
+			return
+		case http.MethodPut:
+			select {
+			case s.keptAlive <- r.Form.Get("listenKey"):
+			default:
+			}
+		case http.MethodDelete:
+			s.closed <- r.Form.Get("listenKey")
+		}
+		w.Write([]byte("{}"))
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.streams <- r.URL.Query().Get("streams")
+		s.conns <- conn
+		for {
+			var msg struct {
+				Method string   `json:"method"`
+				Params []string `json:"params"`
+				ID     int64    `json:"id"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			s.messages <- msg.Method + " " + strings.Join(msg.Params, ",")
+			s.send(conn, map[string]any{"result": nil, "id": msg.ID})
+		}
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// send writes v to conn, which may be used by the server handler at the
+// same time
+func (s *fakeUserDataServer) send(conn *websocket.Conn, v any) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	conn.WriteJSON(v)
+}
+
+// next waits for a value on ch
+func next[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the user data stream")
+		var zero T
+		return zero
+	}
+}
+
+func TestUserDataStream(t *testing.T) {
+	server := newFakeUserDataServer(t)
+
+	client := NewClient(&common.ClientConfig{
+		APIKey:    "key",
+		SecretKey: "secret",
+		BaseURL:   server.URL,
+	})
+	stream := newUserDataStream(client, "ws"+strings.TrimPrefix(server.URL, "http"))
+	stream.SetReconnectPolicy(common.ReconnectPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+	stream.SetKeepAliveInterval(100 * time.Millisecond)
+
+	reports := make(chan *ExecutionReport, 1)
+	positions := make(chan *AccountPosition, 1)
+	updates := make(chan *BalanceUpdate, 1)
+	stream.SetOnExecutionReport(func(report *ExecutionReport) { reports <- report })
+	stream.SetOnAccountPosition(func(position *AccountPosition) { positions <- position })
+	stream.SetOnBalanceUpdate(func(update *BalanceUpdate) { updates <- update })
+
+	if err := stream.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer stream.Disconnect()
+	if err := stream.Connect(); err == nil {
+		t.Error("Expected an error connecting twice")
+	}
+	if key := next(t, server.created); key != "key1" || stream.ListenKey() != "key1" {
+		t.Fatalf("Expected listen key key1, got %s", stream.ListenKey())
+	}
+	if streams := next(t, server.streams); streams != "key1" {
+		t.Fatalf("Expected to connect to key1, got %s", streams)
+	}
+	conn := next(t, server.conns)
+
+	event := func(data string) map[string]any {
+		return map[string]any{"stream": stream.ListenKey(), "data": json.RawMessage(data)}
+	}
+	server.send(conn, event(`{"e":"executionReport","E":1,"s":"BTCUSDT","c":"abc","S":"BUY","o":"LIMIT","f":"GTC","q":"1.0","p":"40000","P":"0","F":"0","C":"","x":"TRADE","X":"FILLED","r":"NONE","i":42,"l":"1.0","z":"1.0","L":"40000","n":"0.001","N":"BNB","T":2,"t":7,"w":false,"m":true}`))
+	if report := next(t, reports); report.OrderID != 42 || report.ExecutionType != ExecutionTypeTrade || report.Status != OrderStatusFilled || report.LastFilledPrice.String() != "40000" {
+		t.Errorf("Unexpected execution report: %+v", report)
+	}
+	server.send(conn, event(`{"e":"outboundAccountPosition","E":1,"u":2,"B":[{"a":"BTC","f":"1.5","l":"0"},{"a":"USDT","f":"100","l":"20"}]}`))
+	if position := next(t, positions); len(position.Balances) != 2 || position.Balances[1].Locked.String() != "20" {
+		t.Errorf("Unexpected account position: %+v", position)
+	}
+	server.send(conn, event(`{"e":"balanceUpdate","E":1,"a":"USDT","d":"-10.5","T":2}`))
+	if update := next(t, updates); update.Asset != "USDT" || update.Delta.String() != "-10.5" {
+		t.Errorf("Unexpected balance update: %+v", update)
+	}
+
+	if key := next(t, server.keptAlive); key != "key1" {
+		t.Errorf("Expected key1 to be kept alive, got %s", key)
+	}
+
+	// An expired key is replaced, the new one subscribed first
+	server.send(conn, event(`{"e":"listenKeyExpired","E":1,"listenKey":"key1"}`))
+	next(t, server.created)
+	if msg := next(t, server.messages); msg != "SUBSCRIBE key2" {
+		t.Fatalf("Unexpected request: %s", msg)
+	}
+	if msg := next(t, server.messages); msg != "UNSUBSCRIBE key1" {
+		t.Fatalf("Unexpected request: %s", msg)
+	}
+
+	// A new key is created once the connection is restored
+	conn.Close()
+	if streams := next(t, server.streams); streams != "key2" {
+		t.Fatalf("Expected to reconnect to key2, got %s", streams)
+	}
+	next(t, server.created)
+	if msg := next(t, server.messages); msg != "SUBSCRIBE key3" {
+		t.Fatalf("Unexpected request: %s", msg)
+	}
+	if msg := next(t, server.messages); msg != "UNSUBSCRIBE key2" {
+		t.Fatalf("Unexpected request: %s", msg)
+	}
+
+	if err := stream.Disconnect(); err != nil {
+		t.Fatalf("Disconnect failed: %v", err)
+	}
+	if key := next(t, server.closed); key != "key3" {
+		t.Errorf("Expected key3 to be closed, got %s", key)
+	}
+}
+
+func TestUserDataStreamConnectFailure(t *testing.T) {
+	server := newFakeUserDataServer(t)
+
+	client := NewClient(&common.ClientConfig{
+		APIKey:    "key",
+		SecretKey: "secret",
+		BaseURL:   server.URL,
+	})
+	// No stream is served there
+	stream := newUserDataStream(client, "ws"+strings.TrimPrefix(server.URL, "http")+"/missing")
+	stream.SetReconnect(false, 0)
+
+	if err := stream.Connect(); err == nil {
+		t.Fatal("Expected Connect to fail")
+	}
+	if key := next(t, server.closed); key != "key1" {
+		t.Errorf("Expected key1 to be closed, got %s", key)
+	}
+	if stream.ListenKey() != "" || len(stream.Subscriptions()) != 0 {
+		t.Errorf("Expected the listen key to be dropped, got %s and %v", stream.ListenKey(), stream.Subscriptions())
+	}
+
+	// Connect may be retried
+	if err := stream.Connect(); err == nil {
+		t.Fatal("Expected Connect to fail again")
+	}
+	if key := next(t, server.closed); key != "key2" {
+		t.Errorf("Expected key2 to be closed, got %s", key)
+	}
+}
+
+func TestParseUserDataEvents(t *testing.T) {
+	position, err := parseAccountPosition(json.RawMessage(`{"e":"outboundAccountPosition","E":1,"u":2,"B":[{"a":"BTC","f":"1","l":"0","x":1},{"a":"ETH","f":"2"}]}`), true)
+	var fieldsErr common.FieldsError
+	if !errors.As(err, &fieldsErr) || fieldsErr.Error() != "missing fields B.l; unknown fields B.x" {
+		t.Errorf("Expected the fields of the balances to be checked, got %v", err)
+	}
+	if len(position.Balances) != 2 || position.Balances[1].Free.String() != "2" {
+		t.Errorf("Unexpected balances: %+v", position.Balances)
+	}
+
+	if _, err := parseBalanceUpdate(json.RawMessage(`{"e":"balanceUpdate","a":"BTC","d":"x"}`), false); err == nil {
+		t.Error("Expected an error for a malformed delta")
+	}
+}
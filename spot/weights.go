@@ -0,0 +1,77 @@
+package spot
+
+import "github.com/yiplee/aster-go/common"
+
+// endpointWeights lists the rate limit cost of every spot REST endpoint
+var endpointWeights = map[string]common.EndpointWeight{
+	"GET /api/v1/ping":                       {Weight: 1},
+	"GET /api/v1/time":                       {Weight: 1},
+	"GET /api/v1/exchangeInfo":               {Weight: 1},
+	"GET /api/v1/depth":                      {WeightFunc: depthWeight},
+	"GET /api/v1/trades":                     {Weight: 1},
+	"GET /api/v1/historicalTrades":           {Weight: 20},
+	"GET /api/v1/aggTrades":                  {Weight: 20},
+	"GET /api/v1/klines":                     {WeightFunc: klinesWeight},
+	"GET /api/v1/ticker/24hr":                {WeightFunc: symbolWeight(1, 40)},
+	"GET /api/v1/ticker/price":               {WeightFunc: symbolWeight(1, 2)},
+	"GET /api/v1/ticker/bookTicker":          {WeightFunc: symbolWeight(1, 2)},
+	"GET /api/v1/commissionRate":             {Weight: 20},
+	"POST /api/v1/order":                     {Weight: 1, Orders: 1},
+	"DELETE /api/v1/order":                   {Weight: 1},
+	"GET /api/v1/order":                      {Weight: 1},
+	"GET /api/v1/openOrders":                 {WeightFunc: symbolWeight(1, 40)},
+	"GET /api/v1/allOrders":                  {Weight: 5},
+	"GET /api/v1/account":                    {Weight: 5},
+	"GET /api/v1/userTrades":                 {Weight: 5},
+	"POST /api/v1/asset/wallet/transfer":     {Weight: 5},
+	"GET /api/v1/aster/withdraw/estimateFee": {Weight: 1},
+	"POST /api/v1/aster/user-withdraw":       {Weight: 1},
+	"POST /api/v1/getNonce":                  {Weight: 1},
+	"POST /api/v1/createApiKey":              {Weight: 1},
+	"POST /api/v1/listenKey":                 {Weight: 1},
+	"PUT /api/v1/listenKey":                  {Weight: 1},
+	"DELETE /api/v1/listenKey":               {Weight: 1},
+}
+
+// depthWeight returns the weight of an order book request for its limit
+func depthWeight(params map[string]any) int {
+	limit, _ := params["limit"].(int)
+	switch {
+	case limit == 0 || limit > 50 && limit <= 100:
+		return 5
+	case limit <= 50:
+		return 2
+	case limit <= 500:
+		return 10
+	default:
+		return 20
+	}
+}
+
+// klinesWeight returns the weight of a klines request for its limit
+func klinesWeight(params map[string]any) int {
+	limit, _ := params["limit"].(int)
+	switch {
+	case limit == 0:
+		return 2
+	case limit < 100:
+		return 1
+	case limit < 500:
+		return 2
+	case limit <= 1000:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// symbolWeight returns a weight function for endpoints that are cheaper
+// when restricted to a single symbol
+func symbolWeight(withSymbol, withoutSymbol int) func(map[string]any) int {
+	return func(params map[string]any) int {
+		if symbol, _ := params["symbol"].(string); symbol != "" {
+			return withSymbol
+		}
+		return withoutSymbol
+	}
+}